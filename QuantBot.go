@@ -1,9 +1,20 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/geniustag/QuantBot/cli"
 	"github.com/geniustag/QuantBot/handler"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] != "server" {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	handler.Server()
 }
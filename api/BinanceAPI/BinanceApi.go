@@ -22,6 +22,7 @@ const (
 	DEPTH_URI              = "depth?symbol=%s&limit=%d"
 	ACCOUNT_URI            = "account?"
 	ORDER_URI              = "order?"
+	CANCEL_REPLACE_URI     = "order/cancelReplace?"
 	UNFINISHED_ORDERS_INFO = "openOrders?"
 )
 
@@ -146,6 +147,36 @@ func CancelOrder(orderId string, symbol string) (bool, error) {
 	return true, nil
 }
 
+// 撤销订单并以新的价格和数量下一个限价单, 撤单失败时不会下单
+func CancelReplaceOrder(orderId, amount, price string, symbol string, orderSide string) (map[string]interface{}, error) {
+	path := API_V3 + CANCEL_REPLACE_URI
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", orderSide)
+	params.Set("type", "LIMIT")
+	params.Set("timeInForce", "GTC")
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE")
+	params.Set("cancelOrderId", orderId)
+	params.Set("quantity", amount)
+	params.Set("price", price)
+
+	buildParamsSigned(&params)
+
+	resp, err := HttpPostForm2(httpClient, path, params, map[string]string{"X-MBX-APIKEY": ACCESS_KEY})
+	if err != nil {
+		return nil, err
+	}
+
+	respmap := make(map[string]interface{})
+	err = json.Unmarshal(resp, &respmap)
+	if err != nil {
+		log.Println(string(resp))
+		return nil, err
+	}
+
+	return respmap, nil
+}
+
 func GetOneOrder(orderId string, symbol string) (map[string]interface{}, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
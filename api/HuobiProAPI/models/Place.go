@@ -15,3 +15,30 @@ type PlaceReturn struct {
 	ErrCode string `json:"err-code"`
 	ErrMsg  string `json:"err-msg"`
 }
+
+type BatchPlaceReturn struct {
+	Status  string        `json:"status"`
+	Data    []BatchPlaced `json:"data"` // 与请求中的订单顺序相同
+	ErrCode string        `json:"err-code"`
+	ErrMsg  string        `json:"err-msg"`
+}
+
+type BatchPlaced struct {
+	OrderID int64  `json:"order-id"` // 下单成功时的订单ID
+	ErrCode string `json:"err-code"`
+	ErrMsg  string `json:"err-msg"`
+}
+
+type BatchCancelReturn struct {
+	Status string `json:"status"`
+	Data   struct {
+		Success []string `json:"success"` // 撤单成功的订单ID
+		Failed  []struct {
+			OrderID string `json:"order-id"`
+			ErrCode string `json:"err-code"`
+			ErrMsg  string `json:"err-msg"`
+		} `json:"failed"`
+	} `json:"data"`
+	ErrCode string `json:"err-code"`
+	ErrMsg  string `json:"err-msg"`
+}
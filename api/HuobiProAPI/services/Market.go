@@ -10,8 +10,6 @@ import (
 	"github.com/geniustag/QuantBot/api/HuobiProAPI/untils"
 )
 
-// 批量操作的API下个版本再封装
-
 //------------------------------------------------------------------------------------------
 // 交易API
 
@@ -223,6 +221,46 @@ func SubmitCancel(strOrderID string) (r models.PlaceReturn, err error) {
 	return
 }
 
+// 批量下单, 一次最多10个订单
+// params: 下单信息
+// return: BatchPlaceReturn对象
+func BatchPlace(params []models.PlaceRequestParams) (r models.BatchPlaceReturn, err error) {
+	listParams := []map[string]string{}
+	for _, p := range params {
+		mapParams := make(map[string]string)
+		mapParams["account-id"] = p.AccountID
+		mapParams["amount"] = p.Amount
+		if 0 < len(p.Price) {
+			mapParams["price"] = p.Price
+		}
+		if 0 < len(p.Source) {
+			mapParams["source"] = p.Source
+		}
+		mapParams["symbol"] = p.Symbol
+		mapParams["type"] = p.Type
+		listParams = append(listParams, mapParams)
+	}
+
+	strRequest := "/v1/order/batch-orders"
+
+	jsonPlaceReturn := untils.ApiKeyPostJSON(listParams, strRequest)
+	err = json.Unmarshal([]byte(jsonPlaceReturn), &r)
+
+	return
+}
+
+// 批量撤销订单, 一次最多50个订单
+// strOrderIDs: 订单ID列表
+// return: BatchCancelReturn对象
+func BatchCancel(strOrderIDs []string) (r models.BatchCancelReturn, err error) {
+	strRequest := "/v1/order/orders/batchcancel"
+
+	jsonCancelReturn := untils.ApiKeyPostJSON(map[string][]string{"order-ids": strOrderIDs}, strRequest)
+	err = json.Unmarshal([]byte(jsonCancelReturn), &r)
+
+	return
+}
+
 // 根据订单ID查询订单详情
 func GetOrderDetail(strOrderID string) (r models.OrderDetailReturn, err error) {
 	strRequest := fmt.Sprintf("/v1/order/orders/%s", strOrderID)
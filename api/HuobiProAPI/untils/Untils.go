@@ -97,14 +97,22 @@ func HttpPostRequest(strUrl string, mapParams map[string]string) string {
 
 	//==========================================================
 	//
-	httpClient := &http.Client{}
-
 	jsonParams := ""
 	if nil != mapParams {
 		bytesParams, _ := json.Marshal(mapParams)
 		jsonParams = string(bytesParams)
 	}
 
+	return httpPost(strUrl, jsonParams)
+}
+
+// 发出JSON请求体的HTTP POST请求
+// strUrl: 请求的URL
+// jsonParams: JSON格式的请求体
+// return: 请求结果
+func httpPost(strUrl, jsonParams string) string {
+	httpClient := &http.Client{}
+
 	request, err := http.NewRequest("POST", strUrl, strings.NewReader(jsonParams))
 	if nil != err {
 		return err.Error()
@@ -169,6 +177,29 @@ func ApiKeyPost(mapParams map[string]string, strRequestPath string) string {
 	return HttpPostRequest(strUrl, mapParams)
 }
 
+// 进行签名后的HTTP POST请求, 请求体可以是数组等任意JSON, 用于批量操作的API
+// params: 请求参数, 序列化为JSON后作为请求体
+// strRequest: API路由路径
+// return: 请求结果
+func ApiKeyPostJSON(params interface{}, strRequestPath string) string {
+	strMethod := "POST"
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05")
+
+	mapParams2Sign := make(map[string]string)
+	mapParams2Sign["AccessKeyId"] = config.ACCESS_KEY
+	mapParams2Sign["SignatureMethod"] = "HmacSHA256"
+	mapParams2Sign["SignatureVersion"] = "2"
+	mapParams2Sign["Timestamp"] = timestamp
+
+	hostName := "api.huobi.pro"
+
+	mapParams2Sign["Signature"] = CreateSign(mapParams2Sign, strMethod, hostName, strRequestPath, config.SECRET_KEY)
+	strUrl := config.TRADE_URL + strRequestPath + "?" + Map2UrlQuery(MapValueEncodeURI(mapParams2Sign))
+
+	bytesParams, _ := json.Marshal(params)
+	return httpPost(strUrl, string(bytesParams))
+}
+
 // 构造签名
 // mapParams: 送进来参与签名的参数, Map类型
 // strMethod: 请求的方法 GET, POST......
@@ -0,0 +1,427 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geniustag/QuantBot/config"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// adapterTimeout is how long a call waits for the response of the adapter process
+var adapterTimeout = 30 * time.Second
+
+// the adapter processes are declared in the [adapters] section of config.ini, the key is the exchange type
+// and the value is the command line, like:
+//
+//	[adapters]
+//	myvenue = /opt/adapters/myvenue --testnet
+//
+// one process is started for every exchange type when it is used at the first time, and restarted if it exits.
+// QuantBot writes one JSON-RPC 2.0 request per line to the stdin of the process, and reads one response per line
+// from its stdout, the stderr is copied to the log of QuantBot.
+//
+// the "periods" of the exchange config, like {"periods": ["M", "H", "D"]}, are the periods the adapter provides,
+// the records of other periods are resampled from them, every period is requested from the adapter if it is empty.
+var (
+	adapters   = make(map[string]*adapterProcess)
+	adaptersMu sync.Mutex
+)
+
+// AdapterTypes returns the exchange types served by adapter processes
+func AdapterTypes() (types []string) {
+	for typ := range config.Section("adapters") {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return
+}
+
+// AdapterParams are the params of every request, Option identifies the exchange account
+type AdapterParams struct {
+	Option    AdapterOption `json:"option"`
+	StockType string        `json:"stockType,omitempty"`
+	TradeType string        `json:"tradeType,omitempty"`
+	Period    string        `json:"period,omitempty"`
+	Price     float64       `json:"price,omitempty"`
+	Amount    float64       `json:"amount,omitempty"`
+	ID        string        `json:"id,omitempty"`
+	Size      int           `json:"size,omitempty"`
+	Begin     int64         `json:"begin,omitempty"` //unix 秒
+	End       int64         `json:"end,omitempty"`
+	Order     *Order        `json:"order,omitempty"`
+}
+
+// AdapterOption is the exchange account sent to the adapter process
+type AdapterOption struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	Config    string `json:"config"`
+}
+
+type adapterRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  AdapterParams `json:"params"`
+}
+
+type adapterResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// adapterProcess is a running adapter process shared by all the exchanges of its type
+type adapterProcess struct {
+	typ     string
+	command string
+
+	mu      sync.Mutex
+	stdin   io.WriteCloser
+	seq     int64
+	pending map[int64]chan adapterResponse
+}
+
+// getAdapter returns the process of the exchange type, it is started if it is not running
+func getAdapter(typ string) (p *adapterProcess, err error) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	if p = adapters[typ]; p == nil {
+		command := config.Section("adapters")[typ]
+		if command == "" {
+			return nil, fmt.Errorf("No adapter process is declared for %v", typ)
+		}
+		p = &adapterProcess{typ: typ, command: command}
+		adapters[typ] = p
+	}
+	return
+}
+
+// start starts the process, p.mu must be held
+func (p *adapterProcess) start() (err error) {
+	args := strings.Fields(p.command)
+	if len(args) == 0 {
+		return fmt.Errorf("No adapter process is declared for %v", p.typ)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	p.stdin = stdin
+	p.pending = make(map[int64]chan adapterResponse)
+	go p.read(cmd, stdout)
+	return
+}
+
+// read dispatches the responses to the waiting calls until the process exits
+func (p *adapterProcess) read(cmd *exec.Cmd, stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		resp := adapterResponse{}
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			log.Printf("Adapter %v error: invalid response, %v\n", p.typ, err)
+			continue
+		}
+		p.mu.Lock()
+		if ch, ok := p.pending[resp.ID]; ok {
+			ch <- resp
+			delete(p.pending, resp.ID)
+		}
+		p.mu.Unlock()
+	}
+	err := cmd.Wait()
+	log.Printf("Adapter %v exited: %v\n", p.typ, err)
+	p.mu.Lock()
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+	p.stdin = nil
+	p.mu.Unlock()
+}
+
+// call sends a request and decodes the result of the response into result
+func (p *adapterProcess) call(method string, params AdapterParams, result interface{}) (err error) {
+	p.mu.Lock()
+	if p.stdin == nil {
+		if err = p.start(); err != nil {
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.seq++
+	req := adapterRequest{JSONRPC: "2.0", ID: p.seq, Method: method, Params: params}
+	ch := make(chan adapterResponse, 1)
+	p.pending[req.ID] = ch
+	data, _ := json.Marshal(req)
+	_, err = p.stdin.Write(append(data, '\n'))
+	p.mu.Unlock()
+	if err != nil {
+		return
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("the adapter process exited")
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%v (%v)", resp.Error.Message, resp.Error.Code)
+		}
+		if result != nil {
+			err = json.Unmarshal(resp.Result, result)
+		}
+		return
+	case <-time.After(adapterTimeout):
+		p.mu.Lock()
+		delete(p.pending, req.ID)
+		p.mu.Unlock()
+		return fmt.Errorf("%v timeout", method)
+	}
+}
+
+// Adapter the exchange struct of the exchanges served by an adapter process
+type Adapter struct {
+	logger    model.Logger
+	option    Option
+	periods   map[string]string
+	resampler resampler
+
+	limit     float64
+	lastSleep int64
+	lastTimes int64
+}
+
+// NewAdapter create an exchange struct served by the adapter process of the exchange type
+func NewAdapter(opt Option) Exchange {
+	e := &Adapter{
+		logger:    model.Logger{TraderID: opt.TraderID, ExchangeType: opt.Type},
+		option:    opt,
+		periods:   make(map[string]string),
+		limit:     10.0,
+		lastSleep: time.Now().UnixNano(),
+	}
+	custom := struct {
+		Periods []string `json:"periods"`
+	}{}
+	if opt.Config != "" {
+		if err := json.Unmarshal([]byte(opt.Config), &custom); err != nil {
+			e.logger.Log(constant.ERROR, "", 0.0, 0.0, "NewAdapter() error, invalid config: ", err)
+		}
+	}
+	for _, period := range custom.Periods {
+		e.periods[period] = period
+	}
+	return e
+}
+
+// call calls the method of the adapter process, errors are logged
+func (e *Adapter) call(method string, params AdapterParams, result interface{}) bool {
+	e.lastTimes++
+	params.Option = AdapterOption{
+		Type:      e.option.Type,
+		Name:      e.option.Name,
+		AccessKey: e.option.AccessKey,
+		SecretKey: e.option.SecretKey,
+		Config:    e.option.Config,
+	}
+	p, err := getAdapter(e.option.Type)
+	if err == nil {
+		err = p.call(method, params, result)
+	}
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, method, "() error, ", err)
+		return false
+	}
+	return true
+}
+
+// Log print something to console
+func (e *Adapter) Log(msgs ...interface{}) {
+	e.logger.Log(constant.INFO, "", 0.0, 0.0, msgs...)
+}
+
+// GetType get the type of this exchange
+func (e *Adapter) GetType() string {
+	return e.option.Type
+}
+
+// GetName get the name of this exchange
+func (e *Adapter) GetName() string {
+	return e.option.Name
+}
+
+// SetLimit set the limit calls amount per second of this exchange
+func (e *Adapter) SetLimit(times interface{}) float64 {
+	e.limit = conver.Float64Must(times)
+	return e.limit
+}
+
+// AutoSleep auto sleep to achieve the limit calls amount per second of this exchange
+func (e *Adapter) AutoSleep() {
+	now := time.Now().UnixNano()
+	interval := 1e+9/e.limit*conver.Float64Must(e.lastTimes) - conver.Float64Must(now-e.lastSleep)
+	if interval > 0.0 {
+		time.Sleep(time.Duration(conver.Int64Must(interval)))
+	}
+	e.lastTimes = 0
+	e.lastSleep = now
+}
+
+// GetMinAmount get the min trade amonut of this exchange
+func (e *Adapter) GetMinAmount(stock string) (amount float64) {
+	e.call("GetMinAmount", AdapterParams{StockType: strings.ToUpper(stock)}, &amount)
+	return
+}
+
+// GetAccount get the account detail of this exchange
+func (e *Adapter) GetAccount() interface{} {
+	account := make(map[string]float64)
+	if !e.call("GetAccount", AdapterParams{}, &account) {
+		return false
+	}
+	return account
+}
+
+// Trade place an order
+func (e *Adapter) Trade(tradeType string, stockType string, _price, _amount interface{}, msgs ...interface{}) interface{} {
+	params := AdapterParams{
+		TradeType: strings.ToUpper(tradeType),
+		StockType: strings.ToUpper(stockType),
+		Price:     conver.Float64Must(_price),
+		Amount:    conver.Float64Must(_amount),
+	}
+	id := ""
+	if !e.call("Trade", params, &id) {
+		return false
+	}
+	e.logger.Log(params.TradeType, params.StockType, params.Price, params.Amount, msgs...)
+	return id
+}
+
+// GetOrder get details of an order
+func (e *Adapter) GetOrder(stockType, id string) interface{} {
+	order := Order{}
+	if !e.call("GetOrder", AdapterParams{StockType: strings.ToUpper(stockType), ID: id}, &order) {
+		return false
+	}
+	return order
+}
+
+// GetOrders get all unfilled orders
+func (e *Adapter) GetOrders(stockType string) interface{} {
+	orders := []Order{}
+	if !e.call("GetOrders", AdapterParams{StockType: strings.ToUpper(stockType)}, &orders) {
+		return false
+	}
+	return orders
+}
+
+// GetTrades get all filled orders recently
+func (e *Adapter) GetTrades(stockType string) interface{} {
+	orders := []Order{}
+	if !e.call("GetTrades", AdapterParams{StockType: strings.ToUpper(stockType)}, &orders) {
+		return false
+	}
+	return orders
+}
+
+// CancelOrder cancel an order
+func (e *Adapter) CancelOrder(order Order) bool {
+	ok := false
+	if !e.call("CancelOrder", AdapterParams{StockType: order.StockType, Order: &order}, &ok) || !ok {
+		return false
+	}
+	e.logger.Log(constant.CANCEL, order.StockType, order.Price, order.Amount-order.DealAmount, order)
+	return true
+}
+
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *Adapter) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *Adapter) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *Adapter) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
+// GetTicker get market ticker & depth
+func (e *Adapter) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	params := AdapterParams{StockType: strings.ToUpper(stockType), Size: 20}
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		params.Size = conver.IntMust(sizes[0])
+	}
+	ticker := Ticker{}
+	if !e.call("GetTicker", params, &ticker) {
+		return false
+	}
+	return ticker
+}
+
+// GetRecordsBetween get the candlestick data in [begin, end) (unix seconds)
+func (e *Adapter) GetRecordsBetween(stockType, period string, begin, end int64) interface{} {
+	params := AdapterParams{StockType: strings.ToUpper(stockType), Period: period, Begin: begin, End: end}
+	records := []Record{}
+	if !e.call("GetRecordsBetween", params, &records) {
+		return false
+	}
+	return records
+}
+
+// GetMarketTrades get the recent public trades of the market
+func (e *Adapter) GetMarketTrades(stockType string) interface{} {
+	trades := []MarketTrade{}
+	if !e.call("GetMarketTrades", AdapterParams{StockType: strings.ToUpper(stockType)}, &trades) {
+		return false
+	}
+	return trades
+}
+
+// GetRecords get candlestick data, the periods the adapter does not provide are resampled
+func (e *Adapter) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.periods, e.getRecords, stockType, period, sizes...)
+}
+
+func (e *Adapter) getRecords(stockType, period string, sizes ...interface{}) interface{} {
+	params := AdapterParams{StockType: strings.ToUpper(stockType), Period: period, Size: 200}
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		params.Size = conver.IntMust(sizes[0])
+	}
+	records := []Record{}
+	if !e.call("GetRecords", params, &records) {
+		return false
+	}
+	return records
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// TestHelperProcess is the adapter process started by the tests, it serves the M1 records only,
+// it does not answer SLOW/USDT and exits at CRASH/USDT
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("QUANTBOT_HELPER_ADAPTER") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		req := adapterRequest{}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		p := req.Params
+		switch p.StockType {
+		case "SLOW/USDT":
+			continue
+		case "CRASH/USDT":
+			os.Exit(1)
+		}
+		var result interface{}
+		var fail string
+		switch req.Method {
+		case "GetTicker":
+			result = Ticker{Buy: float64(os.Getpid()), Sell: float64(p.Size)}
+		case "Trade":
+			if p.Amount <= 0 {
+				fail = "invalid amount"
+			}
+			result = fmt.Sprint(p.Option.Name, "-", p.TradeType, "-", p.StockType, "-", p.Price, "-", p.Amount)
+		case "GetRecords":
+			if p.Period != "M" {
+				fail = "unsupported period " + p.Period
+				break
+			}
+			now := time.Now().Unix() / 60
+			records := []Record{}
+			for m := now - int64(p.Size) + 1; m <= now; m++ {
+				records = append(records, Record{Time: m * 60, Open: 1, High: 2, Low: 0.5, Close: float64(m), Volume: 1})
+			}
+			result = records
+		default:
+			fail = "method not found"
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result}
+		if fail != "" {
+			resp = map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32000, "message": fail}}
+		}
+		encoder.Encode(resp)
+	}
+	os.Exit(0)
+}
+
+// helperAdapter returns an exchange served by TestHelperProcess and the messages of its error logs,
+// stop closes the process
+func helperAdapter(typ, config string) (e *Adapter, errors *[]string, stop func()) {
+	os.Setenv("QUANTBOT_HELPER_ADAPTER", "1")
+	p := &adapterProcess{typ: typ, command: os.Args[0] + " -test.run=^TestHelperProcess$"}
+	adaptersMu.Lock()
+	adapters[typ] = p
+	adaptersMu.Unlock()
+	errors = &[]string{}
+	e = NewAdapter(Option{Type: typ, Name: "venue", Config: config}).(*Adapter)
+	e.logger.Sink = func(l model.Log) {
+		if l.Type == constant.ERROR {
+			*errors = append(*errors, l.Message)
+		}
+	}
+	return e, errors, func() {
+		adaptersMu.Lock()
+		delete(adapters, typ)
+		adaptersMu.Unlock()
+		p.mu.Lock()
+		if p.stdin != nil {
+			p.stdin.Close()
+		}
+		p.mu.Unlock()
+	}
+}
+
+func TestAdapterCalls(t *testing.T) {
+	e, errors, stop := helperAdapter("helper-calls", "")
+	defer stop()
+	ticker, ok := e.GetTicker("btc/usdt", 5).(Ticker)
+	if !ok || ticker.Buy <= 0 || ticker.Sell != 5 {
+		t.Errorf("GetTicker() = %+v", ticker)
+	}
+	if id := e.Trade("buy", "btc/usdt", 100.5, 2); id != "venue-BUY-BTC/USDT-100.5-2" {
+		t.Errorf("Trade() = %v", id)
+	}
+	if id := e.Trade("sell", "btc/usdt", 100.5, 0); id != false {
+		t.Errorf("Trade() of an invalid amount = %v, want false", id)
+	}
+	if e.GetAccount() != false {
+		t.Errorf("GetAccount() of an unknown method should fail")
+	}
+	want := []string{"Trade() error, invalid amount (-32000)", "GetAccount() error, method not found (-32000)"}
+	if !reflect.DeepEqual(*errors, want) {
+		t.Errorf("error logs = %q, want %q", *errors, want)
+	}
+}
+
+func TestAdapterTimeout(t *testing.T) {
+	timeout := adapterTimeout
+	adapterTimeout = 200 * time.Millisecond
+	defer func() { adapterTimeout = timeout }()
+	e, errors, stop := helperAdapter("helper-timeout", "")
+	defer stop()
+	if e.GetTicker("slow/usdt") != false {
+		t.Errorf("GetTicker() without a response should fail")
+	}
+	if len(*errors) != 1 || (*errors)[0] != "GetTicker() error, GetTicker timeout" {
+		t.Errorf("error logs = %q", *errors)
+	}
+	if _, ok := e.GetTicker("btc/usdt").(Ticker); !ok {
+		t.Errorf("GetTicker() after a timeout failed")
+	}
+}
+
+func TestAdapterRestart(t *testing.T) {
+	e, errors, stop := helperAdapter("helper-restart", "")
+	defer stop()
+	before, ok := e.GetTicker("btc/usdt").(Ticker)
+	if !ok {
+		t.Fatal("GetTicker() failed")
+	}
+	if e.GetTicker("crash/usdt") != false {
+		t.Errorf("GetTicker() of a crashed process should fail")
+	}
+	if len(*errors) != 1 || (*errors)[0] != "GetTicker() error, the adapter process exited" {
+		t.Errorf("error logs = %q", *errors)
+	}
+	after, ok := e.GetTicker("btc/usdt").(Ticker)
+	if !ok || after.Buy == before.Buy {
+		t.Errorf("the process is not restarted, pid %v and %v", before.Buy, after.Buy)
+	}
+}
+
+func TestAdapterRecords(t *testing.T) {
+	e, errors, stop := helperAdapter("helper-records", `{"periods": ["M"]}`)
+	defer stop()
+	if records, ok := e.GetRecords("btc/usdt", "M", 3).([]Record); !ok || len(records) != 3 {
+		t.Errorf("GetRecords() of a provided period = %v", records)
+	}
+	records, ok := e.GetRecords("btc/usdt", "M5", 2).([]Record)
+	if !ok || len(records) != 2 || records[1].Time%300 != 0 || records[1].Close != float64(time.Now().Unix()/60) {
+		t.Errorf("GetRecords() of a resampled period = %+v", records)
+	}
+	unset, _, stopUnset := helperAdapter("helper-records-all", "")
+	defer stopUnset()
+	if unset.GetRecords("btc/usdt", "M5", 2) != false {
+		t.Errorf("GetRecords() should request every period from the adapter without the periods")
+	}
+	if len(*errors) != 0 {
+		t.Errorf("error logs = %q", *errors)
+	}
+}
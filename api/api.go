@@ -2,11 +2,12 @@ package api
 
 // Option is an exchange option
 type Option struct {
-	TraderID   int64
-	Type       string
-	Name       string
-	AccessKey  string
-	SecretKey  string
+	TraderID  int64
+	Type      string
+	Name      string
+	AccessKey string
+	SecretKey string
+	Config    string //交易所的 JSON 配置, 目前用于 OKEx v3 兼容的交易所
 }
 
 // Exchange interface
@@ -23,6 +24,9 @@ type Exchange interface {
 	GetOrders(stockType string) interface{}                                                               //返回所有的未完成订单列表
 	GetTrades(stockType string) interface{}                                                               //返回最近的已完成订单列表
 	CancelOrder(order Order) bool                                                                         //取消一笔订单
+	ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{}                 //取消一笔订单并以新的价格和数量重新下单,如果成功返回新订单的 ID,如果失败返回 false
+	BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult                                    //批量下单,返回每个订单的结果
+	BatchCancel(orders []Order) []BatchResult                                                             //批量取消订单,返回每个订单的结果
 	GetTicker(stockType string, sizes ...interface{}) interface{}                                         //获取交易所的最新市场行情数据
 	GetRecords(stockType, period string, sizes ...interface{}) interface{}                                //返回交易所的最新K线数据列表
 }
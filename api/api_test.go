@@ -0,0 +1,27 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geniustag/QuantBot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// TestMain opens a temporary sqlite database for the tests of the stored series
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		panic(err)
+	}
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	model.DB.AutoMigrate(&model.Candle{}, &model.Depth{}, &model.MarketTrade{})
+	code := m.Run()
+	model.DB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
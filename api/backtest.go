@@ -0,0 +1,376 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// BacktestOption is the option of a simulated exchange
+type BacktestOption struct {
+	Type    string                    //交易所类型
+	Name    string                    //交易所名称
+	Period  string                    //K线的周期, 撮合和行情都使用这个周期的K线
+	Candles map[string][]model.Candle //交易对到按时间排序的K线
+	Balance map[string]float64        //初始资金, 如 {"USDT": 1000}
+	Fee     float64                   //手续费率, 从收到的币种中扣除
+	Clock   func() int64              //回测的当前时间, unix 秒
+	Logger  model.Logger
+}
+
+// Backtest the simulated exchange of a backtest, a K-line is visible after it is closed, limit orders are filled
+// at their prices by the first closed K-line whose range reaches the prices, marketable orders and market orders
+// are filled at once at the close price of the last closed K-line
+type Backtest struct {
+	option  BacktestOption
+	seconds int64
+	logger  model.Logger
+	account map[string]float64
+	matched map[string]int //每个交易对已撮合的K线数量
+	orders  []*Order       //未完成的订单
+	trades  []Order        //已完成的订单
+	seq     int64
+	mu      sync.Mutex
+}
+
+// NewBacktest create a simulated exchange
+func NewBacktest(opt BacktestOption) *Backtest {
+	e := &Backtest{
+		option:  opt,
+		seconds: PeriodSeconds(opt.Period),
+		logger:  opt.Logger,
+		account: make(map[string]float64),
+		matched: make(map[string]int),
+	}
+	e.logger.ExchangeType = opt.Type
+	for currency, amount := range opt.Balance {
+		e.account[strings.ToUpper(currency)] = amount
+	}
+	return e
+}
+
+// closed returns the amount of the closed K-lines of the stock type
+func (e *Backtest) closed(stockType string) int {
+	candles := e.option.Candles[stockType]
+	now := e.option.Clock()
+	return sort.Search(len(candles), func(i int) bool { return candles[i].Timestamp+e.seconds > now })
+}
+
+// last returns the last closed K-line of the stock type
+func (e *Backtest) last(stockType string) (candle model.Candle, ok bool) {
+	if n := e.closed(stockType); n > 0 {
+		return e.option.Candles[stockType][n-1], true
+	}
+	return
+}
+
+// Match fills the limit orders by the K-lines closed since the last call, it is called by the backtest
+// every time the clock moves
+func (e *Backtest) Match() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for stockType, candles := range e.option.Candles {
+		n := e.closed(stockType)
+		for _, c := range candles[e.matched[stockType]:n] {
+			for i := 0; i < len(e.orders); i++ {
+				o := e.orders[i]
+				if o.StockType != stockType {
+					continue
+				}
+				if (o.TradeType == constant.TradeTypeBuy && c.Low <= o.Price) || (o.TradeType == constant.TradeTypeSell && c.High >= o.Price) {
+					e.fill(o, o.Price)
+					i--
+				}
+			}
+		}
+		if n > e.matched[stockType] {
+			e.matched[stockType] = n
+		}
+	}
+}
+
+// fill fills an open order at the price, e.mu must be held
+func (e *Backtest) fill(o *Order, price float64) {
+	currencies := strings.Split(o.StockType, "/")
+	base, quote := currencies[0], currencies[1]
+	remain := o.Amount - o.DealAmount
+	if o.TradeType == constant.TradeTypeBuy {
+		e.account["Frozen"+quote] -= o.Price * remain
+		e.account[quote] += (o.Price - price) * remain
+		o.Fee = remain * e.option.Fee
+		e.account[base] += remain - o.Fee
+	} else {
+		e.account["Frozen"+base] -= remain
+		o.Fee = price * remain * e.option.Fee
+		e.account[quote] += price*remain - o.Fee
+	}
+	o.DealAmount = o.Amount
+	o.Price = price
+	for i, order := range e.orders {
+		if order == o {
+			e.orders = append(e.orders[:i], e.orders[i+1:]...)
+			break
+		}
+	}
+	e.trades = append(e.trades, *o)
+}
+
+// Equity returns the value of all the currencies of the account in the quote currency of the first stock type,
+// the currencies without a K-line of that quote currency are not counted
+func (e *Backtest) Equity() (equity float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stockTypes := []string{}
+	for stockType := range e.option.Candles {
+		stockTypes = append(stockTypes, stockType)
+	}
+	if len(stockTypes) == 0 {
+		return
+	}
+	sort.Strings(stockTypes)
+	quote := strings.Split(stockTypes[0], "/")[1]
+	for currency, amount := range e.account {
+		currency = strings.TrimPrefix(currency, "Frozen")
+		if currency == quote {
+			equity += amount
+		} else if c, ok := e.last(currency + "/" + quote); ok {
+			equity += amount * c.Close
+		}
+	}
+	return
+}
+
+// Log print something to console
+func (e *Backtest) Log(msgs ...interface{}) {
+	e.logger.Log(constant.INFO, "", 0.0, 0.0, msgs...)
+}
+
+// GetType get the type of this exchange
+func (e *Backtest) GetType() string {
+	return e.option.Type
+}
+
+// GetName get the name of this exchange
+func (e *Backtest) GetName() string {
+	return e.option.Name
+}
+
+// SetLimit does nothing in a backtest
+func (e *Backtest) SetLimit(times interface{}) float64 {
+	return conver.Float64Must(times)
+}
+
+// AutoSleep does nothing in a backtest
+func (e *Backtest) AutoSleep() {
+}
+
+// GetMinAmount get the min trade amonut of this exchange
+func (e *Backtest) GetMinAmount(stock string) float64 {
+	return 0
+}
+
+// GetAccount get the account detail of this exchange
+func (e *Backtest) GetAccount() interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := make(map[string]float64)
+	for currency, amount := range e.account {
+		result[currency] = amount
+	}
+	return result
+}
+
+// Trade place an order, the amount of a market buy order is the amount of the quote currency
+func (e *Backtest) Trade(tradeType string, stockType string, _price, _amount interface{}, msgs ...interface{}) interface{} {
+	stockType = strings.ToUpper(stockType)
+	tradeType = strings.ToUpper(tradeType)
+	price := conver.Float64Must(_price)
+	amount := conver.Float64Must(_amount)
+	c, ok := e.last(stockType)
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, no K-line of ", stockType)
+		return false
+	}
+	if tradeType != constant.TradeTypeBuy && tradeType != constant.TradeTypeSell {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, unrecognized tradeType: ", tradeType)
+		return false
+	}
+	if amount <= 0 {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, invalid amount: ", amount)
+		return false
+	}
+	currencies := strings.Split(stockType, "/")
+	base, quote := currencies[0], currencies[1]
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	o := &Order{Price: price, Amount: amount, TradeType: tradeType, StockType: stockType}
+	if price <= 0 {
+		o.Price = c.Close
+		if tradeType == constant.TradeTypeBuy {
+			o.Amount = amount / c.Close
+		}
+	}
+	if tradeType == constant.TradeTypeBuy && e.account[quote] < o.Price*o.Amount {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, insufficient ", quote)
+		return false
+	}
+	if tradeType == constant.TradeTypeSell && e.account[base] < o.Amount {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, insufficient ", base)
+		return false
+	}
+	e.seq++
+	o.ID = fmt.Sprint(e.seq)
+	if tradeType == constant.TradeTypeBuy {
+		e.account[quote] -= o.Price * o.Amount
+		e.account["Frozen"+quote] += o.Price * o.Amount
+		e.logger.Log(constant.BUY, stockType, price, amount, msgs...)
+	} else {
+		e.account[base] -= o.Amount
+		e.account["Frozen"+base] += o.Amount
+		e.logger.Log(constant.SELL, stockType, price, amount, msgs...)
+	}
+	e.orders = append(e.orders, o)
+	if (tradeType == constant.TradeTypeBuy && o.Price >= c.Close) || (tradeType == constant.TradeTypeSell && o.Price <= c.Close) {
+		e.fill(o, c.Close)
+	}
+	return o.ID
+}
+
+// GetOrder get details of an order
+func (e *Backtest) GetOrder(stockType, id string) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, o := range e.orders {
+		if o.ID == id {
+			return *o
+		}
+	}
+	for _, o := range e.trades {
+		if o.ID == id {
+			return o
+		}
+	}
+	e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetOrder() error, can not found the order ", id)
+	return false
+}
+
+// GetOrders get all unfilled orders
+func (e *Backtest) GetOrders(stockType string) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	orders := []Order{}
+	for _, o := range e.orders {
+		if o.StockType == strings.ToUpper(stockType) {
+			orders = append(orders, *o)
+		}
+	}
+	return orders
+}
+
+// GetTrades get all filled orders
+func (e *Backtest) GetTrades(stockType string) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	orders := []Order{}
+	for _, o := range e.trades {
+		if o.StockType == strings.ToUpper(stockType) {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// CancelOrder cancel an order
+func (e *Backtest) CancelOrder(order Order) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, o := range e.orders {
+		if o.ID != order.ID {
+			continue
+		}
+		currencies := strings.Split(o.StockType, "/")
+		if o.TradeType == constant.TradeTypeBuy {
+			e.account["Frozen"+currencies[1]] -= o.Price * o.Amount
+			e.account[currencies[1]] += o.Price * o.Amount
+		} else {
+			e.account["Frozen"+currencies[0]] -= o.Amount
+			e.account[currencies[0]] += o.Amount
+		}
+		e.orders = append(e.orders[:i], e.orders[i+1:]...)
+		e.logger.Log(constant.CANCEL, o.StockType, o.Price, o.Amount-o.DealAmount, *o)
+		return true
+	}
+	e.logger.Log(constant.ERROR, "", 0.0, 0.0, "CancelOrder() error, the order ", order.ID, " is not open")
+	return false
+}
+
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *Backtest) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *Backtest) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *Backtest) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
+// GetTicker returns the close price of the last closed K-line as the buy and sell prices,
+// the depth has one level of the K-line volume
+func (e *Backtest) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	c, ok := e.last(strings.ToUpper(stockType))
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetTicker() error, no K-line of ", stockType)
+		return false
+	}
+	return Ticker{
+		Buy:  c.Close,
+		Sell: c.Close,
+		Mid:  c.Close,
+		Bids: []OrderBook{{Price: c.Close, Amount: c.Volume}},
+		Asks: []OrderBook{{Price: c.Close, Amount: c.Volume}},
+	}
+}
+
+// GetRecords returns the closed K-lines, the periods which are multiples of the backtest period are resampled
+func (e *Backtest) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	stockType = strings.ToUpper(stockType)
+	seconds := PeriodSeconds(period)
+	if seconds <= 0 || seconds%e.seconds != 0 {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, the period must be a multiple of ", e.option.Period)
+		return false
+	}
+	size := 200
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	candles := e.option.Candles[stockType][:e.closed(stockType)]
+	ratio := int(seconds / e.seconds)
+	if len(candles) > (size+1)*ratio {
+		candles = candles[len(candles)-(size+1)*ratio:]
+	}
+	records := []Record{}
+	for _, c := range candles {
+		records = append(records, Record{Time: c.Timestamp, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume})
+	}
+	if ratio > 1 {
+		offset := int64(0)
+		if period[0] == 'W' {
+			offset = weekOffset
+		}
+		records = resample(nil, records, seconds, offset)
+	}
+	if len(records) > size {
+		records = records[len(records)-size:]
+	}
+	return records
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// newTestBacktest returns a simulated exchange of BTC/USDT M1 candles with the close prices, the clock is in seconds
+func newTestBacktest(now *int64, closes ...float64) *Backtest {
+	candles := []model.Candle{}
+	for i, c := range closes {
+		candles = append(candles, model.Candle{Timestamp: int64(i) * 60, Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 10})
+	}
+	return NewBacktest(BacktestOption{
+		Type:    "test",
+		Name:    "test",
+		Period:  "M1",
+		Candles: map[string][]model.Candle{"BTC/USDT": candles},
+		Balance: map[string]float64{"usdt": 1000, "BTC": 1},
+		Fee:     0.01,
+		Clock:   func() int64 { return *now },
+		Logger:  model.Logger{Sink: func(model.Log) {}},
+	})
+}
+
+func TestBacktestTrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		tradeType   string
+		price       float64
+		amount      float64
+		wantFilled  bool
+		wantAccount map[string]float64
+	}{
+		{"market buy spends quote money", constant.TradeTypeBuy, -1, 100, true, map[string]float64{"USDT": 900, "BTC": 1.99, "FrozenUSDT": 0}},
+		{"market sell", constant.TradeTypeSell, -1, 0.5, true, map[string]float64{"USDT": 1049.5, "BTC": 0.5, "FrozenBTC": 0}},
+		{"marketable limit buy fills at the close", constant.TradeTypeBuy, 110, 1, true, map[string]float64{"USDT": 900, "BTC": 1.99, "FrozenUSDT": 0}},
+		{"limit buy is frozen", constant.TradeTypeBuy, 90, 1, false, map[string]float64{"USDT": 910, "BTC": 1, "FrozenUSDT": 90}},
+		{"limit sell is frozen", constant.TradeTypeSell, 120, 0.4, false, map[string]float64{"USDT": 1000, "BTC": 0.6, "FrozenBTC": 0.4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := int64(60)
+			e := newTestBacktest(&now, 100, 100)
+			id, ok := e.Trade(tt.tradeType, "btc/usdt", tt.price, tt.amount).(string)
+			if !ok {
+				t.Fatal("Trade() failed")
+			}
+			order := e.GetOrder("BTC/USDT", id).(Order)
+			if filled := order.DealAmount == order.Amount; filled != tt.wantFilled {
+				t.Errorf("filled = %v, want %v", filled, tt.wantFilled)
+			}
+			checkAccount(t, e.GetAccount().(map[string]float64), tt.wantAccount)
+		})
+	}
+}
+
+func TestBacktestTradeErrors(t *testing.T) {
+	now := int64(0)
+	e := newTestBacktest(&now, 100, 100)
+	if e.Trade(constant.TradeTypeBuy, "BTC/USDT", -1, 100) != false {
+		t.Error("Trade() before the first closed candle should fail")
+	}
+	now = 60
+	tests := []struct {
+		tradeType, stockType string
+		price, amount        float64
+	}{
+		{constant.TradeTypeBuy, "ETH/USDT", -1, 100},
+		{"HOLD", "BTC/USDT", -1, 100},
+		{constant.TradeTypeBuy, "BTC/USDT", -1, 0},
+		{constant.TradeTypeBuy, "BTC/USDT", -1, 2000},
+		{constant.TradeTypeSell, "BTC/USDT", 100, 2},
+	}
+	for _, tt := range tests {
+		if e.Trade(tt.tradeType, tt.stockType, tt.price, tt.amount) != false {
+			t.Errorf("Trade(%v, %v, %v, %v) should fail", tt.tradeType, tt.stockType, tt.price, tt.amount)
+		}
+	}
+	checkAccount(t, e.GetAccount().(map[string]float64), map[string]float64{"USDT": 1000, "BTC": 1})
+}
+
+func TestBacktestMatch(t *testing.T) {
+	now := int64(60)
+	// 第二根 K 线的最低价 94 达到买价 95, 第三根的最高价 111 达到卖价 110
+	e := newTestBacktest(&now, 100, 95, 110)
+	buy := e.Trade(constant.TradeTypeBuy, "BTC/USDT", 95, 2).(string)
+	sell := e.Trade(constant.TradeTypeSell, "BTC/USDT", 110, 1).(string)
+	steps := []struct {
+		now        int64
+		wantOrders int
+		account    map[string]float64
+	}{
+		{60, 2, map[string]float64{"USDT": 810, "FrozenUSDT": 190, "BTC": 0, "FrozenBTC": 1}},
+		{120, 1, map[string]float64{"USDT": 810, "FrozenUSDT": 0, "BTC": 1.98, "FrozenBTC": 1}},
+		{180, 0, map[string]float64{"USDT": 918.9, "FrozenUSDT": 0, "BTC": 1.98, "FrozenBTC": 0}},
+	}
+	for _, s := range steps {
+		now = s.now
+		e.Match()
+		if orders := e.GetOrders("BTC/USDT").([]Order); len(orders) != s.wantOrders {
+			t.Errorf("at %v, %v open orders, want %v", s.now, len(orders), s.wantOrders)
+		}
+		checkAccount(t, e.GetAccount().(map[string]float64), s.account)
+	}
+	trades := e.GetTrades("BTC/USDT").([]Order)
+	if len(trades) != 2 || trades[0].ID != buy || trades[1].ID != sell || trades[0].Price != 95 || trades[1].Price != 110 {
+		t.Errorf("trades = %+v", trades)
+	}
+	if equity := e.Equity(); math.Abs(equity-(918.9+1.98*110)) > 1e-9 {
+		t.Errorf("Equity() = %v", equity)
+	}
+}
+
+func TestBacktestCancelOrder(t *testing.T) {
+	now := int64(60)
+	e := newTestBacktest(&now, 100, 100)
+	id := e.Trade(constant.TradeTypeBuy, "BTC/USDT", 90, 1).(string)
+	if !e.CancelOrder(Order{ID: id}) {
+		t.Fatal("CancelOrder() failed")
+	}
+	if e.CancelOrder(Order{ID: id}) {
+		t.Error("CancelOrder() of a canceled order should fail")
+	}
+	checkAccount(t, e.GetAccount().(map[string]float64), map[string]float64{"USDT": 1000, "FrozenUSDT": 0, "BTC": 1})
+}
+
+func TestBacktestGetRecords(t *testing.T) {
+	now := int64(5 * 60)
+	e := newTestBacktest(&now, 1, 2, 3, 4, 5, 6, 7)
+	tests := []struct {
+		period string
+		size   int
+		want   []float64 // 收盘价
+	}{
+		{"M1", 0, []float64{1, 2, 3, 4, 5}},
+		{"M1", 2, []float64{4, 5}},
+		{"M5", 0, []float64{5}},
+	}
+	for _, tt := range tests {
+		records, ok := e.GetRecords("BTC/USDT", tt.period, tt.size).([]Record)
+		if !ok {
+			t.Fatalf("GetRecords(%v) failed", tt.period)
+		}
+		closes := []float64{}
+		for _, r := range records {
+			closes = append(closes, r.Close)
+		}
+		if !reflect.DeepEqual(closes, tt.want) {
+			t.Errorf("GetRecords(%v, %v) closes = %v, want %v", tt.period, tt.size, closes, tt.want)
+		}
+	}
+	if e.GetRecords("BTC/USDT", "X1") != false {
+		t.Error("GetRecords() of an invalid period should fail")
+	}
+	if ticker := e.GetTicker("BTC/USDT").(Ticker); ticker.Buy != 5 || ticker.Sell != 5 {
+		t.Errorf("GetTicker() = %+v", ticker)
+	}
+}
+
+func checkAccount(t *testing.T, got, want map[string]float64) {
+	t.Helper()
+	for currency, amount := range want {
+		if math.Abs(got[currency]-amount) > 1e-9 {
+			t.Errorf("account %v = %v, want %v", currency, got[currency], amount)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// BatchOrder is an order placed by BatchTrade
+type BatchOrder struct {
+	TradeType string
+	StockType string
+	Price     float64
+	Amount    float64
+}
+
+// BatchResult is the result of an order of BatchTrade or BatchCancel, the results are in the same order as the orders
+type BatchResult struct {
+	ID      string //订单ID, 下单失败时为空
+	Success bool
+	Error   string
+}
+
+// batchTrade places the orders one by one, it is used by the exchanges which have no batch endpoint
+func batchTrade(e Exchange, orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	for i, o := range orders {
+		id := e.Trade(o.TradeType, o.StockType, o.Price, o.Amount, msgs...)
+		if ok, isBool := id.(bool); isBool && !ok {
+			results[i].Error = "Trade() failed"
+			continue
+		}
+		results[i] = BatchResult{ID: fmt.Sprint(id), Success: true}
+	}
+	return results
+}
+
+// batchCancel cancels the orders one by one, it is used by the exchanges which have no batch endpoint
+func batchCancel(e Exchange, orders []Order) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	for i, o := range orders {
+		results[i] = BatchResult{ID: o.ID, Success: e.CancelOrder(o)}
+		if !results[i].Success {
+			results[i].Error = "CancelOrder() failed"
+		}
+	}
+	return results
+}
+
+// replaceOrder cancels the order and places a new one of the same trade type and stock type at the price and amount,
+// no order is placed if the cancel fails
+func replaceOrder(e Exchange, logger model.Logger, order Order, price, amount float64, msgs ...interface{}) interface{} {
+	if amount <= 0 {
+		logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, invalid amount: ", amount)
+		return false
+	}
+	if !e.CancelOrder(order) {
+		return false
+	}
+	return e.Trade(order.TradeType, order.StockType, price, amount, msgs...)
+}
+
+// batchChunks splits n orders into the ranges of at most size orders, for the endpoints with a batch limit
+func batchChunks(n, size int) (chunks [][2]int) {
+	for i := 0; i < n; i += size {
+		j := i + size
+		if j > n {
+			j = n
+		}
+		chunks = append(chunks, [2]int{i, j})
+	}
+	return
+}
+
+// batchError fills the error of the results which are not done
+func batchError(results []BatchResult, err interface{}) {
+	for i := range results {
+		if !results[i].Success && results[i].Error == "" {
+			results[i].Error = strings.TrimSpace(fmt.Sprint(err))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/geniustag/QuantBot/model"
+)
+
+// sequentialExchange places the orders with a positive price and cancels the orders of an even ID
+type sequentialExchange struct {
+	Exchange
+	calls []string
+}
+
+func (e *sequentialExchange) Trade(tradeType string, stockType string, price, amount interface{}, msgs ...interface{}) interface{} {
+	e.calls = append(e.calls, fmt.Sprint("Trade ", tradeType, " ", price, " ", amount))
+	if price.(float64) <= 0 {
+		return false
+	}
+	return fmt.Sprint(len(e.calls))
+}
+
+func (e *sequentialExchange) CancelOrder(order Order) bool {
+	e.calls = append(e.calls, "CancelOrder "+order.ID)
+	return len(order.ID)%2 == 0
+}
+
+func TestBatchTrade(t *testing.T) {
+	e := &sequentialExchange{}
+	got := batchTrade(e, []BatchOrder{{"BUY", "BTC/USDT", 10, 1}, {"SELL", "BTC/USDT", 0, 2}, {"SELL", "BTC/USDT", 11, 3}})
+	want := []BatchResult{{ID: "1", Success: true}, {Error: "Trade() failed"}, {ID: "3", Success: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batchTrade() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	e := &sequentialExchange{}
+	got := batchCancel(e, []Order{{ID: "12"}, {ID: "3"}})
+	want := []BatchResult{{ID: "12", Success: true}, {ID: "3", Error: "CancelOrder() failed"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batchCancel() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReplaceOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     Order
+		amount    float64
+		want      interface{}
+		wantCalls []string
+	}{
+		{"cancel and place", Order{ID: "12", TradeType: "BUY"}, 2, "2", []string{"CancelOrder 12", "Trade BUY 9 2"}},
+		{"the cancel fails", Order{ID: "3", TradeType: "BUY"}, 2, false, []string{"CancelOrder 3"}},
+		{"invalid amount", Order{ID: "12", TradeType: "BUY"}, 0, false, nil},
+	}
+	for _, tt := range tests {
+		e := &sequentialExchange{}
+		got := replaceOrder(e, model.Logger{Sink: func(model.Log) {}}, tt.order, 9, tt.amount)
+		if got != tt.want || !reflect.DeepEqual(e.calls, tt.wantCalls) {
+			t.Errorf("%v: replaceOrder() = %v %v, want %v %v", tt.name, got, e.calls, tt.want, tt.wantCalls)
+		}
+	}
+}
+
+func TestBatchChunks(t *testing.T) {
+	tests := []struct {
+		n, size int
+		want    [][2]int
+	}{
+		{0, 10, nil},
+		{3, 10, [][2]int{{0, 3}}},
+		{10, 5, [][2]int{{0, 5}, {5, 10}}},
+		{11, 5, [][2]int{{0, 5}, {5, 10}, {10, 11}}},
+	}
+	for _, tt := range tests {
+		if got := batchChunks(tt.n, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("batchChunks(%v, %v) = %v, want %v", tt.n, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	results := []BatchResult{{ID: "1", Success: true}, {Error: "failed"}, {}}
+	batchError(results, " timeout ")
+	want := []BatchResult{{ID: "1", Success: true}, {Error: "failed"}, {Error: "timeout"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("batchError() = %+v, want %+v", results, want)
+	}
+}
@@ -6,10 +6,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/api/BigoneAPI"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // BigOne the exchange struct of big.one
@@ -21,6 +21,7 @@ type BigOne struct {
 	records          map[string][]Record
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -244,6 +245,21 @@ func (e *BigOne) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *BigOne) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *BigOne) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *BigOne) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *BigOne) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -288,7 +304,12 @@ func (e *BigOne) GetTicker(stockType string, sizes ...interface{}) interface{} {
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *BigOne) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *BigOne) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	return nil
 }
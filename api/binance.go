@@ -5,10 +5,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/api/BinanceAPI"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // Binance the exchange struct of binance.com
@@ -20,6 +20,7 @@ type Binance struct {
 	records          map[string][]Record
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -249,6 +250,58 @@ func (e *Binance) CancelOrder(order Order) bool {
 	return ok
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount by the cancelReplace endpoint
+func (e *Binance) ReplaceOrder(order Order, _price, _amount interface{}, msgs ...interface{}) interface{} {
+	stockType := strings.ToUpper(order.StockType)
+	price := conver.Float64Must(_price)
+	amount := conver.Float64Must(_amount)
+	if _, ok := e.stockTypeMap[stockType]; !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, unrecognized stockType: ", order.StockType)
+		return false
+	}
+	logType := ""
+	switch strings.ToUpper(order.TradeType) {
+	case constant.TradeTypeBuy:
+		logType = constant.BUY
+	case constant.TradeTypeSell:
+		logType = constant.SELL
+	default:
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, unrecognized tradeType: ", order.TradeType)
+		return false
+	}
+	if amount <= 0 {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, invalid amount: ", amount)
+		return false
+	}
+	result, err := BinanceAPI.CancelReplaceOrder(order.ID, conver.StringMust(amount), conver.StringMust(price), e.stockTypeMap[stockType]+"USDT", strings.ToUpper(order.TradeType))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, ", err)
+		return false
+	}
+	if result["cancelResult"] != "SUCCESS" {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, ", result["msg"])
+		return false
+	}
+	e.logger.Log(constant.CANCEL, order.StockType, order.Price, order.Amount-order.DealAmount, order)
+	newOrder, _ := result["newOrderResponse"].(map[string]interface{})
+	if result["newOrderResult"] != "SUCCESS" || newOrder == nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "ReplaceOrder() error, the order is canceled but the new order failed, ", result["msg"])
+		return false
+	}
+	e.logger.Log(logType, stockType, price, amount, msgs...)
+	return fmt.Sprint(conver.Int64Must(newOrder["orderId"]))
+}
+
+// BatchTrade place the orders one by one, the spot API has no batch order endpoint
+func (e *Binance) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one, the spot API has no endpoint to cancel several given orders
+func (e *Binance) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *Binance) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -301,7 +354,12 @@ func (e *Binance) GetTicker(stockType string, sizes ...interface{}) interface{}
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *Binance) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *Binance) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	return nil
 }
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"sort"
+)
+
+// ConsolidatedLevel is a level of the consolidated order book
+type ConsolidatedLevel struct {
+	Price    float64 //扣除手续费后的价格, 买单为 Price*(1-Fee), 卖单为 Price*(1+Fee)
+	RawPrice float64 //交易所的挂单价格
+	Amount   float64
+	Exchange string //交易所名称
+	Index    int    //交易所在 Exchanges 中的序号
+}
+
+// ConsolidatedTicker is the order book merged from several exchanges of the same stock type
+type ConsolidatedTicker struct {
+	Buy          float64 //扣除手续费后的最高买价
+	BuyExchange  string
+	BuyIndex     int
+	Sell         float64 //加上手续费后的最低卖价
+	SellExchange string
+	SellIndex    int
+	Mid          float64
+	Bids         []ConsolidatedLevel
+	Asks         []ConsolidatedLevel
+	Errors       map[string]string //获取深度失败的交易所
+}
+
+// Consolidate gets the tickers of the exchanges concurrently and merges them, the prices are adjusted by
+// the fee rates of the exchanges which are in the same order, the exchanges which fail are skipped
+func Consolidate(exchanges []Exchange, fees []float64, stockType string, size int) (ticker ConsolidatedTicker) {
+	tickers := make([]interface{}, len(exchanges))
+	parallel(len(exchanges), func(i int) {
+		tickers[i] = exchanges[i].GetTicker(stockType, size)
+	})
+	ticker.BuyIndex, ticker.SellIndex = -1, -1
+	for i, t := range tickers {
+		name := exchanges[i].GetName()
+		venue, ok := t.(Ticker)
+		if !ok {
+			if ticker.Errors == nil {
+				ticker.Errors = make(map[string]string)
+			}
+			ticker.Errors[name] = "GetTicker() failed"
+			continue
+		}
+		fee := 0.0
+		if i < len(fees) {
+			fee = fees[i]
+		}
+		for _, l := range venue.Bids {
+			ticker.Bids = append(ticker.Bids, ConsolidatedLevel{Price: l.Price * (1 - fee), RawPrice: l.Price, Amount: l.Amount, Exchange: name, Index: i})
+		}
+		for _, l := range venue.Asks {
+			ticker.Asks = append(ticker.Asks, ConsolidatedLevel{Price: l.Price * (1 + fee), RawPrice: l.Price, Amount: l.Amount, Exchange: name, Index: i})
+		}
+	}
+	sort.SliceStable(ticker.Bids, func(i, j int) bool { return ticker.Bids[i].Price > ticker.Bids[j].Price })
+	sort.SliceStable(ticker.Asks, func(i, j int) bool { return ticker.Asks[i].Price < ticker.Asks[j].Price })
+	if len(ticker.Bids) > 0 {
+		ticker.Buy, ticker.BuyExchange, ticker.BuyIndex = ticker.Bids[0].Price, ticker.Bids[0].Exchange, ticker.Bids[0].Index
+	}
+	if len(ticker.Asks) > 0 {
+		ticker.Sell, ticker.SellExchange, ticker.SellIndex = ticker.Asks[0].Price, ticker.Asks[0].Exchange, ticker.Asks[0].Index
+	}
+	if len(ticker.Bids) > 0 && len(ticker.Asks) > 0 {
+		ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+	}
+	return
+}
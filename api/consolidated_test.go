@@ -0,0 +1,112 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// bookExchange is an exchange which only returns a fixed ticker, the other methods are not implemented
+type bookExchange struct {
+	Exchange
+	name   string
+	ticker interface{}
+}
+
+func (e *bookExchange) GetName() string {
+	return e.name
+}
+
+func (e *bookExchange) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	return e.ticker
+}
+
+// round8 rounds away the float error of the fee adjusted prices
+func round8(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+func TestConsolidate(t *testing.T) {
+	a := &bookExchange{name: "a", ticker: Ticker{
+		Bids: []OrderBook{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
+		Asks: []OrderBook{{Price: 101, Amount: 1}},
+	}}
+	b := &bookExchange{name: "b", ticker: Ticker{
+		Bids: []OrderBook{{Price: 99.5, Amount: 3}},
+		Asks: []OrderBook{{Price: 101.5, Amount: 4}},
+	}}
+	down := &bookExchange{name: "down", ticker: false}
+	tests := []struct {
+		name      string
+		exchanges []Exchange
+		fees      []float64
+		want      ConsolidatedTicker
+	}{
+		{
+			name:      "no fees",
+			exchanges: []Exchange{a, b},
+			want: ConsolidatedTicker{
+				Buy: 100, BuyExchange: "a", BuyIndex: 0,
+				Sell: 101, SellExchange: "a", SellIndex: 0,
+				Mid: 100.5,
+				Bids: []ConsolidatedLevel{
+					{Price: 100, RawPrice: 100, Amount: 1, Exchange: "a", Index: 0},
+					{Price: 99.5, RawPrice: 99.5, Amount: 3, Exchange: "b", Index: 1},
+					{Price: 99, RawPrice: 99, Amount: 2, Exchange: "a", Index: 0},
+				},
+				Asks: []ConsolidatedLevel{
+					{Price: 101, RawPrice: 101, Amount: 1, Exchange: "a", Index: 0},
+					{Price: 101.5, RawPrice: 101.5, Amount: 4, Exchange: "b", Index: 1},
+				},
+			},
+		},
+		{
+			name:      "the fee moves the best prices to another venue",
+			exchanges: []Exchange{a, b},
+			fees:      []float64{0.01},
+			want: ConsolidatedTicker{
+				Buy: 99.5, BuyExchange: "b", BuyIndex: 1,
+				Sell: 101.5, SellExchange: "b", SellIndex: 1,
+				Mid: 100.5,
+				Bids: []ConsolidatedLevel{
+					{Price: 99.5, RawPrice: 99.5, Amount: 3, Exchange: "b", Index: 1},
+					{Price: 99, RawPrice: 100, Amount: 1, Exchange: "a", Index: 0},
+					{Price: 98.01, RawPrice: 99, Amount: 2, Exchange: "a", Index: 0},
+				},
+				Asks: []ConsolidatedLevel{
+					{Price: 101.5, RawPrice: 101.5, Amount: 4, Exchange: "b", Index: 1},
+					{Price: 102.01, RawPrice: 101, Amount: 1, Exchange: "a", Index: 0},
+				},
+			},
+		},
+		{
+			name:      "a failed venue is skipped",
+			exchanges: []Exchange{down, b},
+			want: ConsolidatedTicker{
+				Buy: 99.5, BuyExchange: "b", BuyIndex: 1,
+				Sell: 101.5, SellExchange: "b", SellIndex: 1,
+				Mid:    100.5,
+				Bids:   []ConsolidatedLevel{{Price: 99.5, RawPrice: 99.5, Amount: 3, Exchange: "b", Index: 1}},
+				Asks:   []ConsolidatedLevel{{Price: 101.5, RawPrice: 101.5, Amount: 4, Exchange: "b", Index: 1}},
+				Errors: map[string]string{"down": "GetTicker() failed"},
+			},
+		},
+		{
+			name:      "no venue",
+			exchanges: []Exchange{down},
+			want:      ConsolidatedTicker{BuyIndex: -1, SellIndex: -1, Errors: map[string]string{"down": "GetTicker() failed"}},
+		},
+	}
+	for _, tt := range tests {
+		got := Consolidate(tt.exchanges, tt.fees, "BTC/USDT", 20)
+		for _, levels := range [][]ConsolidatedLevel{got.Bids, got.Asks} {
+			for i := range levels {
+				levels[i].Price = round8(levels[i].Price)
+			}
+		}
+		got.Buy, got.Sell, got.Mid = round8(got.Buy), round8(got.Sell), round8(got.Mid)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: Consolidate() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/model"
+)
+
+// csvFields are the fields of a record and the column names recognized by default
+var csvFields = map[string][]string{
+	"time":   {"time", "timestamp", "date", "datetime", "open_time"},
+	"open":   {"open", "o"},
+	"high":   {"high", "h"},
+	"low":    {"low", "l"},
+	"close":  {"close", "c"},
+	"volume": {"volume", "vol", "v"},
+}
+
+// timeLayouts are tried in order when the time format is not given
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+}
+
+// CSVOption is how the columns of a CSV or Parquet file map to the fields of a record
+type CSVOption struct {
+	Columns    map[string]string //字段名(time, open, high, low, close, volume)到列名, 未指定的字段按默认列名查找
+	TimeFormat string            //unix, unixms 或者 Go 的时间格式, 为空时自动识别, 不用于 Parquet 的时间戳和日期类型
+	Location   *time.Location    //时间中没有时区时使用的时区, 默认 UTC
+}
+
+// ReadCandlesCSV reads the candles from a CSV file with a header line, the candles are sorted by time
+// and the time is converted to unix seconds, the volume column is optional
+func ReadCandlesCSV(r io.Reader, opt CSVOption) (candles []model.Candle, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Read CSV header error: %v", err)
+	}
+	index, err := columnIndex(header, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Location == nil {
+		opt.Location = time.UTC
+	}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(field string) string {
+			if i, ok := index[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		c := model.Candle{}
+		if c.Timestamp, err = parseTime(value("time"), opt); err != nil {
+			return nil, fmt.Errorf("Line %v: %v", line, err)
+		}
+		prices := []*float64{&c.Open, &c.High, &c.Low, &c.Close, &c.Volume}
+		for i, field := range []string{"open", "high", "low", "close", "volume"} {
+			v := value(field)
+			if v == "" && field == "volume" {
+				continue
+			}
+			if *prices[i], err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("Line %v: invalid %v %q", line, field, v)
+			}
+		}
+		candles = append(candles, c)
+	}
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+	return
+}
+
+// ReadCandlesParquet reads the candles from the flat table of a Parquet file like ReadCandlesCSV, the timestamp
+// and date columns are converted as they are, the timestamps without a time zone are in opt.Location, the other
+// time columns are parsed like the CSV, the compression may be SNAPPY, GZIP or ZSTD
+func ReadCandlesParquet(r io.ReaderAt, size int64, opt CSVOption) (candles []model.Candle, err error) {
+	f, err := openParquet(r, size)
+	if err != nil {
+		return nil, err
+	}
+	header := []string{}
+	for _, c := range f.columns {
+		header = append(header, c.name)
+	}
+	index, err := columnIndex(header, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Location == nil {
+		opt.Location = time.UTC
+	}
+	fields := []string{"time", "open", "high", "low", "close", "volume"}
+	values := make([][]interface{}, len(fields))
+	for i, field := range fields {
+		if j, ok := index[field]; ok {
+			if values[i], err = f.values(j); err != nil {
+				return nil, err
+			}
+			if len(values[i]) != len(values[0]) {
+				return nil, fmt.Errorf("The %v column has %v rows but the time column has %v", field, len(values[i]), len(values[0]))
+			}
+		}
+	}
+	for row, v := range values[0] {
+		c := model.Candle{}
+		if v == nil {
+			return nil, fmt.Errorf("Row %v: empty time", row+1)
+		}
+		if c.Timestamp, err = f.columns[index["time"]].unix(v, opt); err != nil {
+			return nil, fmt.Errorf("Row %v: %v", row+1, err)
+		}
+		prices := []*float64{&c.Open, &c.High, &c.Low, &c.Close, &c.Volume}
+		for i, field := range fields[1:] {
+			if values[i+1] == nil || (values[i+1][row] == nil && field == "volume") {
+				continue
+			}
+			if *prices[i], err = f.columns[index[field]].float(values[i+1][row]); err != nil {
+				return nil, fmt.Errorf("Row %v: invalid %v %v", row+1, field, values[i+1][row])
+			}
+		}
+		candles = append(candles, c)
+	}
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+	return
+}
+
+// columnIndex returns the indexes of the columns of the fields in the header, the volume is optional
+func columnIndex(header []string, opt CSVOption) (map[string]int, error) {
+	index := make(map[string]int)
+	for field, names := range csvFields {
+		if name, ok := opt.Columns[field]; ok {
+			names = []string{name}
+		}
+	search:
+		for _, name := range names {
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					index[field] = i
+					break search
+				}
+			}
+		}
+		if _, ok := index[field]; !ok && field != "volume" {
+			return nil, fmt.Errorf("Can not find the %v column in %v", field, header)
+		}
+	}
+	return index, nil
+}
+
+// parseTime returns the unix seconds of the time
+func parseTime(v string, opt CSVOption) (int64, error) {
+	switch opt.TimeFormat {
+	case "unix", "unixms":
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q", v)
+		}
+		if opt.TimeFormat == "unixms" {
+			n /= 1000
+		}
+		return int64(n), nil
+	case "":
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 1e8 {
+			if n > 1e11 { //毫秒时间戳
+				n /= 1000
+			}
+			return int64(n), nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, v, opt.Location); err == nil {
+				return t.Unix(), nil
+			}
+		}
+		return 0, fmt.Errorf("unrecognized time %q", v)
+	}
+	t, err := time.ParseInLocation(opt.TimeFormat, v, opt.Location)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", v)
+	}
+	return t.Unix(), nil
+}
+
+// WriteCandlesCSV writes the candles in the shape of Record, the time is in unix seconds
+func WriteCandlesCSV(w io.Writer, candles []model.Candle) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Time", "Open", "High", "Low", "Close", "Volume"})
+	for _, c := range candles {
+		writer.Write([]string{
+			fmt.Sprint(c.Timestamp),
+			strconv.FormatFloat(c.Open, 'f', -1, 64),
+			strconv.FormatFloat(c.High, 'f', -1, 64),
+			strconv.FormatFloat(c.Low, 'f', -1, 64),
+			strconv.FormatFloat(c.Close, 'f', -1, 64),
+			strconv.FormatFloat(c.Volume, 'f', -1, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
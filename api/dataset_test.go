@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/model"
+)
+
+func TestReadCandlesCSV(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		data    string
+		opt     CSVOption
+		want    []model.Candle
+		wantErr bool
+	}{
+		{
+			name: "default columns sorted by time",
+			data: "Time,Open,High,Low,Close,Volume\n1514764860,2,3,1,2.5,10\n1514764800,1,2,0.5,1.5,20\n",
+			want: []model.Candle{
+				{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 20},
+				{Timestamp: 1514764860, Open: 2, High: 3, Low: 1, Close: 2.5, Volume: 10},
+			},
+		},
+		{
+			name: "alias columns without volume",
+			data: "date, o, h, l, c\n1514764800000,1,2,0.5,1.5\n",
+			want: []model.Candle{{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5}},
+		},
+		{
+			name: "mapped columns in a time zone",
+			data: "Day,Open,High,Low,Close,Amount\n2018-01-01 08:00:00,1,2,0.5,1.5,3\n",
+			opt:  CSVOption{Columns: map[string]string{"time": "Day", "volume": "Amount"}, Location: shanghai},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 3}},
+		},
+		{
+			name: "time format",
+			data: "time,open,high,low,close\n01/01/2018 00:00,1,2,0.5,1.5\n",
+			opt:  CSVOption{TimeFormat: "01/02/2006 15:04"},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5}},
+		},
+		{
+			name: "unixms",
+			data: "time,open,high,low,close\n1514764800500,1,2,0.5,1.5\n",
+			opt:  CSVOption{TimeFormat: "unixms"},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5}},
+		},
+		{
+			name:    "missing column",
+			data:    "time,open,high,close\n1514764800,1,2,1.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "mapped column not found",
+			data:    "time,open,high,low,close\n1514764800,1,2,0.5,1.5\n",
+			opt:     CSVOption{Columns: map[string]string{"time": "Day"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid price",
+			data:    "time,open,high,low,close\n1514764800,1,x,0.5,1.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			data:    "time,open,high,low,close\nyesterday,1,2,0.5,1.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			data:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadCandlesCSV(strings.NewReader(tt.data), tt.opt)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: ReadCandlesCSV() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: ReadCandlesCSV() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		format  string
+		want    int64
+		wantErr bool
+	}{
+		{"1514764800", "", 1514764800, false},
+		{"1514764800000", "", 1514764800, false},
+		{"2018-01-01T08:00:00+08:00", "", 1514764800, false},
+		{"2018-01-01 00:00:00", "", 1514764800, false},
+		{"2018/01/01 00:00", "", 1514764800, false},
+		{"20180101", "", 1514764800, false},
+		{"1514764800", "unix", 1514764800, false},
+		{"1514764800000", "unixms", 1514764800, false},
+		{"x", "unix", 0, true},
+		{"2018-13-01", "2006-01-02", 0, true},
+		{"1000", "", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.value, CSVOption{TimeFormat: tt.format, Location: time.UTC})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTime(%q, %q) = %v %v, want %v wantErr %v", tt.value, tt.format, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteCandlesCSV(t *testing.T) {
+	candles := []model.Candle{
+		{Timestamp: 1514764800, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 20},
+		{Timestamp: 1514764860, Open: 0.00000123, High: 3, Low: 1, Close: 2.5},
+	}
+	buf := bytes.Buffer{}
+	if err := WriteCandlesCSV(&buf, candles); err != nil {
+		t.Fatal(err)
+	}
+	want := "Time,Open,High,Low,Close,Volume\n1514764800,1,2,0.5,1.5,20\n1514764860,0.00000123,3,1,2.5,0\n"
+	if buf.String() != want {
+		t.Errorf("WriteCandlesCSV() = %q, want %q", buf.String(), want)
+	}
+	got, err := ReadCandlesCSV(&buf, CSVOption{})
+	if err != nil || !reflect.DeepEqual(got, candles) {
+		t.Errorf("ReadCandlesCSV(WriteCandlesCSV()) = %+v %v, want %+v", got, err, candles)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/geniustag/QuantBot/model"
+)
+
+// depthFrame is the compact form of a stored order book sample, the levels are [price, amount],
+// in a delta frame an amount of 0 removes the level
+type depthFrame struct {
+	Bids [][2]float64 `json:"b"`
+	Asks [][2]float64 `json:"a"`
+}
+
+// DepthSnapshot is an order book rebuilt from the stored samples
+type DepthSnapshot struct {
+	Time int64 //unix 毫秒
+	Ticker
+}
+
+// book is the order book being encoded or replayed, price to amount
+type book struct {
+	bids map[float64]float64
+	asks map[float64]float64
+}
+
+func newBook() *book {
+	return &book{bids: make(map[float64]float64), asks: make(map[float64]float64)}
+}
+
+// apply applies a frame to the book, a keyframe replaces the whole book
+func (b *book) apply(frame depthFrame, keyframe bool) {
+	if keyframe {
+		b.bids, b.asks = make(map[float64]float64), make(map[float64]float64)
+	}
+	applyLevels(b.bids, frame.Bids)
+	applyLevels(b.asks, frame.Asks)
+}
+
+func applyLevels(side map[float64]float64, levels [][2]float64) {
+	for _, l := range levels {
+		if l[1] == 0 {
+			delete(side, l[0])
+		} else {
+			side[l[0]] = l[1]
+		}
+	}
+}
+
+// diff returns the delta frame from the book to the ticker
+func (b *book) diff(ticker Ticker) (frame depthFrame) {
+	return depthFrame{Bids: diffLevels(b.bids, ticker.Bids), Asks: diffLevels(b.asks, ticker.Asks)}
+}
+
+func diffLevels(side map[float64]float64, levels []OrderBook) (delta [][2]float64) {
+	seen := make(map[float64]bool)
+	for _, l := range levels {
+		seen[l.Price] = true
+		if side[l.Price] != l.Amount {
+			delta = append(delta, [2]float64{l.Price, l.Amount})
+		}
+	}
+	for price := range side {
+		if !seen[price] {
+			delta = append(delta, [2]float64{price, 0})
+		}
+	}
+	return
+}
+
+// ticker returns the book as a ticker, the bids are sorted from high to low and the asks from low to high
+func (b *book) ticker() (ticker Ticker) {
+	for price, amount := range b.bids {
+		ticker.Bids = append(ticker.Bids, OrderBook{Price: price, Amount: amount})
+	}
+	for price, amount := range b.asks {
+		ticker.Asks = append(ticker.Asks, OrderBook{Price: price, Amount: amount})
+	}
+	sort.Slice(ticker.Bids, func(i, j int) bool { return ticker.Bids[i].Price > ticker.Bids[j].Price })
+	sort.Slice(ticker.Asks, func(i, j int) bool { return ticker.Asks[i].Price < ticker.Asks[j].Price })
+	if len(ticker.Bids) > 0 {
+		ticker.Buy = ticker.Bids[0].Price
+	}
+	if len(ticker.Asks) > 0 {
+		ticker.Sell = ticker.Asks[0].Price
+	}
+	ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+	return
+}
+
+// DepthRecorder stores the order book samples of a series with delta encoding
+type DepthRecorder struct {
+	Exchange  string //交易所类型
+	StockType string
+	Keyframe  int //每多少个样本保存一个完整的深度
+
+	book  *book
+	count int
+}
+
+// Record stores a sample, the first sample, every Keyframe samples and the sample after a failed one are keyframes
+func (r *DepthRecorder) Record(timestamp int64, ticker Ticker) (err error) {
+	depth := model.Depth{Exchange: r.Exchange, StockType: r.StockType, Timestamp: timestamp}
+	frame := depthFrame{}
+	if r.book == nil || r.Keyframe <= 1 || r.count%r.Keyframe == 0 {
+		depth.Keyframe = true
+		frame = newBook().diff(ticker)
+	} else {
+		frame = r.book.diff(ticker)
+	}
+	data, _ := json.Marshal(frame)
+	depth.Data = string(data)
+	if err = model.SaveDepth(&depth); err != nil {
+		r.book = nil
+		return
+	}
+	if r.book == nil {
+		r.book = newBook()
+	}
+	r.book.apply(frame, depth.Keyframe)
+	if depth.Keyframe {
+		r.count = 0
+	}
+	r.count++
+	return
+}
+
+// ReplayDepth rebuilds the order book of at most size stored samples in [begin, end], the time is in milliseconds,
+// the next page starts after the time of the last snapshot
+func ReplayDepth(exchange, stockType string, begin, end int64, size int) (snapshots []DepthSnapshot, err error) {
+	if begin <= 0 || end < begin || size <= 0 {
+		return nil, fmt.Errorf("The replay needs a begin time, an end time after it and a size")
+	}
+	depths, err := model.ListDepths(exchange, stockType, begin, end, size)
+	if err != nil {
+		return
+	}
+	return replay(depths, begin)
+}
+
+// DepthAt rebuilds the order book at the time in milliseconds, which is the last stored sample at or before it
+func DepthAt(exchange, stockType string, timestamp int64) (snapshot DepthSnapshot, err error) {
+	depths, err := model.ListDepths(exchange, stockType, timestamp, timestamp, 0)
+	if err != nil {
+		return
+	}
+	snapshots, err := replay(depths, 0)
+	if err != nil {
+		return
+	}
+	if len(snapshots) == 0 {
+		return snapshot, fmt.Errorf("No order book is recorded before %v", timestamp)
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+// replay applies the samples from the first keyframe, it returns the books of the samples since begin
+func replay(depths []model.Depth, begin int64) (snapshots []DepthSnapshot, err error) {
+	var b *book
+	for _, d := range depths {
+		frame := depthFrame{}
+		if err = json.Unmarshal([]byte(d.Data), &frame); err != nil {
+			return
+		}
+		if b == nil {
+			if !d.Keyframe {
+				continue
+			}
+			b = newBook()
+		}
+		b.apply(frame, d.Keyframe)
+		if d.Timestamp >= begin {
+			snapshots = append(snapshots, DepthSnapshot{Time: d.Timestamp, Ticker: b.ticker()})
+		}
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testBook returns a ticker of the bids and the asks which are [price, amount]
+func testBook(bids, asks [][2]float64) Ticker {
+	ticker := Ticker{}
+	for _, l := range bids {
+		ticker.Bids = append(ticker.Bids, OrderBook{Price: l[0], Amount: l[1]})
+	}
+	for _, l := range asks {
+		ticker.Asks = append(ticker.Asks, OrderBook{Price: l[0], Amount: l[1]})
+	}
+	if len(ticker.Bids) > 0 {
+		ticker.Buy = ticker.Bids[0].Price
+	}
+	if len(ticker.Asks) > 0 {
+		ticker.Sell = ticker.Asks[0].Price
+	}
+	ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+	return ticker
+}
+
+// testBooks are the samples of the round trip tests, the levels are sorted like the books which are rebuilt
+var testBooks = []Ticker{
+	testBook([][2]float64{{100, 1}, {99, 2}}, [][2]float64{{101, 1}, {102, 3}}),
+	testBook([][2]float64{{100, 1}, {99, 2}}, [][2]float64{{101, 1}, {102, 3}}),     //没有变化
+	testBook([][2]float64{{100, 5}, {99, 2}}, [][2]float64{{101, 1}, {102, 3}}),     //数量变化
+	testBook([][2]float64{{99, 2}, {98, 1}}, [][2]float64{{100.5, 2}, {101, 1}}),    //档位移除和新增
+	testBook(nil, [][2]float64{{100.5, 2}}),                                         //一边为空
+	testBook([][2]float64{{99.5, 1}}, [][2]float64{{100, 1}, {100.5, 2}, {103, 4}}), //从空恢复
+	testBook([][2]float64{{99.5, 1}}, [][2]float64{{100, 1}}),
+}
+
+func TestBookRoundTrip(t *testing.T) {
+	encoder, decoder := newBook(), newBook()
+	for i, ticker := range testBooks {
+		frame := encoder.diff(ticker)
+		encoder.apply(frame, false)
+		decoder.apply(frame, false)
+		if got := decoder.ticker(); !reflect.DeepEqual(got, ticker) {
+			t.Errorf("sample %v is rebuilt as %+v, want %+v", i, got, ticker)
+		}
+	}
+	if frame := encoder.diff(testBooks[len(testBooks)-1]); len(frame.Bids)+len(frame.Asks) != 0 {
+		t.Errorf("the delta of the same book is %+v, want empty", frame)
+	}
+}
+
+func TestDepthRecorderReplay(t *testing.T) {
+	for _, keyframe := range []int{1, 3, 100} {
+		stock := fmt.Sprintf("K%v/USDT", keyframe)
+		r := &DepthRecorder{Exchange: "test", StockType: stock, Keyframe: keyframe}
+		for i, ticker := range testBooks {
+			if err := r.Record(int64(1000*(i+1)), ticker); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tests := []struct {
+			begin, end int64
+			size       int
+			want       []int // testBooks 的下标
+		}{
+			{1000, 7000, 100, []int{0, 1, 2, 3, 4, 5, 6}},
+			{2500, 6000, 100, []int{2, 3, 4, 5}},
+			{3000, 7000, 2, []int{2, 3}},
+			{5000, 5000, 100, []int{4}},
+			{8000, 9000, 100, nil},
+		}
+		for _, tt := range tests {
+			snapshots, err := ReplayDepth("test", stock, tt.begin, tt.end, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != len(tt.want) {
+				t.Fatalf("keyframe %v, ReplayDepth(%v, %v, %v) returns %v books, want %v", keyframe, tt.begin, tt.end, tt.size, len(snapshots), len(tt.want))
+			}
+			for i, s := range snapshots {
+				if s.Time != int64(1000*(tt.want[i]+1)) || !reflect.DeepEqual(s.Ticker, testBooks[tt.want[i]]) {
+					t.Errorf("keyframe %v, ReplayDepth(%v, %v, %v)[%v] = %+v, want sample %v", keyframe, tt.begin, tt.end, tt.size, i, s, tt.want[i])
+				}
+			}
+		}
+		for _, at := range []int64{3500, 7000, 9000} {
+			want := testBooks[len(testBooks)-1]
+			if at < 7000 {
+				want = testBooks[at/1000-1]
+			}
+			if s, err := DepthAt("test", stock, at); err != nil || !reflect.DeepEqual(s.Ticker, want) {
+				t.Errorf("keyframe %v, DepthAt(%v) = %+v, %v", keyframe, at, s, err)
+			}
+		}
+		if _, err := DepthAt("test", stock, 500); err == nil {
+			t.Errorf("keyframe %v, DepthAt() before the first sample should fail", keyframe)
+		}
+	}
+}
+
+func TestReplayDepthRange(t *testing.T) {
+	tests := []struct {
+		begin, end int64
+		size       int
+	}{
+		{0, 1000, 10},
+		{2000, 1000, 10},
+		{1000, 2000, 0},
+	}
+	for _, tt := range tests {
+		if _, err := ReplayDepth("test", "BTC/USDT", tt.begin, tt.end, tt.size); err == nil {
+			t.Errorf("ReplayDepth(%v, %v, %v) should fail", tt.begin, tt.end, tt.size)
+		}
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"time"
 
 	"github.com/bitly/go-simplejson"
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // GateIo the exchange struct of gateio.io
@@ -21,6 +21,7 @@ type GateIo struct {
 	host             string
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -292,6 +293,21 @@ func (e *GateIo) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *GateIo) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *GateIo) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *GateIo) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *GateIo) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -345,7 +361,12 @@ func (e *GateIo) GetTicker(stockType string, sizes ...interface{}) interface{} {
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *GateIo) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *GateIo) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	return nil
 }
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"github.com/geniustag/QuantBot/model"
+)
+
+// history prepends the stored candles of the exchange type which are older than the records,
+// when the exchange returns less records than the requested size
+func history(exchange, stockType, period string, records []Record, size int) []Record {
+	if len(records) == 0 || len(records) >= size {
+		return records
+	}
+	unit := int64(1)
+	if records[0].Time > 1e11 { //毫秒时间戳
+		unit = 1000
+	}
+	candles, err := model.ListCandles(exchange, stockType, period, 0, records[0].Time/unit, size-len(records))
+	if err != nil || len(candles) == 0 {
+		return records
+	}
+	result := make([]Record, 0, len(candles)+len(records))
+	for _, c := range candles {
+		result = append(result, Record{
+			Time:   c.Timestamp * unit,
+			Open:   c.Open,
+			High:   c.High,
+			Low:    c.Low,
+			Close:  c.Close,
+			Volume: c.Volume,
+		})
+	}
+	return append(result, records...)
+}
+
+// ToCandles converts the records to candles whose time is in seconds
+func ToCandles(records []Record) (candles []model.Candle) {
+	for _, r := range records {
+		t := r.Time
+		if t > 1e11 { //毫秒时间戳
+			t /= 1000
+		}
+		candles = append(candles, model.Candle{
+			Timestamp: t,
+			Open:      r.Open,
+			High:      r.High,
+			Low:       r.Low,
+			Close:     r.Close,
+			Volume:    r.Volume,
+		})
+	}
+	return
+}
+
+// MarketTrade struct, a public trade of the market
+type MarketTrade struct {
+	ID     string  //成交编号
+	Time   int64   //unix 毫秒时间戳
+	Price  float64 //成交价
+	Amount float64 //成交量
+	Type   string  //主动成交的方向, BUY 或 SELL
+}
+
+// MarketTradeProvider is an exchange which returns the recent public trades, the collector stores them
+type MarketTradeProvider interface {
+	GetMarketTrades(stockType string) interface{}
+}
+
+// HistoryProvider is an exchange which returns the records in [begin, end) (unix seconds) of a provided period,
+// the collector uses it to backfill the gaps of the stored candles
+type HistoryProvider interface {
+	GetRecordsBetween(stockType, period string, begin, end int64) interface{}
+}
+
+// ToMarketTrades converts the trades to the stored public trades
+func ToMarketTrades(trades []MarketTrade) (result []model.MarketTrade) {
+	for _, t := range trades {
+		result = append(result, model.MarketTrade{
+			TradeID:   t.ID,
+			Timestamp: t.Time,
+			Price:     t.Price,
+			Amount:    t.Amount,
+			Type:      t.Type,
+		})
+	}
+	return
+}
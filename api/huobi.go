@@ -6,12 +6,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/api/HuobiProAPI/config"
 	"github.com/geniustag/QuantBot/api/HuobiProAPI/models"
 	"github.com/geniustag/QuantBot/api/HuobiProAPI/services"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // Huobi the exchange struct of huobi.com
@@ -23,6 +23,7 @@ type Huobi struct {
 	records          map[string][]Record
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -301,6 +302,114 @@ func (e *Huobi) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *Huobi) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders by the batch endpoint, 10 orders per request
+func (e *Huobi) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	indexes := []int{}
+	params := []models.PlaceRequestParams{}
+	for i, o := range orders {
+		stockType, tradeType := strings.ToUpper(o.StockType), strings.ToUpper(o.TradeType)
+		if _, ok := e.stockTypeMap[stockType]; !ok {
+			results[i].Error = "unrecognized stockType: " + o.StockType
+			continue
+		}
+		typ := ""
+		switch tradeType {
+		case constant.TradeTypeBuy:
+			typ = "buy-limit"
+		case constant.TradeTypeSell:
+			typ = "sell-limit"
+		default:
+			results[i].Error = "unrecognized tradeType: " + o.TradeType
+			continue
+		}
+		indexes = append(indexes, i)
+		params = append(params, models.PlaceRequestParams{
+			AccountID: config.ACCOUNT_ID,
+			Amount:    conver.StringMust(o.Amount),
+			Price:     conver.StringMust(o.Price),
+			Source:    "api",
+			Symbol:    e.stockTypeMap[stockType] + "usdt",
+			Type:      typ,
+		})
+	}
+	for _, chunk := range batchChunks(len(params), 10) {
+		batch := indexes[chunk[0]:chunk[1]]
+		result, err := services.BatchPlace(params[chunk[0]:chunk[1]])
+		if err == nil && result.Status != "ok" {
+			err = fmt.Errorf("%v", result.ErrMsg)
+		}
+		if err != nil {
+			e.logger.Log(constant.ERROR, "", 0.0, 0.0, "BatchTrade() error, ", err)
+			for _, i := range batch {
+				results[i].Error = err.Error()
+			}
+			continue
+		}
+		for j, placed := range result.Data {
+			if j >= len(batch) {
+				break
+			}
+			i := batch[j]
+			if placed.OrderID <= 0 {
+				results[i].Error = placed.ErrMsg
+				continue
+			}
+			o := orders[i]
+			logType := constant.BUY
+			if strings.ToUpper(o.TradeType) == constant.TradeTypeSell {
+				logType = constant.SELL
+			}
+			e.logger.Log(logType, strings.ToUpper(o.StockType), o.Price, o.Amount, msgs...)
+			results[i] = BatchResult{ID: fmt.Sprint(placed.OrderID), Success: true}
+		}
+	}
+	batchError(results, "no result of the order")
+	return results
+}
+
+// BatchCancel cancel the orders by the batch endpoint, 50 orders per request
+func (e *Huobi) BatchCancel(orders []Order) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	for _, chunk := range batchChunks(len(orders), 50) {
+		ids := make(map[string]int)
+		orderIDs := []string{}
+		for i := chunk[0]; i < chunk[1]; i++ {
+			results[i].ID = orders[i].ID
+			ids[orders[i].ID] = i
+			orderIDs = append(orderIDs, orders[i].ID)
+		}
+		result, err := services.BatchCancel(orderIDs)
+		if err == nil && result.Status != "ok" {
+			err = fmt.Errorf("%v", result.ErrMsg)
+		}
+		if err != nil {
+			e.logger.Log(constant.ERROR, "", 0.0, 0.0, "BatchCancel() error, ", err)
+			batchError(results[chunk[0]:chunk[1]], err)
+			continue
+		}
+		for _, id := range result.Data.Success {
+			if i, ok := ids[id]; ok {
+				o := orders[i]
+				results[i].Success = true
+				e.logger.Log(constant.CANCEL, o.StockType, o.Price, o.Amount-o.DealAmount, o)
+			}
+		}
+		for _, failed := range result.Data.Failed {
+			if i, ok := ids[failed.OrderID]; ok {
+				results[i].Error = failed.ErrMsg
+			}
+		}
+	}
+	batchError(results, "no result of the order")
+	return results
+}
+
 // getTicker get market ticker & depth
 func (e *Huobi) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -351,7 +460,12 @@ func (e *Huobi) GetTicker(stockType string, sizes ...interface{}) interface{} {
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *Huobi) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *Huobi) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	return nil
 }
@@ -7,9 +7,9 @@ import (
 	"time"
 
 	"github.com/bitly/go-simplejson"
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // OkexFuture the exchange struct of okex.com future
@@ -25,6 +25,7 @@ type OkexFuture struct {
 	host                string
 	logger              model.Logger
 	option              Option
+	resampler           resampler
 
 	limit     float64
 	lastSleep int64
@@ -407,6 +408,21 @@ func (e *OkexFuture) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *OkexFuture) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *OkexFuture) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *OkexFuture) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *OkexFuture) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -464,8 +480,13 @@ func (e *OkexFuture) GetTicker(stockType string, sizes ...interface{}) interface
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *OkexFuture) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *OkexFuture) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	stockType = strings.ToUpper(stockType)
 	if _, ok := e.stockTypeMap[stockType]; !ok {
 		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, unrecognized stockType: ", stockType)
@@ -520,8 +541,7 @@ func (e *OkexFuture) GetRecords(stockType, period string, sizes ...interface{})
 		}
 	}
 	e.records[period] = append(e.records[period], recordsNew...)
-	if len(e.records[period]) > size {
-		e.records[period] = e.records[period][len(e.records[period])-size : len(e.records[period])]
-	}
-	return e.records[period]
+	cache, records := cacheRecords(e.records[period], size)
+	e.records[period] = cache
+	return records
 }
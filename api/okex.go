@@ -7,9 +7,9 @@ import (
 	"time"
 
 	"github.com/bitly/go-simplejson"
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // OKEX the exchange struct of okex.com
@@ -22,6 +22,7 @@ type OKEX struct {
 	host             string
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -351,6 +352,21 @@ func (e *OKEX) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *OKEX) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *OKEX) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *OKEX) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *OKEX) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	stockType = strings.ToUpper(stockType)
@@ -408,8 +424,13 @@ func (e *OKEX) GetTicker(stockType string, sizes ...interface{}) interface{} {
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *OKEX) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *OKEX) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	stockType = strings.ToUpper(stockType)
 	if _, ok := e.stockTypeMap[stockType]; !ok {
 		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, unrecognized stockType: ", stockType)
@@ -464,8 +485,7 @@ func (e *OKEX) GetRecords(stockType, period string, sizes ...interface{}) interf
 		}
 	}
 	e.records[period] = append(e.records[period], recordsNew...)
-	if len(e.records[period]) > size {
-		e.records[period] = e.records[period][len(e.records[period])-size : len(e.records[period])]
-	}
-	return e.records[period]
+	cache, records := cacheRecords(e.records[period], size)
+	e.records[period] = cache
+	return records
 }
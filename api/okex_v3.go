@@ -0,0 +1,746 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// OKEXV3Config is the config of an OKEx v3 compatible exchange, it is read from the JSON Config of the exchange
+type OKEXV3Config struct {
+	Host       string             `json:"host"`       //如 https://www.okex.com/api/spot/v3
+	Passphrase string             `json:"passphrase"` //创建 API Key 时设置的 Passphrase
+	Symbols    map[string]string  `json:"symbols"`    //如 "BTC/USDT": "btc_usdt", 为空时按 btc_usdt 的格式转换所有的交易对
+	MinAmounts map[string]float64 `json:"minAmounts"` //如 "BTC/USDT": 0.001
+	Periods    map[string]string  `json:"periods"`    //K线周期对应的 granularity 秒数, 为空时使用 M, M5, M15, M30, H, D, W
+	Insecure   bool               `json:"insecure"`   //不验证 HTTPS 证书
+}
+
+// okexV3Presets are the white-label venues which are built in, the Config of the exchange overrides them
+var okexV3Presets = map[string]OKEXV3Config{
+	constant.OkexThree: {Host: "https://www.okex.com/api/spot/v3"},
+	constant.Coffee:    {Host: "https://www.coffeeokex.com/api/spot/v3", Insecure: true},
+	constant.Xnodes:    {Host: "https://www.xnodes.pro/api/spot/v3"},
+}
+
+func init() {
+	for _, typ := range []string{constant.OkexThree, constant.Coffee, constant.Xnodes} {
+		preset := okexV3Presets[typ]
+		preset.Passphrase = "coffee"
+		preset.Symbols = map[string]string{
+			"BTC/USDT": "btc_usdt",
+			"ETH/USDT": "eth_usdt",
+			"EOS/USDT": "eos_usdt",
+			"GST/ETH":  "gst_eth",
+			"GST/BTC":  "gst_btc",
+			"GST/USDT": "gst_usdt",
+		}
+		preset.MinAmounts = map[string]float64{
+			"BTC/USDT": 0.001,
+			"ETH/USDT": 0.001,
+			"EOS/USDT": 0.001,
+			"GST/USDT": 100,
+			"GST/BTC":  100,
+			"GST/ETH":  100,
+		}
+		okexV3Presets[typ] = preset
+	}
+}
+
+var insecureClient = &http.Client{Transport: &http.Transport{
+	TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+}}
+
+// OKEXV3 the exchange struct of the OKEx v3 compatible exchanges
+type OKEXV3 struct {
+	config       OKEXV3Config
+	tradeTypeMap map[string]string
+	records      map[string][]Record
+	logger       model.Logger
+	prefix       string //签名使用的路径前缀, 如 /api/spot/v3
+	client       *http.Client
+	option       Option
+	resampler    resampler
+
+	limit     float64
+	lastSleep int64
+	lastTimes int64
+}
+
+// NewOKEXV3 create an exchange struct of an OKEx v3 compatible exchange, the preset of the exchange type is
+// used as the default config
+func NewOKEXV3(opt Option) Exchange {
+	e := &OKEXV3{
+		config: okexV3Presets[opt.Type],
+		tradeTypeMap: map[string]string{
+			"buy":         constant.TradeTypeBuy,
+			"sell":        constant.TradeTypeSell,
+			"buy_market":  constant.TradeTypeBuy,
+			"sell_market": constant.TradeTypeSell,
+		},
+		records:   make(map[string][]Record),
+		logger:    model.Logger{TraderID: opt.TraderID, ExchangeType: opt.Type},
+		client:    client,
+		option:    opt,
+		limit:     10.0,
+		lastSleep: time.Now().UnixNano(),
+	}
+	if opt.Config != "" {
+		custom := OKEXV3Config{}
+		if err := json.Unmarshal([]byte(opt.Config), &custom); err != nil {
+			e.logger.Log(constant.ERROR, "", 0.0, 0.0, "NewOKEXV3() error, invalid config, ", err)
+		}
+		if custom.Host != "" {
+			e.config.Host = custom.Host
+		}
+		if custom.Passphrase != "" {
+			e.config.Passphrase = custom.Passphrase
+		}
+		if custom.Symbols != nil {
+			e.config.Symbols = custom.Symbols
+		}
+		if custom.MinAmounts != nil {
+			e.config.MinAmounts = custom.MinAmounts
+		}
+		if custom.Periods != nil {
+			e.config.Periods = custom.Periods
+		}
+		e.config.Insecure = e.config.Insecure || custom.Insecure
+	}
+	if len(e.config.Periods) == 0 {
+		e.config.Periods = map[string]string{
+			"M":   "60",
+			"M5":  "300",
+			"M15": "900",
+			"M30": "1800",
+			"H":   "3600",
+			"D":   "86400",
+			"W":   "604800",
+		}
+	}
+	e.config.Host = strings.TrimSuffix(e.config.Host, "/")
+	if u, err := url.Parse(e.config.Host); err == nil {
+		e.prefix = u.Path
+	}
+	if e.config.Insecure {
+		e.client = insecureClient
+	}
+	return e
+}
+
+// Log print something to console
+func (e *OKEXV3) Log(msgs ...interface{}) {
+	e.logger.Log(constant.INFO, "", 0.0, 0.0, msgs...)
+}
+
+// GetType get the type of this exchange
+func (e *OKEXV3) GetType() string {
+	return e.option.Type
+}
+
+// GetName get the name of this exchange
+func (e *OKEXV3) GetName() string {
+	return e.option.Name
+}
+
+// SetLimit set the limit calls amount per second of this exchange
+func (e *OKEXV3) SetLimit(times interface{}) float64 {
+	e.limit = conver.Float64Must(times)
+	return e.limit
+}
+
+// AutoSleep auto sleep to achieve the limit calls amount per second of this exchange
+func (e *OKEXV3) AutoSleep() {
+	now := time.Now().UnixNano()
+	interval := 1e+9/e.limit*conver.Float64Must(e.lastTimes) - conver.Float64Must(now-e.lastSleep)
+	if interval > 0.0 {
+		time.Sleep(time.Duration(conver.Int64Must(interval)))
+	}
+	e.lastTimes = 0
+	e.lastSleep = now
+}
+
+// GetMinAmount get the min trade amonut of this exchange
+func (e *OKEXV3) GetMinAmount(stock string) float64 {
+	return e.config.MinAmounts[stock]
+}
+
+// symbol returns the instrument id of the stockType
+func (e *OKEXV3) symbol(stockType string) (string, bool) {
+	if len(e.config.Symbols) == 0 {
+		return strings.ToLower(strings.Replace(stockType, "/", "_", -1)), strings.Contains(stockType, "/")
+	}
+	symbol, ok := e.config.Symbols[stockType]
+	return symbol, ok
+}
+
+// GetAccount get the account detail of this exchange
+func (e *OKEXV3) GetAccount() interface{} {
+	json, err := e.getAuthJSON("/accounts")
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetAccount() error, ", err)
+		return false
+	}
+	result := make(map[string]float64)
+	for i := len(json.MustArray()); i > 0; i-- {
+		accountJSON := json.GetIndex(i - 1)
+		currency := accountJSON.Get("currency").MustString()
+		result[currency] = conver.Float64Must(accountJSON.Get("available").MustString())
+		result["Frozen"+currency] = conver.Float64Must(accountJSON.Get("hold").MustString())
+	}
+	return result
+}
+
+// Trade place an order
+func (e *OKEXV3) Trade(tradeType string, stockType string, _price, _amount interface{}, msgs ...interface{}) interface{} {
+	stockType = strings.ToUpper(stockType)
+	tradeType = strings.ToUpper(tradeType)
+	price := conver.Float64Must(_price)
+	amount := conver.Float64Must(_amount)
+	if _, ok := e.symbol(stockType); !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	switch tradeType {
+	case constant.TradeTypeBuy:
+		return e.postOrder(stockType, "buy", constant.BUY, price, amount, msgs...)
+	case constant.TradeTypeSell:
+		return e.postOrder(stockType, "sell", constant.SELL, price, amount, msgs...)
+	default:
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, unrecognized tradeType: ", tradeType)
+		return false
+	}
+}
+
+func (e *OKEXV3) postOrder(stockType, side, logType string, price, amount float64, msgs ...interface{}) interface{} {
+	symbol, _ := e.symbol(stockType)
+	params := map[string]interface{}{
+		"client_oid":    IsoTime(),
+		"instrument_id": symbol,
+		"side":          side,
+		"size":          strconv.FormatFloat(amount, 'f', -1, 64),
+		"price":         strconv.FormatFloat(price, 'f', -1, 64),
+		"type":          "market",
+	}
+	if price > 0 {
+		params["type"] = "limit"
+	}
+	body, _ := json.Marshal(params)
+	json, err := e.postAuthJSON("/orders", string(body))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, ", err)
+		return false
+	}
+	if result := json.Get("result").MustBool(); !result {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "Trade() error, the error number is ", json.Get("code").MustInt())
+		return false
+	}
+	e.logger.Log(logType, stockType, price, amount, msgs...)
+	return fmt.Sprint(json.Get("order_id").Interface())
+}
+
+// toOrder converts the order json of the exchange
+func (e *OKEXV3) toOrder(stockType string, orderJSON *simplejson.Json) Order {
+	return Order{
+		ID:         fmt.Sprint(orderJSON.Get("order_id").Interface()),
+		Price:      conver.Float64Must(orderJSON.Get("price").MustString()),
+		Amount:     conver.Float64Must(orderJSON.Get("size").MustString()),
+		DealAmount: conver.Float64Must(orderJSON.Get("filled_size").MustString()),
+		TradeType:  e.tradeTypeMap[orderJSON.Get("side").MustString()],
+		Currency:   orderJSON.Get("instrument_id").MustString(),
+		StockType:  stockType,
+	}
+}
+
+// GetOrder get details of an order
+func (e *OKEXV3) GetOrder(stockType, id string) interface{} {
+	stockType = strings.ToUpper(stockType)
+	if _, ok := e.symbol(stockType); !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetOrder() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	json, err := e.getAuthJSON("/orders/" + id)
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetOrder() error, ", err)
+		return false
+	}
+	return e.toOrder(stockType, json)
+}
+
+// getOrders get the orders of the path
+func (e *OKEXV3) getOrders(name, stockType, path string) interface{} {
+	stockType = strings.ToUpper(stockType)
+	symbol, ok := e.symbol(stockType)
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, name, "() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	json, err := e.getAuthJSON(strings.Replace(path, "{symbol}", symbol, 1))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, name, "() error, ", err)
+		return false
+	}
+	orders := []Order{}
+	for i := 0; i < len(json.MustArray()); i++ {
+		orders = append(orders, e.toOrder(stockType, json.GetIndex(i)))
+	}
+	return orders
+}
+
+// GetOrders get all unfilled orders
+func (e *OKEXV3) GetOrders(stockType string) interface{} {
+	return e.getOrders("GetOrders", stockType, "/orders_pending?instrument_id={symbol}")
+}
+
+// GetTrades get all filled orders recently
+func (e *OKEXV3) GetTrades(stockType string) interface{} {
+	return e.getOrders("GetTrades", stockType, "/orders?instrument_id={symbol}&status=filled")
+}
+
+// CancelOrder cancel an order
+func (e *OKEXV3) CancelOrder(order Order) bool {
+	body, _ := json.Marshal(map[string]interface{}{"instrument_id": order.Currency})
+	json, err := e.postAuthJSON("/cancel_orders/"+order.ID, string(body))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "CancelOrder() error, ", err)
+		return false
+	}
+	if result := json.Get("result").MustBool(); !result {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "CancelOrder() error, the error number is ", json.Get("code").MustInt())
+		return false
+	}
+	e.logger.Log(constant.CANCEL, order.StockType, order.Price, order.Amount-order.DealAmount, order)
+	return true
+}
+
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *OKEXV3) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// okexV3BatchSize and okexV3BatchSymbols are the limits of the orders and the instruments of a batch request
+const (
+	okexV3BatchSize    = 10
+	okexV3BatchSymbols = 4
+)
+
+// batches groups the indexes of the orders into the batch requests, the orders without a symbol are skipped
+func (e *OKEXV3) batches(symbols []string) (batches [][]int) {
+	batch, instruments := []int{}, make(map[string]bool)
+	for i, symbol := range symbols {
+		if symbol == "" {
+			continue
+		}
+		if len(batch) == okexV3BatchSize || (!instruments[symbol] && len(instruments) == okexV3BatchSymbols) {
+			batches = append(batches, batch)
+			batch, instruments = []int{}, make(map[string]bool)
+		}
+		batch = append(batch, i)
+		instruments[symbol] = true
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return
+}
+
+// BatchTrade place the orders by the batch endpoint
+func (e *OKEXV3) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	symbols := make([]string, len(orders))
+	for i, o := range orders {
+		symbol, ok := e.symbol(strings.ToUpper(o.StockType))
+		if !ok {
+			results[i].Error = "unrecognized stockType: " + o.StockType
+			continue
+		}
+		if tradeType := strings.ToUpper(o.TradeType); tradeType != constant.TradeTypeBuy && tradeType != constant.TradeTypeSell {
+			results[i].Error = "unrecognized tradeType: " + o.TradeType
+			continue
+		}
+		symbols[i] = symbol
+	}
+	for _, batch := range e.batches(symbols) {
+		e.batchTrade(orders, symbols, results, batch, msgs...)
+	}
+	batchError(results, "no result of the order")
+	return results
+}
+
+// batchTrade places the orders of the batch in one request
+func (e *OKEXV3) batchTrade(orders []BatchOrder, symbols []string, results []BatchResult, batch []int, msgs ...interface{}) {
+	params := []map[string]interface{}{}
+	clientOids := make(map[string]int)
+	prefix := time.Now().UnixNano()
+	for _, i := range batch {
+		o := orders[i]
+		clientOid := fmt.Sprintf("q%v%v", prefix, i)
+		clientOids[clientOid] = i
+		order := map[string]interface{}{
+			"client_oid":    clientOid,
+			"instrument_id": symbols[i],
+			"side":          strings.ToLower(o.TradeType),
+			"size":          strconv.FormatFloat(o.Amount, 'f', -1, 64),
+			"price":         strconv.FormatFloat(o.Price, 'f', -1, 64),
+			"type":          "market",
+		}
+		if o.Price > 0 {
+			order["type"] = "limit"
+		}
+		params = append(params, order)
+	}
+	body, _ := json.Marshal(params)
+	json, err := e.postAuthJSON("/batch_orders", string(body))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "BatchTrade() error, ", err)
+		for _, i := range batch {
+			results[i].Error = err.Error()
+		}
+		return
+	}
+	for symbol := range json.MustMap() {
+		resultsJSON := json.Get(symbol)
+		for j := 0; j < len(resultsJSON.MustArray()); j++ {
+			resultJSON := resultsJSON.GetIndex(j)
+			i, ok := clientOids[resultJSON.Get("client_oid").MustString()]
+			if !ok {
+				continue
+			}
+			if !resultJSON.Get("result").MustBool() {
+				results[i].Error = fmt.Sprintf("the error number is %v, %v", resultJSON.Get("error_code").Interface(), resultJSON.Get("error_message").MustString())
+				continue
+			}
+			o := orders[i]
+			logType := constant.BUY
+			if strings.ToUpper(o.TradeType) == constant.TradeTypeSell {
+				logType = constant.SELL
+			}
+			e.logger.Log(logType, strings.ToUpper(o.StockType), o.Price, o.Amount, msgs...)
+			results[i] = BatchResult{ID: fmt.Sprint(resultJSON.Get("order_id").Interface()), Success: true}
+		}
+	}
+}
+
+// BatchCancel cancel the orders by the batch endpoint
+func (e *OKEXV3) BatchCancel(orders []Order) []BatchResult {
+	results := make([]BatchResult, len(orders))
+	symbols := make([]string, len(orders))
+	for i, o := range orders {
+		results[i].ID = o.ID
+		symbols[i] = o.Currency
+		if symbols[i] == "" {
+			symbol, ok := e.symbol(strings.ToUpper(o.StockType))
+			if !ok {
+				results[i].Error = "unrecognized stockType: " + o.StockType
+				continue
+			}
+			symbols[i] = symbol
+		}
+	}
+	for _, batch := range e.batches(symbols) {
+		e.batchCancel(orders, symbols, results, batch)
+	}
+	batchError(results, "no result of the order")
+	return results
+}
+
+// batchCancel cancels the orders of the batch in one request
+func (e *OKEXV3) batchCancel(orders []Order, symbols []string, results []BatchResult, batch []int) {
+	params := []map[string]interface{}{}
+	instruments := make(map[string]int)
+	ids := make(map[string]int)
+	for _, i := range batch {
+		j, ok := instruments[symbols[i]]
+		if !ok {
+			j = len(params)
+			instruments[symbols[i]] = j
+			params = append(params, map[string]interface{}{"instrument_id": symbols[i], "order_ids": []string{}})
+		}
+		params[j]["order_ids"] = append(params[j]["order_ids"].([]string), orders[i].ID)
+		ids[orders[i].ID] = i
+	}
+	body, _ := json.Marshal(params)
+	json, err := e.postAuthJSON("/cancel_batch_orders", string(body))
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "BatchCancel() error, ", err)
+		for _, i := range batch {
+			results[i].Error = err.Error()
+		}
+		return
+	}
+	done := func(i int) {
+		o := orders[i]
+		results[i].Success = true
+		e.logger.Log(constant.CANCEL, o.StockType, o.Price, o.Amount-o.DealAmount, o)
+	}
+	for symbol := range json.MustMap() {
+		resultsJSON := json.Get(symbol)
+		// 有的版本返回 {"result": true, "order_id": [...]}
+		if _, ok := resultsJSON.CheckGet("result"); ok {
+			idsJSON := resultsJSON.Get("order_id")
+			for j := 0; j < len(idsJSON.MustArray()); j++ {
+				if i, ok := ids[fmt.Sprint(idsJSON.GetIndex(j).Interface())]; ok && resultsJSON.Get("result").MustBool() {
+					done(i)
+				}
+			}
+			continue
+		}
+		for j := 0; j < len(resultsJSON.MustArray()); j++ {
+			resultJSON := resultsJSON.GetIndex(j)
+			i, ok := ids[fmt.Sprint(resultJSON.Get("order_id").Interface())]
+			if !ok {
+				continue
+			}
+			if !resultJSON.Get("result").MustBool() {
+				results[i].Error = fmt.Sprintf("the error number is %v, %v", resultJSON.Get("error_code").Interface(), resultJSON.Get("error_message").MustString())
+				continue
+			}
+			done(i)
+		}
+	}
+}
+
+// getTicker get market ticker & depth
+func (e *OKEXV3) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
+	stockType = strings.ToUpper(stockType)
+	symbol, ok := e.symbol(stockType)
+	if !ok {
+		err = fmt.Errorf("GetTicker() error, unrecognized stockType: %+v", stockType)
+		return
+	}
+	size := 20
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	resp, err := e.request("GET", fmt.Sprintf("/instruments/%v/book?size=%v", symbol, size), "")
+	if err != nil {
+		err = fmt.Errorf("GetTicker() error, %+v", err)
+		return
+	}
+	json, err := simplejson.NewJson(resp)
+	if err != nil {
+		err = fmt.Errorf("GetTicker() error, %+v", err)
+		return
+	}
+	depthsJSON := json.Get("bids")
+	for i := 0; i < len(depthsJSON.MustArray()); i++ {
+		depthJSON := depthsJSON.GetIndex(i)
+		ticker.Bids = append(ticker.Bids, OrderBook{
+			Price:  conver.Float64Must(depthJSON.GetIndex(0).MustString()),
+			Amount: conver.Float64Must(depthJSON.GetIndex(1).MustString()),
+		})
+	}
+	depthsJSON = json.Get("asks")
+	for i := 0; i < len(depthsJSON.MustArray()); i++ {
+		depthJSON := depthsJSON.GetIndex(i)
+		ticker.Asks = append(ticker.Asks, OrderBook{
+			Price:  conver.Float64Must(depthJSON.GetIndex(0).MustString()),
+			Amount: conver.Float64Must(depthJSON.GetIndex(1).MustString()),
+		})
+	}
+	if len(ticker.Bids) < 1 || len(ticker.Asks) < 1 {
+		err = fmt.Errorf("GetTicker() error, can not get enough Bids or Asks")
+		return
+	}
+	ticker.Buy = ticker.Bids[0].Price
+	ticker.Sell = ticker.Asks[0].Price
+	ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+	return
+}
+
+// GetTicker get market ticker & depth
+func (e *OKEXV3) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	ticker, err := e.getTicker(stockType, sizes...)
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, err)
+		return false
+	}
+	return ticker
+}
+
+// GetRecords get candlestick data, the periods which are not provided are resampled
+func (e *OKEXV3) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.config.Periods, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *OKEXV3) getRecords(stockType, period string, sizes ...interface{}) interface{} {
+	stockType = strings.ToUpper(stockType)
+	symbol, ok := e.symbol(stockType)
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	if _, ok := e.config.Periods[period]; !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, unrecognized period: ", period)
+		return false
+	}
+	size := 200
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	resp, err := e.request("GET", "/instruments/"+symbol+"/candles?granularity="+e.config.Periods[period], "")
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, ", err)
+		return false
+	}
+	json, err := simplejson.NewJson(resp)
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecords() error, ", err)
+		return false
+	}
+	key := stockType + "#" + period
+	timeLast := int64(0)
+	if len(e.records[key]) > 0 {
+		timeLast = e.records[key][len(e.records[key])-1].Time
+	}
+	recordsNew := []Record{}
+	for i := len(json.MustArray()); i > 0; i-- {
+		record := okexV3Record(json.GetIndex(i - 1))
+		if record.Time > timeLast {
+			recordsNew = append([]Record{record}, recordsNew...)
+		} else if timeLast > 0 && record.Time == timeLast {
+			e.records[key][len(e.records[key])-1] = record
+		} else {
+			break
+		}
+	}
+	e.records[key] = append(e.records[key], recordsNew...)
+	cache, records := cacheRecords(e.records[key], size)
+	e.records[key] = cache
+	return records
+}
+
+// okexV3Record parses a candle, the time is in milliseconds
+func okexV3Record(recordJSON *simplejson.Json) Record {
+	recordTimeOrigin, _ := time.Parse("2006-01-02T15:04:05Z", recordJSON.Get("time").MustString())
+	return Record{
+		Time:   recordTimeOrigin.UnixNano() / 1e6,
+		Open:   conver.Float64Must(recordJSON.Get("open").MustString()),
+		High:   conver.Float64Must(recordJSON.Get("high").MustString()),
+		Low:    conver.Float64Must(recordJSON.Get("low").MustString()),
+		Close:  conver.Float64Must(recordJSON.Get("close").MustString()),
+		Volume: conver.Float64Must(recordJSON.Get("volume").MustString()),
+	}
+}
+
+// GetRecordsBetween get the candlestick data in [begin, end) (unix seconds) of a provided period, the exchange
+// returns at most 200 records from the end
+func (e *OKEXV3) GetRecordsBetween(stockType, period string, begin, end int64) interface{} {
+	stockType = strings.ToUpper(stockType)
+	symbol, ok := e.symbol(stockType)
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecordsBetween() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	if _, ok := e.config.Periods[period]; !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecordsBetween() error, unrecognized period: ", period)
+		return false
+	}
+	iso := func(t int64) string {
+		return url.QueryEscape(time.Unix(t, 0).UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+	resp, err := e.request("GET", fmt.Sprintf("/instruments/%v/candles?granularity=%v&start=%v&end=%v", symbol, e.config.Periods[period], iso(begin), iso(end)), "")
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecordsBetween() error, ", err)
+		return false
+	}
+	json, err := simplejson.NewJson(resp)
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetRecordsBetween() error, ", err)
+		return false
+	}
+	records := []Record{}
+	for i := len(json.MustArray()); i > 0; i-- {
+		record := okexV3Record(json.GetIndex(i - 1))
+		if record.Time >= begin*1000 && record.Time < end*1000 && (len(records) == 0 || record.Time > records[len(records)-1].Time) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// GetMarketTrades get the recent public trades of the market
+func (e *OKEXV3) GetMarketTrades(stockType string) interface{} {
+	stockType = strings.ToUpper(stockType)
+	symbol, ok := e.symbol(stockType)
+	if !ok {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetMarketTrades() error, unrecognized stockType: ", stockType)
+		return false
+	}
+	resp, err := e.request("GET", fmt.Sprintf("/instruments/%v/trades?limit=100", symbol), "")
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetMarketTrades() error, ", err)
+		return false
+	}
+	json, err := simplejson.NewJson(resp)
+	if err != nil {
+		e.logger.Log(constant.ERROR, "", 0.0, 0.0, "GetMarketTrades() error, ", err)
+		return false
+	}
+	trades := []MarketTrade{}
+	for i := len(json.MustArray()); i > 0; i-- {
+		tradeJSON := json.GetIndex(i - 1)
+		tradeTime, _ := time.Parse(time.RFC3339, tradeJSON.Get("timestamp").MustString())
+		trades = append(trades, MarketTrade{
+			ID:     fmt.Sprint(tradeJSON.Get("trade_id").Interface()),
+			Time:   tradeTime.UnixNano() / 1e6,
+			Price:  conver.Float64Must(tradeJSON.Get("price").MustString()),
+			Amount: conver.Float64Must(tradeJSON.Get("size").MustString()),
+			Type:   e.tradeTypeMap[tradeJSON.Get("side").MustString()],
+		})
+	}
+	return trades
+}
+
+func (e *OKEXV3) getAuthJSON(path string) (json *simplejson.Json, err error) {
+	resp, err := e.request("GET", path, "")
+	if err != nil {
+		return
+	}
+	return simplejson.NewJson(resp)
+}
+
+func (e *OKEXV3) postAuthJSON(path string, body string) (json *simplejson.Json, err error) {
+	resp, err := e.request("POST", path, body)
+	if err != nil {
+		return
+	}
+	return simplejson.NewJson(resp)
+}
+
+// request sends a signed request to the host
+func (e *OKEXV3) request(method, path string, body string) (ret []byte, err error) {
+	req, err := http.NewRequest(method, e.config.Host+path, strings.NewReader(body))
+	if err != nil {
+		return
+	}
+	timestamp := IsoTime()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("OK-ACCESS-KEY", e.option.AccessKey)
+	req.Header.Set("OK-ACCESS-SIGN", ComputeHmac256(timestamp+method+e.prefix+path+body, e.option.SecretKey))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", e.config.Passphrase)
+	resp, err := e.client.Do(req)
+	if resp == nil {
+		err = fmt.Errorf("[%s %s] HTTP Error Info: %v", method, path, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		err = fmt.Errorf("[%s %s] HTTP Status: %d, Info: %v", method, path, resp.StatusCode, err)
+		return
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// okexV3Server is a fake OKEx v3 host, respond returns the status and the JSON of a request body
+type okexV3Server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string // 路径和签名请求头
+	bodies   []interface{}
+}
+
+func newOKEXV3Server(t *testing.T, respond func(path string, body []map[string]interface{}) (int, interface{})) *okexV3Server {
+	s := &okexV3Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body := []map[string]interface{}{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("%v body %s error = %v", r.URL.Path, data, err)
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		if r.Header.Get("OK-ACCESS-SIGN") == "" || r.Header.Get("OK-ACCESS-PASSPHRASE") != "secret" {
+			t.Errorf("%v is not signed", r.URL.Path)
+		}
+		status, resp := respond(r.URL.Path, body)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	return s
+}
+
+// exchange returns an OKEx v3 exchange of the server which does not save the logs
+func (s *okexV3Server) exchange() *OKEXV3 {
+	e := NewOKEXV3(Option{Type: constant.OkexThree, Name: "v3", AccessKey: "key", SecretKey: "secret", Config: `{"host": "` + s.URL + `/api/spot/v3", "passphrase": "secret"}`}).(*OKEXV3)
+	e.logger.Sink = func(model.Log) {}
+	return e
+}
+
+func TestOKEXV3Batches(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbols []string
+		want    [][]int
+	}{
+		{"empty", nil, nil},
+		{"skip the orders without a symbol", []string{"a", "", "b"}, [][]int{{0, 2}}},
+		{"at most 10 orders", strings.Split("a,a,a,a,a,a,a,a,a,a,a,a", ","), [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {10, 11}}},
+		{"at most 4 instruments", []string{"a", "b", "c", "d", "a", "e", "b"}, [][]int{{0, 1, 2, 3, 4}, {5, 6}}},
+	}
+	e := &OKEXV3{}
+	for _, tt := range tests {
+		if got := e.batches(tt.symbols); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: batches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOKEXV3BatchTrade(t *testing.T) {
+	// 按 client_oid 返回结果, 价格为 13 的订单下单失败, 价格为 14 的订单没有结果
+	placed := func(path string, body []map[string]interface{}) (int, interface{}) {
+		resp := make(map[string][]map[string]interface{})
+		for _, o := range body {
+			symbol := fmt.Sprint(o["instrument_id"])
+			switch o["price"] {
+			case "13":
+				resp[symbol] = append(resp[symbol], map[string]interface{}{"client_oid": o["client_oid"], "result": false, "error_code": 33017, "error_message": "insufficient balance"})
+			case "14":
+			default:
+				resp[symbol] = append(resp[symbol], map[string]interface{}{"client_oid": o["client_oid"], "order_id": fmt.Sprint(o["side"], "-", o["price"]), "result": true})
+			}
+		}
+		return http.StatusOK, resp
+	}
+	tests := []struct {
+		name         string
+		orders       []BatchOrder
+		respond      func(path string, body []map[string]interface{}) (int, interface{})
+		want         []BatchResult
+		wantRequests int
+	}{
+		{
+			name: "every order is placed",
+			orders: []BatchOrder{
+				{TradeType: "buy", StockType: "btc/usdt", Price: 10, Amount: 1},
+				{TradeType: "SELL", StockType: "ETH/USDT", Price: 0, Amount: 2},
+			},
+			respond:      placed,
+			want:         []BatchResult{{ID: "buy-10", Success: true}, {ID: "sell-0", Success: true}},
+			wantRequests: 1,
+		},
+		{
+			name: "the results of every order",
+			orders: []BatchOrder{
+				{TradeType: "BUY", StockType: "BTC/USDT", Price: 13, Amount: 1},
+				{TradeType: "BUY", StockType: "DOGE/USDT", Price: 10, Amount: 1},
+				{TradeType: "HOLD", StockType: "BTC/USDT", Price: 10, Amount: 1},
+				{TradeType: "BUY", StockType: "BTC/USDT", Price: 14, Amount: 1},
+				{TradeType: "SELL", StockType: "BTC/USDT", Price: 12, Amount: 1},
+			},
+			respond: placed,
+			want: []BatchResult{
+				{Error: "the error number is 33017, insufficient balance"},
+				{Error: "unrecognized stockType: DOGE/USDT"},
+				{Error: "unrecognized tradeType: HOLD"},
+				{Error: "no result of the order"},
+				{ID: "sell-12", Success: true},
+			},
+			wantRequests: 1,
+		},
+		{
+			name:         "the orders are split into batches",
+			orders:       []BatchOrder{{"BUY", "BTC/USDT", 1, 1}, {"BUY", "BTC/USDT", 2, 1}, {"BUY", "BTC/USDT", 3, 1}, {"BUY", "BTC/USDT", 4, 1}, {"BUY", "BTC/USDT", 5, 1}, {"BUY", "BTC/USDT", 6, 1}, {"BUY", "BTC/USDT", 7, 1}, {"BUY", "BTC/USDT", 8, 1}, {"BUY", "BTC/USDT", 9, 1}, {"BUY", "BTC/USDT", 10, 1}, {"BUY", "BTC/USDT", 11, 1}},
+			respond:      placed,
+			want:         []BatchResult{{ID: "buy-1", Success: true}, {ID: "buy-2", Success: true}, {ID: "buy-3", Success: true}, {ID: "buy-4", Success: true}, {ID: "buy-5", Success: true}, {ID: "buy-6", Success: true}, {ID: "buy-7", Success: true}, {ID: "buy-8", Success: true}, {ID: "buy-9", Success: true}, {ID: "buy-10", Success: true}, {ID: "buy-11", Success: true}},
+			wantRequests: 2,
+		},
+		{
+			name:   "the request fails",
+			orders: []BatchOrder{{TradeType: "BUY", StockType: "BTC/USDT", Price: 10, Amount: 1}},
+			respond: func(string, []map[string]interface{}) (int, interface{}) {
+				return http.StatusInternalServerError, nil
+			},
+			want:         []BatchResult{{Error: "[POST /batch_orders] HTTP Status: 500, Info: <nil>"}},
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		s := newOKEXV3Server(t, tt.respond)
+		got := s.exchange().BatchTrade(tt.orders)
+		s.Close()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: BatchTrade() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if len(s.requests) != tt.wantRequests {
+			t.Errorf("%v: %v requests, want %v", tt.name, len(s.requests), tt.wantRequests)
+		}
+		for _, r := range s.requests {
+			if r != "POST /api/spot/v3/batch_orders" {
+				t.Errorf("%v: request %v", tt.name, r)
+			}
+		}
+	}
+}
+
+func TestOKEXV3BatchTradeBody(t *testing.T) {
+	s := newOKEXV3Server(t, func(path string, body []map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	s.exchange().BatchTrade([]BatchOrder{
+		{TradeType: "BUY", StockType: "BTC/USDT", Price: 65432.123, Amount: 0.1},
+		{TradeType: "SELL", StockType: "BTC/USDT", Price: 0.00001234, Amount: 123456789},
+		{TradeType: "BUY", StockType: "ETH/USDT", Price: 0, Amount: 12.5},
+	})
+	s.Close()
+	want := [][4]string{
+		{"btc_usdt", "limit", "65432.123", "0.1"},
+		{"btc_usdt", "limit", "0.00001234", "123456789"},
+		{"eth_usdt", "market", "0", "12.5"},
+	}
+	if len(s.bodies) != 1 || len(s.bodies[0].([]map[string]interface{})) != len(want) {
+		t.Fatalf("request bodies = %v", s.bodies)
+	}
+	for i, o := range s.bodies[0].([]map[string]interface{}) {
+		if got := [4]string{fmt.Sprint(o["instrument_id"]), fmt.Sprint(o["type"]), fmt.Sprint(o["price"]), fmt.Sprint(o["size"])}; got != want[i] {
+			t.Errorf("order %v = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestOKEXV3BatchCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		orders   []Order
+		respond  func(path string, body []map[string]interface{}) (int, interface{})
+		want     []BatchResult
+		wantBody interface{}
+	}{
+		{
+			name: "the results of every order",
+			orders: []Order{
+				{ID: "1", StockType: "BTC/USDT"},
+				{ID: "2", Currency: "eth_usdt"},
+				{ID: "3", StockType: "BTC/USDT"},
+				{ID: "4", StockType: "DOGE/USDT"},
+				{ID: "5", StockType: "BTC/USDT"},
+			},
+			respond: func(string, []map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{
+					"btc_usdt": []map[string]interface{}{
+						{"order_id": "1", "result": true},
+						{"order_id": 3, "result": false, "error_code": 33014, "error_message": "order not exist"},
+					},
+					"eth_usdt": []map[string]interface{}{{"order_id": "2", "result": true}},
+				}
+			},
+			want: []BatchResult{
+				{ID: "1", Success: true},
+				{ID: "2", Success: true},
+				{ID: "3", Error: "the error number is 33014, order not exist"},
+				{ID: "4", Error: "unrecognized stockType: DOGE/USDT"},
+				{ID: "5", Error: "no result of the order"},
+			},
+			wantBody: []map[string]interface{}{
+				{"instrument_id": "btc_usdt", "order_ids": []interface{}{"1", "3", "5"}},
+				{"instrument_id": "eth_usdt", "order_ids": []interface{}{"2"}},
+			},
+		},
+		{
+			name:   "the result of an instrument",
+			orders: []Order{{ID: "1", StockType: "BTC/USDT"}, {ID: "2", StockType: "BTC/USDT"}, {ID: "3", StockType: "ETH/USDT"}},
+			respond: func(string, []map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{
+					"btc_usdt": map[string]interface{}{"result": true, "order_id": []interface{}{"1", 2}},
+					"eth_usdt": map[string]interface{}{"result": false, "order_id": []interface{}{"3"}},
+				}
+			},
+			want: []BatchResult{{ID: "1", Success: true}, {ID: "2", Success: true}, {ID: "3", Error: "no result of the order"}},
+			wantBody: []map[string]interface{}{
+				{"instrument_id": "btc_usdt", "order_ids": []interface{}{"1", "2"}},
+				{"instrument_id": "eth_usdt", "order_ids": []interface{}{"3"}},
+			},
+		},
+		{
+			name:   "the request fails",
+			orders: []Order{{ID: "1", StockType: "BTC/USDT"}},
+			respond: func(string, []map[string]interface{}) (int, interface{}) {
+				return http.StatusBadGateway, nil
+			},
+			want:     []BatchResult{{ID: "1", Error: "[POST /cancel_batch_orders] HTTP Status: 502, Info: <nil>"}},
+			wantBody: []map[string]interface{}{{"instrument_id": "btc_usdt", "order_ids": []interface{}{"1"}}},
+		},
+	}
+	for _, tt := range tests {
+		s := newOKEXV3Server(t, tt.respond)
+		got := s.exchange().BatchCancel(tt.orders)
+		s.Close()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: BatchCancel() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if len(s.requests) != 1 || s.requests[0] != "POST /api/spot/v3/cancel_batch_orders" {
+			t.Errorf("%v: requests = %v", tt.name, s.requests)
+			continue
+		}
+		if !reflect.DeepEqual(s.bodies[0], tt.wantBody) {
+			t.Errorf("%v: request body = %v, want %v", tt.name, s.bodies[0], tt.wantBody)
+		}
+	}
+}
+
+func TestOKEXV3Trade(t *testing.T) {
+	tests := []struct {
+		tradeType     string
+		price, amount float64
+		want          map[string]interface{}
+	}{
+		{"BUY", 65432.123, 0.1, map[string]interface{}{"side": "buy", "price": "65432.123", "size": "0.1", "type": "limit"}},
+		{"SELL", 0.00001234, 123456789, map[string]interface{}{"side": "sell", "price": "0.00001234", "size": "123456789", "type": "limit"}},
+		{"BUY", 0, 12.5, map[string]interface{}{"side": "buy", "price": "0", "size": "12.5", "type": "market"}},
+	}
+	for _, tt := range tests {
+		var body map[string]interface{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": true, "order_id": "1"})
+		}))
+		e := (&okexV3Server{Server: srv}).exchange()
+		got := e.Trade(tt.tradeType, "BTC/USDT", tt.price, tt.amount)
+		srv.Close()
+		if got != "1" {
+			t.Errorf("Trade(%v, %v, %v) = %v, want 1", tt.tradeType, tt.price, tt.amount, got)
+			continue
+		}
+		for k, v := range tt.want {
+			if body[k] != v {
+				t.Errorf("Trade(%v, %v, %v) %v = %v, want %v", tt.tradeType, tt.price, tt.amount, k, body[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,635 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// parquetMagic is at the start and the end of a Parquet file
+const parquetMagic = "PAR1"
+
+// the physical types of the Parquet columns
+const (
+	parquetBoolean = iota
+	parquetInt32
+	parquetInt64
+	parquetInt96
+	parquetFloat
+	parquetDouble
+	parquetByteArray
+	parquetFixedLenByteArray
+)
+
+// parquetCodecs are the names of the compression codecs of Parquet
+var parquetCodecs = []string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZO", "BROTLI", "LZ4", "ZSTD", "LZ4_RAW"}
+
+// parquetEncodings are the names of the encodings of Parquet
+var parquetEncodings = []string{"PLAIN", "GROUP_VAR_INT", "PLAIN_DICTIONARY", "RLE", "BIT_PACKED", "DELTA_BINARY_PACKED",
+	"DELTA_LENGTH_BYTE_ARRAY", "DELTA_BYTE_ARRAY", "RLE_DICTIONARY", "BYTE_STREAM_SPLIT"}
+
+// zstdDecoder decompresses the ZSTD pages, it is safe for the concurrent DecodeAll
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// thriftStruct is a struct of the thrift compact protocol by the field ids, the integers are int64,
+// the binaries are []byte, the lists are []interface{} and the maps are skipped
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) integer(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) text(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s thriftStruct) child(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// thriftReader decodes the thrift compact protocol of the Parquet metadata
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// size reads a length which must not exceed the remaining data
+func (r *thriftReader) size() (int, error) {
+	n, err := r.varint()
+	if err == nil && n > uint64(len(r.data)-r.pos) {
+		err = io.ErrUnexpectedEOF
+	}
+	return int(n), err
+}
+
+func (r *thriftReader) readStruct() (thriftStruct, error) {
+	s := thriftStruct{}
+	id := int16(0)
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return s, nil
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			n, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(n)
+		}
+		switch typ := b & 0x0f; typ {
+		case 1, 2: //字段中的布尔值就是类型本身
+			s[id] = typ == 1
+		default:
+			if s[id], err = r.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case 1, 2:
+		b, err := r.byte()
+		return b == 1, err
+	case 3:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		if r.pos+8 > len(r.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos-8:])), nil
+	case 8:
+		n, err := r.size()
+		if err != nil {
+			return nil, err
+		}
+		r.pos += n
+		return r.data[r.pos-n : r.pos], nil
+	case 9, 10:
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := int(b >> 4)
+		if n == 15 {
+			if n, err = r.size(); err != nil {
+				return nil, err
+			}
+		}
+		list := []interface{}{}
+		for i := 0; i < n; i++ {
+			v, err := r.readValue(b & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case 11:
+		n, err := r.size()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		b, err := r.byte()
+		for i := 0; i < n && err == nil; i++ {
+			if _, err = r.readValue(b >> 4); err == nil {
+				_, err = r.readValue(b & 0x0f)
+			}
+		}
+		return nil, err
+	case 12:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %v", typ)
+}
+
+// parquetColumn is a leaf column of a Parquet schema
+type parquetColumn struct {
+	name       string
+	typ        int64
+	typeLength int
+	optional   bool
+	nested     bool  //在嵌套的结构中或者可以重复
+	converted  int64 //ConvertedType, 没有时为 -1
+	scale      int
+	logical    thriftStruct
+}
+
+// timestamp returns the unit of a timestamp column and whether it is adjusted to UTC
+func (c parquetColumn) timestamp() (unit time.Duration, utc, ok bool) {
+	if ts := c.logical.child(8); ts != nil {
+		utc, _ = ts[1].(bool)
+		units := ts.child(2)
+		for i, unit := range []time.Duration{time.Millisecond, time.Microsecond, time.Nanosecond} {
+			if _, ok := units[int16(i+1)]; ok {
+				return unit, utc, true
+			}
+		}
+	}
+	switch c.converted {
+	case 9:
+		return time.Millisecond, true, true
+	case 10:
+		return time.Microsecond, true, true
+	}
+	return 0, false, false
+}
+
+// decimal returns the scale of a decimal column
+func (c parquetColumn) decimal() (scale int, ok bool) {
+	if d := c.logical.child(5); d != nil {
+		return int(d.integer(1)), true
+	}
+	return c.scale, c.converted == 5
+}
+
+// unix returns the unix seconds of a time value, the timestamps which are not adjusted to UTC and the dates
+// are in the location of opt, the numbers and the strings are parsed like the CSV
+func (c parquetColumn) unix(v interface{}, opt CSVOption) (int64, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Unix(), nil
+	case []byte:
+		return parseTime(strings.TrimSpace(string(v)), opt)
+	case float64:
+		return parseTime(strconv.FormatFloat(v, 'f', -1, 64), opt)
+	case int64:
+		if unit, utc, ok := c.timestamp(); ok {
+			per := int64(time.Second / unit)
+			t := time.Unix(v/per, v%per*int64(unit)).UTC()
+			if !utc {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), opt.Location)
+			}
+			return t.Unix(), nil
+		}
+		if _, ok := c.logical[6]; ok || c.converted == 6 {
+			return time.Date(1970, 1, 1+int(v), 0, 0, 0, 0, opt.Location).Unix(), nil
+		}
+		return parseTime(strconv.FormatInt(v, 10), opt)
+	}
+	return 0, fmt.Errorf("invalid time %v", v)
+}
+
+// float returns the number of a price value, the decimals are scaled and the strings are parsed
+func (c parquetColumn) float(v interface{}) (float64, error) {
+	scale, isDecimal := c.decimal()
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		if isDecimal {
+			return strconv.ParseFloat(fmt.Sprintf("%ve-%v", v, scale), 64)
+		}
+		return float64(v), nil
+	case []byte:
+		if isDecimal {
+			n := new(big.Int).SetBytes(v)
+			if len(v) > 0 && v[0]&0x80 != 0 { //大端的补码
+				n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(v)*8)))
+			}
+			return strconv.ParseFloat(fmt.Sprintf("%ve-%v", n, scale), 64)
+		}
+		return strconv.ParseFloat(strings.TrimSpace(string(v)), 64)
+	}
+	return 0, fmt.Errorf("invalid number %v", v)
+}
+
+// parquetFile is the metadata of a Parquet file
+type parquetFile struct {
+	r       io.ReaderAt
+	size    int64
+	columns []parquetColumn
+	groups  []thriftStruct
+}
+
+// openParquet reads the footer of a Parquet file
+func openParquet(r io.ReaderAt, size int64) (*parquetFile, error) {
+	tail := make([]byte, 8)
+	head := make([]byte, 4)
+	if size < 12 {
+		return nil, fmt.Errorf("Not a Parquet file")
+	}
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	length := int64(binary.LittleEndian.Uint32(tail))
+	if string(head) != parquetMagic || string(tail[4:]) != parquetMagic || length > size-12 {
+		return nil, fmt.Errorf("Not a Parquet file")
+	}
+	footer := make([]byte, length)
+	if _, err := r.ReadAt(footer, size-8-length); err != nil {
+		return nil, err
+	}
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("Read Parquet metadata error: %v", err)
+	}
+	f := &parquetFile{r: r, size: size}
+	schema := meta.list(2)
+	remain := []int64{} //各层结构中未读取的子元素数量
+	for i, v := range schema {
+		e, _ := v.(thriftStruct)
+		for len(remain) > 0 && remain[len(remain)-1] == 0 {
+			remain = remain[:len(remain)-1]
+		}
+		if i > 0 && len(remain) == 0 {
+			return nil, fmt.Errorf("Invalid Parquet schema")
+		}
+		if i > 0 {
+			remain[len(remain)-1]--
+		}
+		if n := e.integer(5); n > 0 || i == 0 {
+			remain = append(remain, n)
+			continue
+		}
+		c := parquetColumn{
+			name:       e.text(4),
+			typ:        e.integer(1),
+			typeLength: int(e.integer(2)),
+			optional:   e.integer(3) == 1,
+			nested:     len(remain) > 1 || e.integer(3) == 2,
+			converted:  -1,
+			scale:      int(e.integer(7)),
+			logical:    e.child(10),
+		}
+		if _, ok := e[6]; ok {
+			c.converted = e.integer(6)
+		}
+		f.columns = append(f.columns, c)
+	}
+	for _, g := range meta.list(4) {
+		group, _ := g.(thriftStruct)
+		if len(group.list(1)) != len(f.columns) {
+			return nil, fmt.Errorf("Invalid Parquet row group")
+		}
+		f.groups = append(f.groups, group)
+	}
+	return f, nil
+}
+
+// values returns the values of a column in all the row groups, the nulls are nil, the integers are int64,
+// the floats are float64, INT96 is time.Time and the others are []byte
+func (f *parquetFile) values(index int) (values []interface{}, err error) {
+	c := f.columns[index]
+	if c.nested {
+		return nil, fmt.Errorf("Nested column %v is not supported", c.name)
+	}
+	for _, group := range f.groups {
+		chunk, _ := group.list(1)[index].(thriftStruct)
+		meta := chunk.child(3)
+		if meta == nil || chunk.text(1) != "" {
+			return nil, fmt.Errorf("The column %v in another file is not supported", c.name)
+		}
+		start, length := meta.integer(9), meta.integer(7)
+		if offset := meta.integer(11); offset > 0 && offset < start {
+			start = offset
+		}
+		if start < 4 || length < 0 || start+length > f.size-8 {
+			return nil, fmt.Errorf("Invalid column chunk of %v", c.name)
+		}
+		data := make([]byte, length)
+		if _, err = f.r.ReadAt(data, start); err != nil {
+			return nil, err
+		}
+		if values, err = readParquetChunk(values, data, c, meta.integer(4), meta.integer(5)); err != nil {
+			return nil, fmt.Errorf("Read column %v error: %v", c.name, err)
+		}
+	}
+	return
+}
+
+// readParquetChunk appends the values of the pages of a column chunk
+func readParquetChunk(values []interface{}, data []byte, c parquetColumn, codec, count int64) ([]interface{}, error) {
+	r := &thriftReader{data: data}
+	var dict []interface{}
+	for read := int64(0); read < count && r.pos < len(data); {
+		header, err := r.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		size := int(header.integer(3))
+		if size < 0 || size > len(data)-r.pos {
+			return nil, io.ErrUnexpectedEOF
+		}
+		page := data[r.pos : r.pos+size]
+		r.pos += size
+		switch header.integer(1) {
+		case 0: // DATA_PAGE
+			h := header.child(5)
+			if page, err = decompress(codec, page, header.integer(2)); err != nil {
+				return nil, err
+			}
+			n := int(h.integer(1))
+			var defs []int
+			if c.optional {
+				if len(page) < 4 || int(binary.LittleEndian.Uint32(page)) > len(page)-4 {
+					return nil, io.ErrUnexpectedEOF
+				}
+				length := int(binary.LittleEndian.Uint32(page))
+				if defs, err = rleValues(page[4:4+length], 1, n); err != nil {
+					return nil, err
+				}
+				page = page[4+length:]
+			}
+			if values, err = appendPage(values, page, c, h.integer(2), n, defs, dict); err != nil {
+				return nil, err
+			}
+			read += int64(n)
+		case 2: // DICTIONARY_PAGE
+			if page, err = decompress(codec, page, header.integer(2)); err != nil {
+				return nil, err
+			}
+			if dict, err = plainValues(page, c, int(header.child(7).integer(1))); err != nil {
+				return nil, err
+			}
+		case 3: // DATA_PAGE_V2, 重复和定义级别不压缩
+			h := header.child(8)
+			n := int(h.integer(1))
+			levels := int(h.integer(5) + h.integer(6))
+			if levels < 0 || levels > len(page) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			var defs []int
+			if c.optional {
+				if defs, err = rleValues(page[h.integer(6):levels], 1, n); err != nil {
+					return nil, err
+				}
+			}
+			page = page[levels:]
+			if compressed, ok := h[7].(bool); !ok || compressed {
+				if page, err = decompress(codec, page, header.integer(2)-int64(levels)); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = appendPage(values, page, c, h.integer(4), n, defs, dict); err != nil {
+				return nil, err
+			}
+			read += int64(n)
+		}
+	}
+	return values, nil
+}
+
+// decompress returns the page decompressed by the codec
+func decompress(codec int64, data []byte, size int64) ([]byte, error) {
+	switch codec {
+	case 0:
+		return data, nil
+	case 1:
+		return s2.Decode(nil, data)
+	case 2:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	case 6:
+		if size < 0 || size > int64(len(data))*1024 {
+			size = 0
+		}
+		return zstdDecoder.DecodeAll(data, make([]byte, 0, size))
+	}
+	if codec > 0 && codec < int64(len(parquetCodecs)) {
+		return nil, fmt.Errorf("unsupported compression %v", parquetCodecs[codec])
+	}
+	return nil, fmt.Errorf("unsupported compression %v", codec)
+}
+
+// appendPage appends the values of a data page, the definition levels of 0 are nulls
+func appendPage(values []interface{}, data []byte, c parquetColumn, encoding int64, n int, defs []int, dict []interface{}) ([]interface{}, error) {
+	count := n
+	if defs != nil {
+		count = 0
+		for _, d := range defs {
+			count += d
+		}
+	}
+	var page []interface{}
+	var err error
+	switch encoding {
+	case 0: // PLAIN
+		page, err = plainValues(data, c, count)
+	case 2, 8: // PLAIN_DICTIONARY, RLE_DICTIONARY
+		if count == 0 {
+			break
+		}
+		if dict == nil || len(data) == 0 {
+			return nil, fmt.Errorf("no dictionary page")
+		}
+		ids, err := rleValues(data[1:], int(data[0]), count)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if id >= len(dict) {
+				return nil, fmt.Errorf("invalid dictionary index %v", id)
+			}
+			page = append(page, dict[id])
+		}
+	default:
+		if encoding > 0 && encoding < int64(len(parquetEncodings)) {
+			return nil, fmt.Errorf("unsupported encoding %v", parquetEncodings[encoding])
+		}
+		return nil, fmt.Errorf("unsupported encoding %v", encoding)
+	}
+	if err != nil || defs == nil {
+		return append(values, page...), err
+	}
+	for _, d := range defs {
+		if d == 0 {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, page[0])
+		page = page[1:]
+	}
+	return values, nil
+}
+
+// plainValues decodes n values of the PLAIN encoding
+func plainValues(data []byte, c parquetColumn, n int) (values []interface{}, err error) {
+	sizes := map[int64]int{parquetInt32: 4, parquetInt64: 8, parquetInt96: 12, parquetFloat: 4, parquetDouble: 8, parquetFixedLenByteArray: c.typeLength}
+	pos := 0
+	for i := 0; i < n; i++ {
+		size, fixed := sizes[c.typ]
+		if c.typ == parquetByteArray {
+			if pos+4 > len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+		} else if c.typ == parquetBoolean {
+			if i/8 >= len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			values = append(values, data[i/8]>>uint(i%8)&1 == 1)
+			continue
+		} else if !fixed {
+			return nil, fmt.Errorf("unknown type %v", c.typ)
+		}
+		if size < 0 || pos+size > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := data[pos : pos+size]
+		pos += size
+		switch c.typ {
+		case parquetInt32:
+			values = append(values, int64(int32(binary.LittleEndian.Uint32(v))))
+		case parquetInt64:
+			values = append(values, int64(binary.LittleEndian.Uint64(v)))
+		case parquetInt96: //一天中的纳秒和儒略日
+			days := int64(int32(binary.LittleEndian.Uint32(v[8:])))
+			values = append(values, time.Unix((days-2440588)*86400, int64(binary.LittleEndian.Uint64(v))).UTC())
+		case parquetFloat:
+			values = append(values, float64(math.Float32frombits(binary.LittleEndian.Uint32(v))))
+		case parquetDouble:
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(v)))
+		default:
+			values = append(values, v)
+		}
+	}
+	return
+}
+
+// rleValues decodes n values of the RLE and bit-packing hybrid encoding
+func rleValues(data []byte, width, n int) ([]int, error) {
+	if width > 32 {
+		return nil, fmt.Errorf("invalid bit width %v", width)
+	}
+	values := []int{}
+	r := &thriftReader{data: data}
+	for len(values) < n {
+		header, err := r.varint()
+		if err != nil || header>>1 == 0 {
+			return nil, fmt.Errorf("invalid RLE data")
+		}
+		if header&1 == 0 {
+			size := (width + 7) / 8
+			if r.pos+size > len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			v := 0
+			for i := 0; i < size; i++ {
+				v |= int(data[r.pos+i]) << uint(8*i)
+			}
+			r.pos += size
+			for i := uint64(0); i < header>>1 && len(values) < n; i++ {
+				values = append(values, v)
+			}
+			continue
+		}
+		count := int(header>>1) * 8
+		packed := data[r.pos:]
+		if count*width/8 < len(packed) {
+			packed = packed[:count*width/8]
+		}
+		r.pos += len(packed)
+		for i := 0; i < count && (i+1)*width <= len(packed)*8 && len(values) < n; i++ {
+			v := 0
+			for b := 0; b < width; b++ {
+				bit := i*width + b
+				v |= int(packed[bit/8]>>uint(bit%8)&1) << uint(b)
+			}
+			values = append(values, v)
+		}
+		if len(packed) < count*width/8 && len(values) < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,569 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/model"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// thriftWriter encodes the thrift compact protocol for the Parquet files of the tests
+type thriftWriter struct {
+	bytes.Buffer
+	last []int16
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	w.Write(b[:binary.PutUvarint(b, v)])
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.WriteByte(typ)
+		w.varint(uint64(id<<1 ^ id>>15))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) begin()         { w.last = append(w.last, 0) }
+func (w *thriftWriter) end()           { w.WriteByte(0); w.last = w.last[:len(w.last)-1] }
+func (w *thriftWriter) zigzag(v int64) { w.varint(uint64(v<<1 ^ v>>63)) }
+
+func (w *thriftWriter) int(id int16, v int64) {
+	w.field(id, 6)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, 1)
+	} else {
+		w.field(id, 2)
+	}
+}
+
+func (w *thriftWriter) binary(id int16, v []byte) {
+	w.field(id, 8)
+	w.varint(uint64(len(v)))
+	w.Write(v)
+}
+
+func (w *thriftWriter) list(id int16, typ byte, n int) {
+	w.field(id, 9)
+	if n < 15 {
+		w.WriteByte(byte(n)<<4 | typ)
+	} else {
+		w.WriteByte(0xf0 | typ)
+		w.varint(uint64(n))
+	}
+}
+
+func (w *thriftWriter) child(id int16) {
+	w.field(id, 12)
+	w.begin()
+}
+
+// testColumn is a column of the Parquet files of the tests, a nil value is a null
+type testColumn struct {
+	name      string
+	typ       int64
+	length    int   //FIXED_LEN_BYTE_ARRAY 的长度
+	optional  bool  //有空值时必须为 true
+	converted int64 //0 表示没有 ConvertedType
+	scale     int
+	logical   func(w *thriftWriter)
+	group     string //不为空时列在这个名称的结构中
+	dict      bool
+	values    []interface{}
+}
+
+// testParquet is a Parquet file of the tests
+type testParquet struct {
+	codec   int64
+	v2      bool
+	groups  int
+	columns []testColumn
+}
+
+func (p testParquet) compress(data []byte) []byte {
+	switch p.codec {
+	case 1:
+		return s2.EncodeSnappy(nil, data)
+	case 2:
+		buf := bytes.Buffer{}
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	case 6:
+		w, _ := zstd.NewWriter(nil)
+		return w.EncodeAll(data, nil)
+	}
+	return data
+}
+
+func testPlain(c testColumn, values []interface{}) []byte {
+	buf := bytes.Buffer{}
+	for _, v := range values {
+		switch c.typ {
+		case parquetInt32:
+			binary.Write(&buf, binary.LittleEndian, int32(v.(int)))
+		case parquetInt64:
+			binary.Write(&buf, binary.LittleEndian, int64(v.(int)))
+		case parquetInt96:
+			t := v.(time.Time)
+			days := t.Unix()/86400 + 2440588
+			binary.Write(&buf, binary.LittleEndian, t.UnixNano()-t.Unix()/86400*86400*1e9)
+			binary.Write(&buf, binary.LittleEndian, int32(days))
+		case parquetFloat:
+			binary.Write(&buf, binary.LittleEndian, float32(v.(float64)))
+		case parquetDouble:
+			binary.Write(&buf, binary.LittleEndian, v.(float64))
+		case parquetByteArray:
+			binary.Write(&buf, binary.LittleEndian, int32(len(v.(string))))
+			buf.WriteString(v.(string))
+		case parquetFixedLenByteArray:
+			buf.Write(v.([]byte))
+		}
+	}
+	return buf.Bytes()
+}
+
+// testRLE encodes the values as RLE runs
+func testRLE(values []int, width int) []byte {
+	w := thriftWriter{}
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		w.varint(uint64(j-i) << 1)
+		for b := 0; b < (width+7)/8; b++ {
+			w.WriteByte(byte(values[i] >> uint(8*b)))
+		}
+		i = j
+	}
+	return w.Bytes()
+}
+
+// testBitPacked encodes the values as a bit-packed run
+func testBitPacked(values []int, width int) []byte {
+	w := thriftWriter{}
+	groups := (len(values) + 7) / 8
+	w.varint(uint64(groups<<1 | 1))
+	packed := make([]byte, groups*width)
+	for i, v := range values {
+		for b := 0; b < width; b++ {
+			if v>>uint(b)&1 == 1 {
+				bit := i*width + b
+				packed[bit/8] |= 1 << uint(bit%8)
+			}
+		}
+	}
+	w.Write(packed)
+	return w.Bytes()
+}
+
+// page writes the page header and the page
+func (p testParquet) page(out *bytes.Buffer, typ int64, raw, body []byte, header func(w *thriftWriter)) {
+	w := thriftWriter{}
+	w.begin()
+	w.int(1, typ)
+	w.int(2, int64(len(raw)))
+	w.int(3, int64(len(body)))
+	header(&w)
+	w.end()
+	out.Write(w.Bytes())
+	out.Write(body)
+}
+
+// chunk writes the pages of the values of a column and returns the offsets of the pages
+func (p testParquet) chunk(out *bytes.Buffer, c testColumn, values []interface{}) (dictOffset, dataOffset int64) {
+	defs := []int{}
+	present := []interface{}{}
+	for _, v := range values {
+		if v == nil {
+			defs = append(defs, 0)
+			continue
+		}
+		defs = append(defs, 1)
+		present = append(present, v)
+	}
+	encoding := int64(0)
+	data := testPlain(c, present)
+	if c.dict {
+		dict := []interface{}{}
+		ids := []int{}
+		for _, v := range present {
+			id := len(dict)
+			for i, d := range dict {
+				if reflect.DeepEqual(d, v) {
+					id = i
+				}
+			}
+			if id == len(dict) {
+				dict = append(dict, v)
+			}
+			ids = append(ids, id)
+		}
+		raw := testPlain(c, dict)
+		dictOffset = int64(out.Len())
+		p.page(out, 2, raw, p.compress(raw), func(w *thriftWriter) {
+			w.child(7)
+			w.int(1, int64(len(dict)))
+			w.int(2, 0)
+			w.end()
+		})
+		width := 1
+		for 1<<uint(width) < len(dict) {
+			width++
+		}
+		encoding, data = 8, append([]byte{byte(width)}, testBitPacked(ids, width)...)
+	}
+	levels := []byte{}
+	if c.optional {
+		levels = testRLE(defs, 1)
+	}
+	dataOffset = int64(out.Len())
+	if p.v2 {
+		p.page(out, 3, append(levels, data...), append(levels, p.compress(data)...), func(w *thriftWriter) {
+			w.child(8)
+			w.int(1, int64(len(values)))
+			w.int(2, int64(len(values)-len(present)))
+			w.int(3, int64(len(values)))
+			w.int(4, encoding)
+			w.int(5, int64(len(levels)))
+			w.int(6, 0)
+			w.end()
+		})
+		return
+	}
+	raw := data
+	if c.optional {
+		raw = make([]byte, 4)
+		binary.LittleEndian.PutUint32(raw, uint32(len(levels)))
+		raw = append(append(raw, levels...), data...)
+	}
+	p.page(out, 0, raw, p.compress(raw), func(w *thriftWriter) {
+		w.child(5)
+		w.int(1, int64(len(values)))
+		w.int(2, encoding)
+		w.int(3, 3)
+		w.int(4, 3)
+		w.end()
+	})
+	return
+}
+
+// bytes returns the Parquet file
+func (p testParquet) bytes() []byte {
+	out := bytes.NewBufferString(parquetMagic)
+	rows := len(p.columns[0].values)
+	if p.groups == 0 {
+		p.groups = 1
+	}
+	meta := thriftWriter{}
+	meta.begin()
+	meta.int(1, 1)
+	elements := 1
+	for _, c := range p.columns {
+		elements++
+		if c.group != "" {
+			elements++
+		}
+	}
+	meta.list(2, 12, elements)
+	meta.begin()
+	meta.binary(4, []byte("schema"))
+	meta.int(5, int64(len(p.columns)))
+	meta.end()
+	for _, c := range p.columns {
+		if c.group != "" {
+			meta.begin()
+			meta.int(3, 0)
+			meta.binary(4, []byte(c.group))
+			meta.int(5, 1)
+			meta.end()
+		}
+		meta.begin()
+		meta.int(1, c.typ)
+		if c.length > 0 {
+			meta.int(2, int64(c.length))
+		}
+		if c.optional {
+			meta.int(3, 1)
+		} else {
+			meta.int(3, 0)
+		}
+		meta.binary(4, []byte(c.name))
+		if c.converted > 0 {
+			meta.int(6, c.converted)
+		}
+		if c.scale > 0 {
+			meta.int(7, int64(c.scale))
+			meta.int(8, 18)
+		}
+		if c.logical != nil {
+			meta.child(10)
+			c.logical(&meta)
+			meta.end()
+		}
+		meta.end()
+	}
+	meta.int(3, int64(rows))
+	meta.list(4, 12, p.groups)
+	for g := 0; g < p.groups; g++ {
+		from, to := rows*g/p.groups, rows*(g+1)/p.groups
+		meta.begin()
+		meta.list(1, 12, len(p.columns))
+		for _, c := range p.columns {
+			start := int64(out.Len())
+			dictOffset, dataOffset := p.chunk(out, c, c.values[from:to])
+			meta.begin()
+			meta.int(2, start)
+			meta.child(3)
+			meta.int(1, c.typ)
+			meta.list(2, 5, 1)
+			meta.zigzag(0)
+			meta.list(3, 8, 1)
+			meta.varint(uint64(len(c.name)))
+			meta.WriteString(c.name)
+			meta.int(4, p.codec)
+			meta.int(5, int64(to-from))
+			meta.int(6, int64(out.Len())-start)
+			meta.int(7, int64(out.Len())-start)
+			meta.int(9, dataOffset)
+			if c.dict {
+				meta.int(11, dictOffset)
+			}
+			meta.end()
+			meta.end()
+		}
+		meta.int(2, 0)
+		meta.int(3, int64(to-from))
+		meta.end()
+	}
+	meta.list(5, 12, 1)
+	meta.begin()
+	meta.binary(1, []byte("pandas"))
+	meta.binary(2, []byte(`{"index_columns": []}`))
+	meta.end()
+	meta.binary(6, []byte("quantbot test"))
+	meta.end()
+	out.Write(meta.Bytes())
+	binary.Write(out, binary.LittleEndian, uint32(meta.Len()))
+	out.WriteString(parquetMagic)
+	return out.Bytes()
+}
+
+// timestampType writes the logical type of a timestamp
+func timestampType(unit int16, utc bool) func(w *thriftWriter) {
+	return func(w *thriftWriter) {
+		w.child(8)
+		w.bool(1, utc)
+		w.child(2)
+		w.child(unit)
+		w.end()
+		w.end()
+		w.end()
+	}
+}
+
+func TestReadCandlesParquet(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := func(typ int64, values ...interface{}) []testColumn {
+		columns := []testColumn{}
+		for _, name := range []string{"open", "high", "low", "close"} {
+			columns = append(columns, testColumn{name: name, typ: typ, values: values})
+		}
+		return columns
+	}
+	dictionary := func(columns []testColumn) []testColumn {
+		for i := range columns {
+			columns[i].dict = true
+		}
+		return columns
+	}
+	// 2018-01-01 08:00 和 08:01 在上海时区, 按纳秒的本地时间存储
+	naive := []interface{}{int(time.Date(2018, 1, 1, 8, 1, 0, 0, time.UTC).UnixNano()), int(time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC).UnixNano()), int(time.Date(2018, 1, 1, 8, 2, 0, 0, time.UTC).UnixNano())}
+	tests := []struct {
+		name    string
+		file    testParquet
+		opt     CSVOption
+		want    []model.Candle
+		wantErr string
+	}{
+		{
+			name: "pandas with snappy, dictionaries, row groups and a null volume",
+			file: testParquet{codec: 1, groups: 2, columns: append(append([]testColumn{
+				{name: "__index_level_0__", typ: parquetInt64, values: []interface{}{0, 1, 2}},
+				{name: "date", typ: parquetInt64, logical: timestampType(3, false), values: naive},
+			}, dictionary(prices(parquetDouble, 1.5, 1.5, 2.5))...),
+				testColumn{name: "volume", typ: parquetDouble, optional: true, dict: true, values: []interface{}{10.0, nil, 10.0}},
+			)},
+			opt: CSVOption{Location: shanghai},
+			want: []model.Candle{
+				{Timestamp: 1514764800, Open: 1.5, High: 1.5, Low: 1.5, Close: 1.5},
+				{Timestamp: 1514764860, Open: 1.5, High: 1.5, Low: 1.5, Close: 1.5, Volume: 10},
+				{Timestamp: 1514764920, Open: 2.5, High: 2.5, Low: 2.5, Close: 2.5, Volume: 10},
+			},
+		},
+		{
+			name: "spark with gzip, data page v2, int96, decimals and a nested column",
+			file: testParquet{codec: 2, v2: true, columns: append([]testColumn{
+				{name: "note", typ: parquetByteArray, group: "extra", values: []interface{}{"a"}},
+				{name: "timestamp", typ: parquetInt96, values: []interface{}{time.Date(2018, 1, 1, 0, 0, 0, 5e8, time.UTC)}},
+				{name: "vol", typ: parquetFixedLenByteArray, length: 3, optional: true, converted: 5, scale: 2, values: []interface{}{[]byte{0xff, 0xff, 0x85}}},
+			}, func() []testColumn {
+				columns := prices(parquetInt64, 12345)
+				for i := range columns {
+					columns[i].converted, columns[i].scale = 5, 2
+				}
+				return columns
+			}()...)},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 123.45, High: 123.45, Low: 123.45, Close: 123.45, Volume: -1.23}},
+		},
+		{
+			name: "strings with zstd and mapped columns",
+			file: testParquet{codec: 6, columns: append([]testColumn{
+				{name: "Day", typ: parquetByteArray, optional: true, values: []interface{}{"2018-01-01 08:00:00"}},
+				{name: "Amount", typ: parquetByteArray, values: []interface{}{" 3 "}},
+			}, prices(parquetFloat, 0.5)...)},
+			opt:  CSVOption{Columns: map[string]string{"time": "Day", "volume": "Amount"}, Location: shanghai},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 0.5, High: 0.5, Low: 0.5, Close: 0.5, Volume: 3}},
+		},
+		{
+			name: "timestamp millis adjusted to UTC",
+			file: testParquet{columns: append([]testColumn{
+				{name: "time", typ: parquetInt64, converted: 9, values: []interface{}{1514764800500}},
+			}, prices(parquetInt32, 2)...)},
+			opt:  CSVOption{Location: shanghai},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 2, High: 2, Low: 2, Close: 2}},
+		},
+		{
+			name: "date in the location",
+			file: testParquet{columns: append([]testColumn{
+				{name: "date", typ: parquetInt32, logical: func(w *thriftWriter) { w.child(6); w.end() }, values: []interface{}{17532}},
+			}, prices(parquetInt32, 2)...)},
+			opt:  CSVOption{Location: shanghai},
+			want: []model.Candle{{Timestamp: 1514736000, Open: 2, High: 2, Low: 2, Close: 2}},
+		},
+		{
+			name: "unix milliseconds without a logical type",
+			file: testParquet{v2: true, columns: append([]testColumn{
+				{name: "open_time", typ: parquetInt64, values: []interface{}{1514764800000}},
+			}, prices(parquetDouble, 2.0)...)},
+			want: []model.Candle{{Timestamp: 1514764800, Open: 2, High: 2, Low: 2, Close: 2}},
+		},
+		{
+			name:    "missing column",
+			file:    testParquet{columns: append([]testColumn{{name: "time", typ: parquetInt64, values: []interface{}{1514764800}}}, prices(parquetDouble, 2.0)[:3]...)},
+			wantErr: "Can not find the close column",
+		},
+		{
+			name:    "nested column",
+			file:    testParquet{columns: append([]testColumn{{name: "time", typ: parquetInt64, group: "t", values: []interface{}{1514764800}}}, prices(parquetDouble, 2.0)...)},
+			wantErr: "Nested column time is not supported",
+		},
+		{
+			name:    "null time",
+			file:    testParquet{columns: append([]testColumn{{name: "time", typ: parquetInt64, optional: true, values: []interface{}{nil}}}, prices(parquetDouble, 2.0)...)},
+			wantErr: "Row 1: empty time",
+		},
+		{
+			name:    "invalid price",
+			file:    testParquet{columns: append([]testColumn{{name: "time", typ: parquetInt64, values: []interface{}{1514764800}}}, prices(parquetByteArray, "x")...)},
+			wantErr: "Row 1: invalid open",
+		},
+		{
+			name:    "unsupported compression",
+			file:    testParquet{codec: 7, columns: append([]testColumn{{name: "time", typ: parquetInt64, values: []interface{}{1514764800}}}, prices(parquetDouble, 2.0)...)},
+			wantErr: "unsupported compression LZ4_RAW",
+		},
+	}
+	for _, tt := range tests {
+		data := tt.file.bytes()
+		got, err := ReadCandlesParquet(bytes.NewReader(data), int64(len(data)), tt.opt)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%v: ReadCandlesParquet() error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: ReadCandlesParquet() error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: ReadCandlesParquet() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCandlesParquetInvalid(t *testing.T) {
+	csv := []byte("time,open,high,low,close\n1514764800,1,1,1,1\n")
+	file := testParquet{columns: []testColumn{{name: "time", typ: parquetInt64, values: []interface{}{1514764800}}}}.bytes()
+	for _, data := range [][]byte{nil, csv, file[:len(file)-1], append([]byte(parquetMagic), file[len(file)-12:]...)} {
+		if _, err := ReadCandlesParquet(bytes.NewReader(data), int64(len(data)), CSVOption{}); err == nil {
+			t.Errorf("ReadCandlesParquet(%q) should fail", data)
+		}
+	}
+}
+
+func TestRLEValues(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		width int
+		n     int
+		want  []int
+	}{
+		{[]byte{0x03, 0x88, 0xc6, 0xfa}, 3, 8, []int{0, 1, 2, 3, 4, 5, 6, 7}}, //Parquet 文档中位打包的例子
+		{[]byte{0x06, 0x05, 0x03, 0x01}, 3, 5, []int{5, 5, 5, 1, 0}},
+		{[]byte{0x08, 0x2c, 0x01}, 9, 4, []int{300, 300, 300, 300}},
+		{[]byte{0x04}, 0, 2, []int{0, 0}},
+	}
+	for _, tt := range tests {
+		if got, err := rleValues(tt.data, tt.width, tt.n); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rleValues(%x, %v, %v) = %v %v, want %v", tt.data, tt.width, tt.n, got, err, tt.want)
+		}
+	}
+	for _, data := range [][]byte{{}, {0x00}, {0x02}, {0x03, 0x88}} {
+		if _, err := rleValues(data, 3, 8); err == nil {
+			t.Errorf("rleValues(%x) should fail", data)
+		}
+	}
+}
+
+func TestParquetFloat(t *testing.T) {
+	tests := []struct {
+		column parquetColumn
+		value  interface{}
+		want   float64
+	}{
+		{parquetColumn{converted: -1}, 1.25, 1.25},
+		{parquetColumn{converted: -1}, int64(7), 7},
+		{parquetColumn{converted: -1}, []byte("0.1"), 0.1},
+		{parquetColumn{converted: 5, scale: 8}, int64(12345678901), 123.45678901},
+		{parquetColumn{converted: -1, logical: thriftStruct{5: thriftStruct{1: int64(3)}}}, []byte{0x30, 0x39}, 12.345},
+		{parquetColumn{converted: 5, scale: 1}, []byte{0x80, 0x00}, -3276.8},
+	}
+	for _, tt := range tests {
+		if got, err := tt.column.float(tt.value); err != nil || got != tt.want {
+			t.Errorf("float(%v) = %v %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
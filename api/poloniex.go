@@ -9,9 +9,9 @@ import (
 	"time"
 
 	"github.com/bitly/go-simplejson"
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
 )
 
 // Poloniex the exchange struct of poloniex
@@ -24,6 +24,7 @@ type Poloniex struct {
 	host             string
 	logger           model.Logger
 	option           Option
+	resampler        resampler
 
 	limit     float64
 	lastSleep int64
@@ -428,6 +429,21 @@ func (e *Poloniex) CancelOrder(order Order) bool {
 	return true
 }
 
+// ReplaceOrder cancel an order and place a new one at the price and amount
+func (e *Poloniex) ReplaceOrder(order Order, price, amount interface{}, msgs ...interface{}) interface{} {
+	return replaceOrder(e, e.logger, order, conver.Float64Must(price), conver.Float64Must(amount), msgs...)
+}
+
+// BatchTrade place the orders one by one
+func (e *Poloniex) BatchTrade(orders []BatchOrder, msgs ...interface{}) []BatchResult {
+	return batchTrade(e, orders, msgs...)
+}
+
+// BatchCancel cancel the orders one by one
+func (e *Poloniex) BatchCancel(orders []Order) []BatchResult {
+	return batchCancel(e, orders)
+}
+
 // getTicker get market ticker & depth
 func (e *Poloniex) getTicker(stockType string, sizes ...interface{}) (ticker Ticker, err error) {
 	e.lastTimes++
@@ -486,8 +502,13 @@ func (e *Poloniex) GetTicker(stockType string, sizes ...interface{}) interface{}
 	return ticker
 }
 
-// GetRecords get candlestick data
+// GetRecords get candlestick data, the periods which are not provided are resampled
 func (e *Poloniex) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.resampler.records(e.option.Type, e.recordsPeriodMap, e.getRecords, stockType, period, sizes...)
+}
+
+// getRecords get candlestick data of the provided periods
+func (e *Poloniex) getRecords(stockType, period string, sizes ...interface{}) interface{} {
 	e.lastTimes++
 	stockType = strings.ToUpper(stockType)
 	if _, ok := e.stockTypeMap[stockType]; !ok {
@@ -548,8 +569,7 @@ func (e *Poloniex) GetRecords(stockType, period string, sizes ...interface{}) in
 		}
 	}
 	e.records[period] = append(e.records[period], recordsNew...)
-	if len(e.records[period]) > size {
-		e.records[period] = e.records[period][len(e.records[period])-size : len(e.records[period])]
-	}
-	return e.records[period]
+	cache, records := cacheRecords(e.records[period], size)
+	e.records[period] = cache
+	return records
 }
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miaolz123/conver"
+)
+
+// periodUnits are the seconds of the period units, a period is a unit with an optional multiple, like M, M3, H4, D3
+var periodUnits = map[byte]int64{'M': 60, 'H': 3600, 'D': 86400, 'W': 604800}
+
+// weekOffset moves the week buckets to start on Monday, 1970-01-01 is a Thursday
+const weekOffset = 4 * 86400
+
+// PeriodSeconds returns the seconds of the period, or 0 if the period is invalid
+func PeriodSeconds(period string) int64 {
+	if len(period) == 0 {
+		return 0
+	}
+	unit, ok := periodUnits[period[0]]
+	if !ok {
+		return 0
+	}
+	if len(period) == 1 {
+		return unit
+	}
+	multiple := conver.Int64Must(period[1:])
+	if multiple <= 0 {
+		return 0
+	}
+	return unit * multiple
+}
+
+// recordsCacheSize is the least records an exchange keeps of a series, a call of a smaller size does not shrink
+// the cache because the cached series only grows with the newer records, otherwise the next call of the default
+// size or the resampler would get the small series
+const recordsCacheSize = 200
+
+// cacheRecords trims the cached records to the larger of the size and recordsCacheSize, and returns the trimmed
+// cache and its latest records of the size
+func cacheRecords(records []Record, size int) (cache, latest []Record) {
+	keep := size
+	if keep < recordsCacheSize {
+		keep = recordsCacheSize
+	}
+	if len(records) > keep {
+		records = records[len(records)-keep:]
+	}
+	if len(records) > size {
+		return records, records[len(records)-size:]
+	}
+	return records, records
+}
+
+// resampler builds the records of the periods an exchange does not provide from the finer records it provides,
+// every exchange keeps its own resampler because the provided periods are different
+type resampler struct {
+	mu     sync.Mutex
+	series map[string][]Record // stockType#period 对应的聚合K线
+}
+
+// records returns the records of the provided periods from fetch, other periods are resampled from the largest
+// provided period which divides it, the aggregated series is kept and only the records since its last bucket
+// are fetched again, the collected candles are prepended when the exchange returns less records than the size
+func (r *resampler) records(exchange string, periods map[string]string, fetch func(string, string, ...interface{}) interface{}, stockType, period string, sizes ...interface{}) interface{} {
+	stockType = strings.ToUpper(stockType)
+	size := 200
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	if _, ok := periods[period]; ok {
+		result := fetch(stockType, period, sizes...)
+		if records, ok := result.([]Record); ok && len(sizes) > 0 {
+			return history(exchange, stockType, period, records, size)
+		}
+		return result
+	}
+	base := basePeriod(periods, period)
+	if base == "" {
+		return fetch(stockType, period, sizes...)
+	}
+	seconds, baseSeconds := PeriodSeconds(period), PeriodSeconds(base)
+	offset := int64(0)
+	if period[0] == 'W' {
+		offset = weekOffset
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.series == nil {
+		r.series = make(map[string][]Record)
+	}
+	key := stockType + "#" + period
+	series := r.series[key]
+	need := (size + 1) * int(seconds/baseSeconds)
+	if len(series) >= size {
+		last := series[len(series)-1].Time
+		if last > 1e11 { //毫秒时间戳
+			last /= 1000
+		}
+		if n := int((time.Now().Unix()-last)/baseSeconds) + 1; n > 0 && n < need {
+			need = n
+		}
+	} else {
+		series = nil
+	}
+	records, ok := fetch(stockType, base, need).([]Record)
+	if !ok {
+		return false
+	}
+	if series == nil {
+		records = history(exchange, stockType, base, records, need)
+	}
+	series = resample(series, records, seconds, offset)
+	if len(series) > size {
+		series = series[len(series)-size:]
+	}
+	r.series[key] = series
+	return append([]Record{}, series...)
+}
+
+// basePeriod returns the largest provided period which divides the period, or "" if there is none
+func basePeriod(periods map[string]string, period string) (base string) {
+	seconds := PeriodSeconds(period)
+	if seconds <= 0 {
+		return
+	}
+	for p := range periods {
+		s := PeriodSeconds(p)
+		if s > 0 && s < seconds && seconds%s == 0 && s > PeriodSeconds(base) {
+			base = p
+		}
+	}
+	return
+}
+
+// resample merges the sorted records into the aggregated series, the last bucket of the series is rebuilt
+// when the records cover it because it may be incomplete, the time of a bucket is the time of its first record
+func resample(series, records []Record, seconds, offset int64) []Record {
+	if len(records) == 0 {
+		return series
+	}
+	unit := int64(1)
+	if records[0].Time > 1e11 { //毫秒时间戳
+		unit = 1000
+	}
+	bucket := func(t int64) int64 {
+		d := t/unit - offset
+		if d < 0 {
+			return (d - seconds + 1) / seconds
+		}
+		return d / seconds
+	}
+	start := 0
+	if n := len(series); n > 0 {
+		last := bucket(series[n-1].Time)
+		for start < len(records) && bucket(records[start].Time) < last {
+			start++
+		}
+		if start < len(records) && bucket(records[start].Time) == last {
+			series = series[:n-1]
+		}
+	}
+	for _, record := range records[start:] {
+		n := len(series)
+		if n == 0 || bucket(series[n-1].Time) != bucket(record.Time) {
+			series = append(series, record)
+			continue
+		}
+		last := &series[n-1]
+		if record.High > last.High {
+			last.High = record.High
+		}
+		if record.Low < last.Low {
+			last.Low = record.Low
+		}
+		last.Close = record.Close
+		last.Volume += record.Volume
+	}
+	return series
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+func TestPeriodSeconds(t *testing.T) {
+	tests := []struct {
+		period string
+		want   int64
+	}{
+		{"M", 60},
+		{"M5", 300},
+		{"H4", 14400},
+		{"D3", 259200},
+		{"W", 604800},
+		{"", 0},
+		{"X", 0},
+		{"M0", 0},
+		{"M-1", 0},
+	}
+	for _, tt := range tests {
+		if got := PeriodSeconds(tt.period); got != tt.want {
+			t.Errorf("PeriodSeconds(%q) = %v, want %v", tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestBasePeriod(t *testing.T) {
+	poloniex := map[string]string{"M5": "300", "M15": "900", "M30": "1800", "H2": "7200", "H4": "14400", "D": "86400"}
+	okexFuture := map[string]string{"M": "", "M3": "", "M5": "", "M15": "", "M30": "", "H": "", "H2": "", "H4": "", "H6": "", "H12": "", "D": "", "D3": "", "W": ""}
+	tests := []struct {
+		name    string
+		periods map[string]string
+		period  string
+		want    string
+	}{
+		{"poloniex hour", poloniex, "H", "M30"},
+		{"poloniex week", poloniex, "W", "D"},
+		{"poloniex 8 hours", poloniex, "H8", "H4"},
+		{"poloniex minute", poloniex, "M", ""},
+		{"poloniex 7 minutes", poloniex, "M7", ""},
+		{"okex future 9 minutes", okexFuture, "M9", "M3"},
+		{"okex future 24 hours", okexFuture, "H24", "H12"},
+		{"okex future 2 weeks", okexFuture, "W2", "W"},
+		{"invalid period", okexFuture, "X", ""},
+	}
+	for _, tt := range tests {
+		if got := basePeriod(tt.periods, tt.period); got != tt.want {
+			t.Errorf("%v: basePeriod(%q) = %q, want %q", tt.name, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	minutes := []Record{
+		{Time: 0, Open: 1, High: 2, Low: 1, Close: 2, Volume: 1},
+		{Time: 60, Open: 2, High: 5, Low: 2, Close: 3, Volume: 2},
+		{Time: 120, Open: 3, High: 4, Low: 0, Close: 1, Volume: 3},
+		{Time: 180, Open: 1, High: 1, Low: 1, Close: 1, Volume: 4},
+	}
+	ms := []Record{}
+	for _, r := range minutes {
+		r.Time = (r.Time + 12e8) * 1000
+		ms = append(ms, r)
+	}
+	tests := []struct {
+		name            string
+		series, records []Record
+		seconds, offset int64
+		want            []Record
+	}{
+		{"aggregate", nil, minutes, 120, 0, []Record{
+			{Time: 0, Open: 1, High: 5, Low: 1, Close: 3, Volume: 3},
+			{Time: 120, Open: 3, High: 4, Low: 0, Close: 1, Volume: 7},
+		}},
+		{"offset", nil, minutes, 120, 60, []Record{
+			{Time: 0, Open: 1, High: 2, Low: 1, Close: 2, Volume: 1},
+			{Time: 60, Open: 2, High: 5, Low: 0, Close: 1, Volume: 5},
+			{Time: 180, Open: 1, High: 1, Low: 1, Close: 1, Volume: 4},
+		}},
+		{"milliseconds", nil, ms[2:], 120, 0, []Record{
+			{Time: ms[2].Time, Open: 3, High: 4, Low: 0, Close: 1, Volume: 7},
+		}},
+		{"the last bucket is rebuilt", []Record{{Time: 0, Open: 1, High: 2, Low: 1, Close: 2, Volume: 1}}, minutes, 120, 0, []Record{
+			{Time: 0, Open: 1, High: 5, Low: 1, Close: 3, Volume: 3},
+			{Time: 120, Open: 3, High: 4, Low: 0, Close: 1, Volume: 7},
+		}},
+		{"the last bucket is kept when the records are after it", []Record{{Time: 0, Open: 9, High: 9, Low: 9, Close: 9, Volume: 9}}, minutes[2:], 120, 0, []Record{
+			{Time: 0, Open: 9, High: 9, Low: 9, Close: 9, Volume: 9},
+			{Time: 120, Open: 3, High: 4, Low: 0, Close: 1, Volume: 7},
+		}},
+		{"no record", []Record{{Time: 0}}, nil, 120, 0, []Record{{Time: 0}}},
+	}
+	for _, tt := range tests {
+		series := append([]Record{}, tt.series...)
+		if got := resample(series, tt.records, tt.seconds, tt.offset); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: resample() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeMinutes returns a fetch which serves the M1 records ending at the current minute, the close is the minute
+func fakeMinutes(sizes *[]int) func(string, string, ...interface{}) interface{} {
+	return func(stockType, period string, args ...interface{}) interface{} {
+		if period != "M" {
+			return false
+		}
+		size := args[0].(int)
+		*sizes = append(*sizes, size)
+		now := time.Now().Unix() / 60
+		records := []Record{}
+		for t := now - int64(size) + 1; t <= now; t++ {
+			records = append(records, Record{Time: t * 60, Open: float64(t), High: float64(t), Low: float64(t), Close: float64(t), Volume: 1})
+		}
+		return records
+	}
+}
+
+func TestResamplerRecords(t *testing.T) {
+	periods := map[string]string{"M": "1min"}
+	sizes := []int{}
+	r := resampler{}
+	if _, ok := r.records("test", periods, fakeMinutes(&sizes), "btc/usdt", "M", 3).([]Record); !ok {
+		t.Fatal("records() of a provided period failed")
+	}
+	if r.records("test", periods, fakeMinutes(&sizes), "BTC/USDT", "X") != false {
+		t.Error("records() of an invalid period should fail")
+	}
+	records := r.records("test", periods, fakeMinutes(&sizes), "BTC/USDT", "M5", 3).([]Record)
+	if len(records) != 3 {
+		t.Fatalf("records() returns %v records, want 3", len(records))
+	}
+	for _, record := range records {
+		if record.Time%300 != 0 && record != records[0] {
+			t.Errorf("the bucket %v does not start at 5 minutes", record.Time)
+		}
+	}
+	if last := records[len(records)-1]; last.Close != float64(time.Now().Unix()/60) {
+		t.Errorf("the last close is %v", last.Close)
+	}
+	records[0].Close = -1
+	again := r.records("test", periods, fakeMinutes(&sizes), "BTC/USDT", "M5", 3).([]Record)
+	if again[0].Close == -1 {
+		t.Error("the returned records share the kept series")
+	}
+	// 第一次获取 (3+1)*5 根, 之后只获取最后一根聚合K线之后的数据
+	if want := []int{3, 20}; !reflect.DeepEqual(sizes[:2], want) || len(sizes) != 3 || sizes[2] > 6 {
+		t.Errorf("the fetched sizes are %v", sizes)
+	}
+}
+
+func TestResamplerConcurrent(t *testing.T) {
+	periods := map[string]string{"M": "1min"}
+	r := resampler{}
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sizes := []int{}
+			fetch := fakeMinutes(&sizes)
+			r.records("test", periods, func(stockType, period string, args ...interface{}) interface{} {
+				mu.Lock()
+				defer mu.Unlock()
+				return fetch(stockType, period, args...)
+			}, "BTC/USDT", "M3", 5)
+		}()
+	}
+	wg.Wait()
+	if len(r.series["BTC/USDT#M3"]) != 5 {
+		t.Errorf("%v records are kept, want 5", len(r.series["BTC/USDT#M3"]))
+	}
+}
+
+func TestCacheRecords(t *testing.T) {
+	records := make([]Record, 300)
+	for i := range records {
+		records[i].Time = int64(i)
+	}
+	tests := []struct {
+		n, size             int
+		wantCache, wantLast int
+	}{
+		{300, 10, 200, 10},
+		{300, 250, 250, 250},
+		{150, 10, 150, 10},
+		{5, 10, 5, 5},
+	}
+	for _, tt := range tests {
+		cache, latest := cacheRecords(records[:tt.n], tt.size)
+		if len(cache) != tt.wantCache || len(latest) != tt.wantLast || latest[len(latest)-1].Time != int64(tt.n-1) {
+			t.Errorf("cacheRecords(%v, %v) = %v %v records, want %v %v", tt.n, tt.size, len(cache), len(latest), tt.wantCache, tt.wantLast)
+		}
+	}
+}
+
+// TestResamplerKeepsCache calls the provided period of an exchange after the resampled period and a small size,
+// the exchange returns the 200 latest hours every time like OKEx
+func TestResamplerKeepsCache(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		candles := []map[string]string{}
+		for i := 0; i < 200; i++ {
+			candles = append(candles, map[string]string{"time": now.Add(-time.Duration(i) * time.Hour).Format("2006-01-02T15:04:05Z"), "open": "1", "high": "2", "low": "0.5", "close": "1.5", "volume": "1"})
+		}
+		json.NewEncoder(w).Encode(candles)
+	}))
+	defer srv.Close()
+	e := NewOKEXV3(Option{Type: constant.OkexThree, Name: "v3", Config: `{"host": "` + srv.URL + `/api/spot/v3", "passphrase": "secret"}`}).(*OKEXV3)
+	e.logger.Sink = func(model.Log) {}
+	calls := []struct {
+		period string
+		sizes  []interface{}
+		want   int
+	}{
+		{"H", nil, 200},
+		{"H4", []interface{}{10}, 10},
+		{"H4", []interface{}{10}, 10},
+		{"H", nil, 200},
+		{"H", []interface{}{5}, 5},
+		{"H", nil, 200},
+	}
+	for i, c := range calls {
+		records, ok := e.GetRecords("BTC/USDT", c.period, c.sizes...).([]Record)
+		if !ok || len(records) != c.want {
+			t.Errorf("%v: GetRecords(%v, %v) returns %v records, want %v", i, c.period, c.sizes, len(records), c.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/geniustag/QuantBot/constant"
+)
+
+// RouteChild is a child order placed by the router on one exchange
+type RouteChild struct {
+	Exchange   string  //交易所名称
+	Index      int     //交易所在 Exchanges 中的序号
+	ID         string  //订单ID, 下单失败时为空
+	Price      float64 //限价, 即在该交易所吃到的最差一档价格
+	Amount     float64
+	DealAmount float64
+	EstPrice   float64 //按下单时的深度估算的成交均价, 限价单实际的成交价只会更优
+	Fee        float64
+	Error      string
+}
+
+// RouteResult is the aggregate result of a routed order
+type RouteResult struct {
+	TradeType  string
+	StockType  string
+	Amount     float64 //请求的数量
+	DealAmount float64 //子订单的成交量之和, 来自 GetOrder
+	EstCost    float64 //成交量乘以估算均价之和, 不含手续费, 订单接口不返回成交均价所以只是估算
+	EstPrice   float64 //EstCost / DealAmount
+	Fee        float64 //子订单的手续费之和, 来自 GetOrder
+	Children   []RouteChild
+}
+
+// AmountPrecisionProvider is an exchange which returns the decimal places of the trade amount of a stock type,
+// the router uses the decimal places of GetMinAmount for the other exchanges
+type AmountPrecisionProvider interface {
+	GetAmountPrecision(stockType string) int
+}
+
+// Route splits an order across the exchanges by walking their consolidated depth from the best fee adjusted price,
+// a venue takes no more than its available balance, its part is rounded down to its amount precision and is
+// skipped if below its min amount, every child order is a limit order at the worst price taken on its venue
+// and the part not filled stays open
+func Route(exchanges []Exchange, fees []float64, tradeType, stockType string, amount float64, size int) (result RouteResult, err error) {
+	tradeType, stockType = strings.ToUpper(tradeType), strings.ToUpper(stockType)
+	result = RouteResult{TradeType: tradeType, StockType: stockType, Amount: amount}
+	if tradeType != constant.TradeTypeBuy && tradeType != constant.TradeTypeSell {
+		return result, fmt.Errorf("unrecognized tradeType: %v", tradeType)
+	}
+	currencies := strings.Split(stockType, "/")
+	if len(currencies) != 2 {
+		return result, fmt.Errorf("unrecognized stockType: %v", stockType)
+	}
+	if amount <= 0 {
+		return result, fmt.Errorf("invalid amount: %v", amount)
+	}
+	book := Consolidate(exchanges, fees, stockType, size)
+	levels := book.Asks
+	if tradeType == constant.TradeTypeSell {
+		levels = book.Bids
+	}
+	if len(levels) == 0 {
+		return result, fmt.Errorf("no exchange returns the depth of %v", stockType)
+	}
+
+	// 可用余额(买入时为计价币种, 卖出时为交易币种)和最小交易量
+	available := make([]float64, len(exchanges))
+	mins := make([]float64, len(exchanges))
+	precisions := make([]int, len(exchanges))
+	parallel(len(exchanges), func(i int) {
+		mins[i] = exchanges[i].GetMinAmount(stockType)
+		if p, ok := exchanges[i].(AmountPrecisionProvider); ok {
+			precisions[i] = p.GetAmountPrecision(stockType)
+		} else {
+			precisions[i] = amountPrecision(mins[i])
+		}
+		account, ok := exchanges[i].GetAccount().(map[string]float64)
+		if !ok {
+			return
+		}
+		if tradeType == constant.TradeTypeBuy {
+			available[i] = balance(account, currencies[1])
+		} else {
+			available[i] = balance(account, currencies[0])
+		}
+	})
+
+	// 按价格从优到劣分配数量, 按精度向下取整后不足最小交易量的交易所被排除后重新分配
+	excluded := make(map[int]bool)
+	var children []RouteChild
+	for {
+		children = walk(levels, available, excluded, tradeType, amount)
+		below := false
+		for i := range children {
+			c := &children[i]
+			c.Amount = floor(c.Amount, precisions[c.Index])
+			if c.Amount <= 0 || c.Amount < mins[c.Index] {
+				excluded[c.Index], below = true, true
+			}
+		}
+		if !below {
+			break
+		}
+	}
+
+	// 下单
+	result.Children = children
+	parallel(len(result.Children), func(j int) {
+		c := &result.Children[j]
+		e := exchanges[c.Index]
+		id, ok := e.Trade(tradeType, stockType, c.Price, c.Amount, "Route").(string)
+		if !ok {
+			c.Error = "Trade() failed"
+			return
+		}
+		c.ID = id
+		if o, ok := e.GetOrder(stockType, id).(Order); ok {
+			c.DealAmount, c.Fee = o.DealAmount, o.Fee
+		}
+	})
+	for _, c := range result.Children {
+		result.DealAmount += c.DealAmount
+		result.EstCost += c.DealAmount * c.EstPrice
+		result.Fee += c.Fee
+	}
+	if result.DealAmount > 0 {
+		result.EstPrice = result.EstCost / result.DealAmount
+	}
+	return
+}
+
+// walk allocates the amount to the levels in order, limited by the available balances of the exchanges
+func walk(levels []ConsolidatedLevel, available []float64, excluded map[int]bool, tradeType string, amount float64) (children []RouteChild) {
+	index := make(map[int]int)
+	left := append([]float64{}, available...)
+	for _, l := range levels {
+		if amount <= 0 {
+			break
+		}
+		if excluded[l.Index] {
+			continue
+		}
+		take := math.Min(amount, l.Amount)
+		if tradeType == constant.TradeTypeBuy {
+			take = math.Min(take, left[l.Index]/l.Price)
+		} else {
+			take = math.Min(take, left[l.Index])
+		}
+		if take <= 0 {
+			continue
+		}
+		i, ok := index[l.Index]
+		if !ok {
+			i = len(children)
+			index[l.Index] = i
+			children = append(children, RouteChild{Exchange: l.Exchange, Index: l.Index})
+		}
+		c := &children[i]
+		c.EstPrice = (c.EstPrice*c.Amount + l.RawPrice*take) / (c.Amount + take)
+		c.Amount += take
+		c.Price = l.RawPrice
+		if tradeType == constant.TradeTypeBuy {
+			left[l.Index] -= take * l.Price
+		} else {
+			left[l.Index] -= take
+		}
+		amount -= take
+	}
+	return
+}
+
+// amountPrecision returns the decimal places of the min amount, at most 8
+func amountPrecision(min float64) int {
+	if min <= 0 {
+		return 8
+	}
+	places := 0
+	for scaled := min; places < 8 && (math.Round(scaled) == 0 || math.Abs(scaled-math.Round(scaled)) > 1e-9); places++ {
+		scaled *= 10
+	}
+	return places
+}
+
+// floor rounds the value down to the decimal places
+func floor(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Floor(v*p+1e-6) / p
+}
+
+// parallel calls fn(0) to fn(n-1) concurrently and waits for them
+func parallel(n int, fn func(i int)) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// balance returns the available amount of the currency in an account returned by GetAccount
+func balance(account map[string]float64, currency string) float64 {
+	for _, key := range []string{currency, strings.ToUpper(currency), strings.ToLower(currency)} {
+		if v, ok := account[key]; ok {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,362 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// routeExchange is a venue of the router tests, every order is filled by the fill ratio
+type routeExchange struct {
+	bookExchange
+	account   interface{}
+	min       float64
+	precision int // GetAmountPrecision 的返回值, 为负数时不实现 AmountPrecisionProvider
+	fill      float64
+	fail      bool
+	mu        sync.Mutex
+	orders    []Order
+}
+
+func (e *routeExchange) GetMinAmount(stock string) float64 {
+	return e.min
+}
+
+func (e *routeExchange) GetAccount() interface{} {
+	return e.account
+}
+
+func (e *routeExchange) Trade(tradeType string, stockType string, price, amount interface{}, msgs ...interface{}) interface{} {
+	if e.fail {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	o := Order{ID: fmt.Sprint(len(e.orders) + 1), Price: price.(float64), Amount: amount.(float64), TradeType: tradeType, StockType: stockType}
+	o.DealAmount = o.Amount * e.fill
+	o.Fee = o.DealAmount * 0.001
+	e.orders = append(e.orders, o)
+	return o.ID
+}
+
+func (e *routeExchange) GetOrder(stockType, id string) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, o := range e.orders {
+		if o.ID == id {
+			return o
+		}
+	}
+	return false
+}
+
+// precisionExchange is a routeExchange which implements AmountPrecisionProvider
+type precisionExchange struct {
+	*routeExchange
+}
+
+func (e precisionExchange) GetAmountPrecision(stockType string) int {
+	return e.precision
+}
+
+func TestRoute(t *testing.T) {
+	type venue struct {
+		bids, asks []OrderBook
+		account    interface{}
+		min        float64
+		precision  int
+		fill       float64
+		fail       bool
+	}
+	rich := map[string]float64{"BTC": 100, "USDT": 1e6}
+	tests := []struct {
+		name         string
+		venues       []venue
+		fees         []float64
+		tradeType    string
+		amount       float64
+		wantChildren []RouteChild // ID, Exchange, Index, Price, Amount, EstPrice 和 Error
+		wantDeal     float64
+		wantEstPrice float64
+		wantErr      bool
+	}{
+		{
+			name: "buy across the venues by price",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 1}, {Price: 102, Amount: 5}}, account: rich, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 2}}, account: rich, precision: -1, fill: 1},
+			},
+			tradeType: "buy",
+			amount:    4,
+			wantChildren: []RouteChild{
+				{ID: "1", Exchange: "v0", Index: 0, Price: 102, Amount: 2, EstPrice: 101},
+				{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 2, EstPrice: 101},
+			},
+			wantDeal:     4,
+			wantEstPrice: 101,
+		},
+		{
+			name: "the fee makes the other venue better",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 1}}, account: rich, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 2}}, account: rich, precision: -1, fill: 1},
+			},
+			fees:         []float64{0.02},
+			tradeType:    "buy",
+			amount:       2,
+			wantChildren: []RouteChild{{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 2, EstPrice: 101}},
+			wantDeal:     2,
+			wantEstPrice: 101,
+		},
+		{
+			name: "the quote balance limits a buy",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 5}}, account: map[string]float64{"USDT": 50}, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 5}}, account: rich, precision: -1, fill: 1},
+			},
+			tradeType: "BUY",
+			amount:    2,
+			wantChildren: []RouteChild{
+				{ID: "1", Exchange: "v0", Index: 0, Price: 100, Amount: 0.5, EstPrice: 100},
+				{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 1.5, EstPrice: 101},
+			},
+			wantDeal:     2,
+			wantEstPrice: 100.75,
+		},
+		{
+			name: "the base balance limits a sell and a failed account is skipped",
+			venues: []venue{
+				{bids: []OrderBook{{Price: 100, Amount: 5}}, account: map[string]float64{"btc": 1}, precision: -1, fill: 1},
+				{bids: []OrderBook{{Price: 101, Amount: 5}}, account: false, precision: -1, fill: 1},
+				{bids: []OrderBook{{Price: 99, Amount: 5}}, account: rich, precision: -1, fill: 1},
+			},
+			tradeType: "SELL",
+			amount:    3,
+			wantChildren: []RouteChild{
+				{ID: "1", Exchange: "v0", Index: 0, Price: 100, Amount: 1, EstPrice: 100},
+				{ID: "1", Exchange: "v2", Index: 2, Price: 99, Amount: 2, EstPrice: 99},
+			},
+			wantDeal:     3,
+			wantEstPrice: 99.33333333,
+		},
+		{
+			name: "a part below the min amount is excluded and walked again",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 1}}, account: rich, min: 1.5, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 5}}, account: rich, min: 0.1, precision: -1, fill: 1},
+			},
+			tradeType:    "BUY",
+			amount:       2,
+			wantChildren: []RouteChild{{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 2, EstPrice: 101}},
+			wantDeal:     2,
+			wantEstPrice: 101,
+		},
+		{
+			name: "every part is below the min amount",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 1}}, account: rich, min: 5, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 5}}, account: rich, min: 5, precision: -1, fill: 1},
+			},
+			tradeType: "BUY",
+			amount:    2,
+		},
+		{
+			name: "the amounts are rounded down to the decimals of the min amount",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 0.123456}}, account: rich, min: 0.01, precision: -1, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 5}}, account: rich, min: 0.001, precision: -1, fill: 1},
+			},
+			tradeType: "BUY",
+			amount:    1,
+			wantChildren: []RouteChild{
+				{ID: "1", Exchange: "v0", Index: 0, Price: 100, Amount: 0.12, EstPrice: 100},
+				{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 0.876, EstPrice: 101},
+			},
+			wantDeal:     0.996,
+			wantEstPrice: 100.87951807,
+		},
+		{
+			name: "the provided precision rounds a part to zero",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 0.5}}, account: rich, precision: 0, fill: 1},
+				{asks: []OrderBook{{Price: 101, Amount: 5}}, account: rich, precision: 2, fill: 1},
+			},
+			tradeType:    "BUY",
+			amount:       1.005,
+			wantChildren: []RouteChild{{ID: "1", Exchange: "v1", Index: 1, Price: 101, Amount: 1, EstPrice: 101}},
+			wantDeal:     1,
+			wantEstPrice: 101,
+		},
+		{
+			name: "partial fills and a failed trade",
+			venues: []venue{
+				{asks: []OrderBook{{Price: 100, Amount: 1}}, account: rich, precision: -1, fill: 0.5},
+				{asks: []OrderBook{{Price: 101, Amount: 1}}, account: rich, precision: -1, fail: true},
+			},
+			tradeType: "BUY",
+			amount:    2,
+			wantChildren: []RouteChild{
+				{ID: "1", Exchange: "v0", Index: 0, Price: 100, Amount: 1, EstPrice: 100},
+				{Exchange: "v1", Index: 1, Price: 101, Amount: 1, EstPrice: 101, Error: "Trade() failed"},
+			},
+			wantDeal:     0.5,
+			wantEstPrice: 100,
+		},
+		{name: "invalid trade type", venues: []venue{{account: rich}}, tradeType: "HOLD", amount: 1, wantErr: true},
+		{name: "invalid amount", venues: []venue{{account: rich}}, tradeType: "BUY", amount: 0, wantErr: true},
+		{name: "no depth", venues: []venue{{bids: []OrderBook{{Price: 100, Amount: 1}}, account: rich}}, tradeType: "BUY", amount: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		exchanges := make([]Exchange, len(tt.venues))
+		for i, v := range tt.venues {
+			e := &routeExchange{
+				bookExchange: bookExchange{name: fmt.Sprint("v", i), ticker: Ticker{Bids: v.bids, Asks: v.asks}},
+				account:      v.account,
+				min:          v.min,
+				precision:    v.precision,
+				fill:         v.fill,
+				fail:         v.fail,
+			}
+			exchanges[i] = e
+			if v.precision >= 0 {
+				exchanges[i] = precisionExchange{e}
+			}
+		}
+		result, err := Route(exchanges, tt.fees, tt.tradeType, "btc/usdt", tt.amount, 20)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: Route() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		children := []RouteChild{}
+		fee := 0.0
+		for _, c := range result.Children {
+			fee += c.Fee
+			c.DealAmount, c.Fee = 0, 0
+			c.Amount, c.EstPrice = round8(c.Amount), round8(c.EstPrice)
+			children = append(children, c)
+		}
+		if tt.wantChildren == nil {
+			tt.wantChildren = []RouteChild{}
+		}
+		if !reflect.DeepEqual(children, tt.wantChildren) {
+			t.Errorf("%v: Route() children = %+v, want %+v", tt.name, children, tt.wantChildren)
+		}
+		if round8(result.DealAmount) != tt.wantDeal || round8(result.EstPrice) != tt.wantEstPrice || math.Abs(result.EstCost-tt.wantDeal*tt.wantEstPrice) > 1e-6 {
+			t.Errorf("%v: Route() = %v@%v cost %v, want %v@%v", tt.name, result.DealAmount, result.EstPrice, result.EstCost, tt.wantDeal, tt.wantEstPrice)
+		}
+		if math.Abs(result.Fee-fee) > 1e-12 || round8(result.Fee) != round8(tt.wantDeal*0.001) {
+			t.Errorf("%v: Route() fee = %v, want %v", tt.name, result.Fee, tt.wantDeal*0.001)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	levels := []ConsolidatedLevel{
+		{Price: 100, RawPrice: 100, Amount: 1, Index: 0},
+		{Price: 101, RawPrice: 101, Amount: 2, Index: 1},
+		{Price: 102, RawPrice: 102, Amount: 3, Index: 0},
+	}
+	tests := []struct {
+		name      string
+		available []float64
+		excluded  map[int]bool
+		tradeType string
+		amount    float64
+		want      []RouteChild
+	}{
+		{
+			name:      "the levels in order",
+			available: []float64{1e6, 1e6},
+			tradeType: "BUY",
+			amount:    4,
+			want: []RouteChild{
+				{Index: 0, Price: 102, Amount: 2, EstPrice: 101},
+				{Index: 1, Price: 101, Amount: 2, EstPrice: 101},
+			},
+		},
+		{
+			name:      "an excluded venue",
+			available: []float64{1e6, 1e6},
+			excluded:  map[int]bool{1: true},
+			tradeType: "BUY",
+			amount:    3,
+			want:      []RouteChild{{Index: 0, Price: 102, Amount: 3, EstPrice: 101.33333333}},
+		},
+		{
+			name:      "the balance is spent across the levels of a venue",
+			available: []float64{202, 0},
+			tradeType: "BUY",
+			amount:    5,
+			want:      []RouteChild{{Index: 0, Price: 102, Amount: 2, EstPrice: 101}},
+		},
+		{
+			name:      "a sell takes the base balance",
+			available: []float64{0.5, 1},
+			tradeType: "SELL",
+			amount:    5,
+			want: []RouteChild{
+				{Index: 0, Price: 100, Amount: 0.5, EstPrice: 100},
+				{Index: 1, Price: 101, Amount: 1, EstPrice: 101},
+			},
+		},
+		{
+			name:      "no balance",
+			available: []float64{0, 0},
+			tradeType: "BUY",
+			amount:    1,
+		},
+	}
+	for _, tt := range tests {
+		got := walk(levels, tt.available, tt.excluded, tt.tradeType, tt.amount)
+		for i := range got {
+			got[i].Amount, got[i].EstPrice = round8(got[i].Amount), round8(got[i].EstPrice)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: walk() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAmountPrecision(t *testing.T) {
+	tests := []struct {
+		min  float64
+		want int
+	}{
+		{0, 8},
+		{1, 0},
+		{10, 0},
+		{0.1, 1},
+		{0.001, 3},
+		{0.0025, 4},
+		{1e-10, 8},
+	}
+	for _, tt := range tests {
+		if got := amountPrecision(tt.min); got != tt.want {
+			t.Errorf("amountPrecision(%v) = %v, want %v", tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestFloor(t *testing.T) {
+	tests := []struct {
+		v      float64
+		places int
+		want   float64
+	}{
+		{1.239, 2, 1.23},
+		{0.3, 1, 0.3},
+		{0.7, 0, 0},
+		{2.675, 3, 2.675},
+		{1.23456789, 8, 1.23456789},
+	}
+	for _, tt := range tests {
+		if got := floor(tt.v, tt.places); got != tt.want {
+			t.Errorf("floor(%v, %v) = %v, want %v", tt.v, tt.places, got, tt.want)
+		}
+	}
+}
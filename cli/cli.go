@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+const usage = `Usage: QuantBot [command] [subcommand] [flags] [args]
+
+Commands:
+  server                                  run the server (default)
+  user add -name NAME -pass PASS [-level N]
+  exchange add -name NAME -type TYPE -access KEY -secret KEY [-config JSON]
+  algorithm upload -file FILE [-name NAME] [-id ID]
+  trader create -name NAME -algorithm ID -exchanges ID,ID [-env ENV]
+  trader start ID
+  trader stop ID
+  trader command ID COMMAND
+  log tail [-n N] [-f] ID
+  backtest -algorithm ID -exchange TYPE -stock STOCK,STOCK -period PERIOD -begin TIME -end TIME -balance USDT=1000 [-fee RATE] [-version N] [-o FILE]
+  export logs|performance|portfolio [-trader ID] [-format csv|json] [-o FILE]
+  candle import -file FILE -exchange TYPE -stock STOCK -period PERIOD [-columns time=Date,...] [-timeformat FORMAT] [-tz ZONE]
+  candle export -exchange TYPE -stock STOCK -period PERIOD [-begin TIME] [-end TIME] [-o FILE]
+
+Common flags:
+  -server URL     the running server, or set QUANTBOT_SERVER, default http://localhost:<port in config.ini>
+                  if the file is in the working directory, else http://localhost:9876
+  -u USER -p PASS login, or set QUANTBOT_USER and QUANTBOT_PASSWORD, or QUANTBOT_TOKEN
+`
+
+// command registers its flags and returns the function to run after the flags are parsed
+type command func(fs *flag.FlagSet) func(c *client) error
+
+var commands = map[string]command{
+	"user add":           userAdd,
+	"exchange add":       exchangeAdd,
+	"algorithm upload":   algorithmUpload,
+	"trader create":      traderCreate,
+	"trader start":       traderSwitch(true),
+	"trader stop":        traderSwitch(false),
+	"trader command":     traderCommand,
+	"log tail":           logTail,
+	"backtest":           backtest,
+	"export logs":        export("logs"),
+	"export performance": export("performance"),
+	"export portfolio":   export("portfolio"),
+	"candle import":      candleImport,
+	"candle export":      candleExport,
+}
+
+// Run runs the CLI command, args do not include the program name
+func Run(args []string) (err error) {
+	name, rest := "", args
+	for i := 1; i <= 2 && i <= len(args); i++ {
+		if _, ok := commands[strings.Join(args[:i], " ")]; ok {
+			name, rest = strings.Join(args[:i], " "), args[i:]
+		}
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("Unknown command: %v", strings.Join(args, " "))
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	server := fs.String("server", defaultServer(), "the running server")
+	username := fs.String("u", "", "username")
+	password := fs.String("p", "", "password")
+	run := cmd(fs)
+	if err = fs.Parse(rest); err != nil {
+		return
+	}
+	c, err := newClient(*server, *username, *password)
+	if err != nil {
+		return fmt.Errorf("Login error: %v", err)
+	}
+	return run(c)
+}
+
+// defaultServer returns QUANTBOT_SERVER, or the local server of the port in custom/config.ini or config.ini
+// of the working directory, the CLI does not need the config file and never fails without it
+func defaultServer() string {
+	if server := os.Getenv("QUANTBOT_SERVER"); server != "" {
+		return server
+	}
+	for _, name := range []string{"custom/config.ini", "config.ini"} {
+		if conf, err := ini.InsensitiveLoad(name); err == nil {
+			if port := conf.Section("").Key("port").String(); port != "" {
+				return "http://localhost:" + port
+			}
+		}
+	}
+	return "http://localhost:9876"
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// client calls the REST API of a running QuantBot server
+type client struct {
+	server string
+	token  string
+}
+
+// response is the common response of the REST API
+type response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// newClient logs in with the flags or the environment variables QUANTBOT_TOKEN, QUANTBOT_USER and QUANTBOT_PASSWORD
+func newClient(server, username, password string) (c *client, err error) {
+	c = &client{server: strings.TrimSuffix(server, "/"), token: os.Getenv("QUANTBOT_TOKEN")}
+	if username == "" {
+		username = os.Getenv("QUANTBOT_USER")
+	}
+	if password == "" {
+		password = os.Getenv("QUANTBOT_PASSWORD")
+	}
+	if username == "" {
+		return
+	}
+	token := ""
+	err = c.call("POST", "/login", nil, map[string]string{"username": username, "password": password}, &token)
+	c.token = token
+	return
+}
+
+// call sends the request and decodes the data of the response into result
+func (c *client) call(method, path string, query url.Values, body, result interface{}) (err error) {
+	var reader io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bs)
+	}
+	u := c.server + "/api/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	r := response{}
+	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("[%s %s] HTTP Status: %d, Info: %v", method, path, resp.StatusCode, err)
+	}
+	if !r.Success {
+		return fmt.Errorf("%v", r.Message)
+	}
+	if result != nil && len(r.Data) > 0 {
+		err = json.Unmarshal(r.Data, result)
+	}
+	return
+}
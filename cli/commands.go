@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func userAdd(fs *flag.FlagSet) func(c *client) error {
+	name := fs.String("name", "", "username")
+	pass := fs.String("pass", "", "password")
+	level := fs.Int64("level", 0, "level, must be lower than yours")
+	return func(c *client) error {
+		if *name == "" || *pass == "" {
+			return fmt.Errorf("Username and Password can not be empty")
+		}
+		body := map[string]interface{}{"username": *name, "level": *level, "password": *pass}
+		if err := c.call("POST", "/users", nil, body, nil); err != nil {
+			return err
+		}
+		fmt.Println("User created:", *name)
+		return nil
+	}
+}
+
+func exchangeAdd(fs *flag.FlagSet) func(c *client) error {
+	req := exchange{}
+	fs.StringVar(&req.Name, "name", "", "exchange name")
+	fs.StringVar(&req.Type, "type", "", "exchange type")
+	fs.StringVar(&req.AccessKey, "access", "", "access key")
+	fs.StringVar(&req.SecretKey, "secret", "", "secret key")
+	fs.StringVar(&req.Config, "config", "", "JSON config, like the host and passphrase of an okex-v3-compatible exchange")
+	return func(c *client) error {
+		if req.Name == "" || req.Type == "" {
+			return fmt.Errorf("Name and Type can not be empty")
+		}
+		if err := c.call("POST", "/exchanges", nil, req, nil); err != nil {
+			return err
+		}
+		fmt.Println("Exchange added:", req.Name)
+		return nil
+	}
+}
+
+func algorithmUpload(fs *flag.FlagSet) func(c *client) error {
+	file := fs.String("file", "", "the script file")
+	name := fs.String("name", "", "algorithm name, default the file name")
+	id := fs.Int64("id", 0, "update the algorithm with this id instead of creating one")
+	return func(c *client) error {
+		script, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		req := algorithm{ID: *id, Name: *name, Script: string(script)}
+		if *id > 0 {
+			list := struct{ List []algorithm }{}
+			if err := c.call("GET", "/algorithms", nil, nil, &list); err != nil {
+				return err
+			}
+			found := false
+			for _, a := range list.List {
+				if a.ID == *id {
+					req.Description, req.EvnDefault, found = a.Description, a.EvnDefault, true
+					if req.Name == "" {
+						req.Name = a.Name
+					}
+				}
+			}
+			if !found {
+				return fmt.Errorf("Can not found the algorithm %v", *id)
+			}
+		}
+		if req.Name == "" {
+			req.Name = strings.TrimSuffix(filepath.Base(*file), filepath.Ext(*file))
+		}
+		if err := c.call("POST", "/algorithms", nil, req, nil); err != nil {
+			return err
+		}
+		fmt.Println("Algorithm uploaded:", req.Name)
+		return nil
+	}
+}
+
+func traderCreate(fs *flag.FlagSet) func(c *client) error {
+	req := trader{}
+	fs.StringVar(&req.Name, "name", "", "trader name")
+	fs.Int64Var(&req.AlgorithmID, "algorithm", 0, "algorithm id")
+	fs.StringVar(&req.Environment, "env", "", "environment")
+	exchanges := fs.String("exchanges", "", "comma separated exchange ids")
+	return func(c *client) error {
+		for _, s := range strings.Split(*exchanges, ",") {
+			if id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				req.Exchanges = append(req.Exchanges, exchange{ID: id})
+			}
+		}
+		if req.Name == "" || req.AlgorithmID <= 0 || len(req.Exchanges) == 0 {
+			return fmt.Errorf("Name, Algorithm and Exchanges can not be empty")
+		}
+		if err := c.call("POST", "/traders", nil, req, nil); err != nil {
+			return err
+		}
+		fmt.Println("Trader created:", req.Name)
+		return nil
+	}
+}
+
+func traderSwitch(start bool) command {
+	return func(fs *flag.FlagSet) func(c *client) error {
+		return func(c *client) error {
+			id := fs.Arg(0)
+			if id == "" {
+				return fmt.Errorf("Trader ID can not be empty")
+			}
+			status := struct{ Status int64 }{}
+			if err := c.call("GET", "/traders/"+id+"/status", nil, nil, &status); err != nil {
+				return err
+			}
+			if start == (status.Status > 0) {
+				fmt.Println("Trader", id, "is already", map[bool]string{true: "running", false: "stopped"}[start])
+				return nil
+			}
+			if err := c.call("POST", "/traders/"+id+"/switch", nil, nil, nil); err != nil {
+				return err
+			}
+			fmt.Println("Trader", id, map[bool]string{true: "started", false: "stopped"}[start])
+			return nil
+		}
+	}
+}
+
+func traderCommand(fs *flag.FlagSet) func(c *client) error {
+	return func(c *client) error {
+		if fs.NArg() < 2 {
+			return fmt.Errorf("Trader ID and Command can not be empty")
+		}
+		id, cmd := fs.Arg(0), strings.Join(fs.Args()[1:], " ")
+		if err := c.call("POST", "/traders/"+id+"/command", url.Values{"command": {cmd}}, nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("Command sent to trader", id)
+		return nil
+	}
+}
+
+func logTail(fs *flag.FlagSet) func(c *client) error {
+	n := fs.Int("n", 20, "the number of lines")
+	follow := fs.Bool("f", false, "follow the new logs")
+	return func(c *client) error {
+		id := fs.Arg(0)
+		if id == "" {
+			return fmt.Errorf("Trader ID can not be empty")
+		}
+		list := struct{ List []logEntry }{}
+		if err := c.call("GET", "/traders/"+id+"/logs", url.Values{"pageSize": {fmt.Sprint(*n)}}, nil, &list); err != nil {
+			return err
+		}
+		for i := len(list.List) - 1; i >= 0; i-- {
+			printLog(list.List[i])
+		}
+		if !*follow {
+			return nil
+		}
+		return c.stream(id, func(e event) {
+			if e.Type != "log" {
+				return
+			}
+			l := logEntry{}
+			if json.Unmarshal(e.Data, &l) == nil {
+				printLog(l)
+			}
+		})
+	}
+}
+
+func printLog(l logEntry) {
+	fmt.Printf("%v %-8v %-12v %-10v %v %v %v\n", l.Time.Format("2006-01-02 15:04:05"), l.ExchangeType, l.Type, l.StockType, l.Price, l.Amount, l.Message)
+}
+
+// stream reads the server-sent events of a trader until the connection is closed
+func (c *client) stream(id string, fn func(e event)) error {
+	resp, err := http.Get(c.server + "/api/stream?" + url.Values{"trader": {id}, "token": {c.token}}.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(msg)))
+	}
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if strings.HasPrefix(line, "data: ") {
+			e := event{}
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e) == nil {
+				fn(e)
+			}
+		}
+	}
+}
+
+func backtest(fs *flag.FlagSet) func(c *client) error {
+	algorithm := fs.Int64("algorithm", 0, "algorithm id")
+	version := fs.Int64("version", 0, "algorithm version, default the latest")
+	exchange := fs.String("exchange", "", "exchange type of the stored candles")
+	stocks := fs.String("stock", "", "stock types, like BTC/USDT,ETH/USDT")
+	period := fs.String("period", "", "period of the candles, like M15")
+	begin := fs.String("begin", "", "begin time, RFC3339")
+	end := fs.String("end", "", "end time, RFC3339")
+	balance := fs.String("balance", "", "initial balance, like USDT=1000,BTC=0.5")
+	fee := fs.Float64("fee", 0.001, "fee rate")
+	output := fs.String("o", "", "write the logs and the equity curve as JSON to the file")
+	return func(c *client) (err error) {
+		if *algorithm <= 0 || *exchange == "" || *stocks == "" || *period == "" {
+			return fmt.Errorf("Algorithm, Exchange, Stock and Period can not be empty")
+		}
+		from, err := time.Parse(time.RFC3339, *begin)
+		if err != nil {
+			return fmt.Errorf("Invalid begin time: %v", err)
+		}
+		to, err := time.Parse(time.RFC3339, *end)
+		if err != nil {
+			return fmt.Errorf("Invalid end time: %v", err)
+		}
+		x := map[string]interface{}{"Type": *exchange, "Stocks": strings.Split(*stocks, ","), "Fee": *fee}
+		funds := make(map[string]float64)
+		for _, s := range strings.Split(*balance, ",") {
+			if kv := strings.SplitN(s, "=", 2); len(kv) == 2 {
+				if funds[strings.ToUpper(strings.TrimSpace(kv[0]))], err = strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err != nil {
+					return fmt.Errorf("Invalid balance: %v", s)
+				}
+			}
+		}
+		x["Balance"] = funds
+		req := map[string]interface{}{
+			"AlgorithmID": *algorithm,
+			"Version":     *version,
+			"Exchanges":   []interface{}{x},
+			"Period":      *period,
+			"Begin":       from,
+			"End":         to,
+		}
+		result := struct {
+			Logs        []logEntry
+			Performance performance
+			Accounts    []map[string]float64
+			Error       string
+		}{}
+		if err = c.call("POST", "/backtests", nil, req, &result); err != nil {
+			return
+		}
+		for _, l := range result.Logs {
+			printLog(l)
+		}
+		p := result.Performance
+		fmt.Printf("Profit: %v, Return: %.2f%%, Max drawdown: %v (%.2f%%), Sharpe: %.2f, Sortino: %.2f, Trades: %v\n",
+			p.Profit, p.Return*100, p.MaxDrawdown, p.MaxDrawdownRate*100, p.Sharpe, p.Sortino, p.TradeCount)
+		for _, account := range result.Accounts {
+			fmt.Println("Account:", account)
+		}
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			encoder := json.NewEncoder(f)
+			encoder.SetIndent("", "  ")
+			if err = encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+		if result.Error != "" {
+			return fmt.Errorf("Backtest error: %v", result.Error)
+		}
+		return
+	}
+}
+
+func export(kind string) command {
+	return func(fs *flag.FlagSet) func(c *client) error {
+		trader := fs.Int64("trader", 0, "trader id, required by logs and performance")
+		format := fs.String("format", "csv", "csv or json")
+		output := fs.String("o", "", "output file, default stdout")
+		begin := fs.String("begin", "", "begin time, RFC3339")
+		end := fs.String("end", "", "end time, RFC3339")
+		return func(c *client) (err error) {
+			if kind != "portfolio" && *trader <= 0 {
+				return fmt.Errorf("Trader ID can not be empty")
+			}
+			query := url.Values{"begin": {*begin}, "end": {*end}}
+			header, rows := []string{}, [][]string{}
+			var data interface{}
+			switch kind {
+			case "logs":
+				logs, err := c.allLogs(*trader, query)
+				if err != nil {
+					return err
+				}
+				data = logs
+				header = []string{"time", "exchangeType", "type", "stockType", "price", "amount", "message"}
+				for _, l := range logs {
+					rows = append(rows, []string{l.Time.Format(time.RFC3339), l.ExchangeType, l.Type, l.StockType, fmt.Sprint(l.Price), fmt.Sprint(l.Amount), l.Message})
+				}
+			case "performance":
+				perf := performance{}
+				if err = c.call("GET", fmt.Sprintf("/traders/%v/performance", *trader), query, nil, &perf); err != nil {
+					return
+				}
+				data = perf
+				header = []string{"time", "equity"}
+				for _, p := range perf.Curve {
+					rows = append(rows, []string{p.Time.Format(time.RFC3339), fmt.Sprint(p.Equity)})
+				}
+			case "portfolio":
+				portfolios := []portfolio{}
+				if err = c.call("GET", "/portfolio", query, nil, &portfolios); err != nil {
+					return
+				}
+				data = portfolios
+				header = []string{"time", "exchangeId", "currency", "amount", "price", "value", "quote"}
+				for _, p := range portfolios {
+					for _, s := range p.Snapshots {
+						rows = append(rows, []string{p.Time.Format(time.RFC3339), fmt.Sprint(s.ExchangeID), s.Currency, fmt.Sprint(s.Amount), fmt.Sprint(s.Price), fmt.Sprint(s.Value), s.Quote})
+					}
+				}
+			}
+			w := os.Stdout
+			if *output != "" {
+				if w, err = os.Create(*output); err != nil {
+					return
+				}
+				defer w.Close()
+			}
+			if *format == "json" {
+				encoder := json.NewEncoder(w)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(data)
+			}
+			writer := csv.NewWriter(w)
+			writer.Write(header)
+			writer.WriteAll(rows)
+			return writer.Error()
+		}
+	}
+}
+
+// allLogs reads all the logs of the trader page by page, from the oldest to the newest
+func (c *client) allLogs(trader int64, query url.Values) (logs []logEntry, err error) {
+	for page := 1; ; page++ {
+		list := struct {
+			Total int64
+			List  []logEntry
+		}{}
+		query.Set("pageSize", "1000")
+		query.Set("current", fmt.Sprint(page))
+		if err = c.call("GET", fmt.Sprintf("/traders/%v/logs", trader), query, nil, &list); err != nil {
+			return
+		}
+		logs = append(logs, list.List...)
+		if len(list.List) == 0 || int64(page*1000) >= list.Total {
+			break
+		}
+	}
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return
+}
+
+func candleImport(fs *flag.FlagSet) func(c *client) error {
+	file := fs.String("file", "", "the CSV file with a header line, or the Parquet file (.parquet)")
+	exchange := fs.String("exchange", "", "exchange type")
+	stock := fs.String("stock", "", "stock type, like BTC/USDT")
+	period := fs.String("period", "", "period, like M15")
+	columns := fs.String("columns", "", "column names of the fields, like time=Date,volume=Vol")
+	timeFormat := fs.String("timeformat", "", "unix, unixms or a Go time layout, default auto detect")
+	tz := fs.String("tz", "UTC", "time zone of the times without one, like Asia/Shanghai")
+	return func(c *client) error {
+		if *file == "" || *exchange == "" || *stock == "" || *period == "" {
+			return fmt.Errorf("File, Exchange, Stock and Period can not be empty")
+		}
+		data, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		fields := make(map[string]string)
+		for _, s := range strings.Split(*columns, ",") {
+			if kv := strings.SplitN(s, "=", 2); len(kv) == 2 {
+				fields[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+		}
+		// 由服务器解析 CSV 和 Parquet, CLI 不依赖 api 和 model, Parquet 的内容以 base64 发送
+		req := map[string]interface{}{"data": string(data), "columns": fields, "timeFormat": *timeFormat, "timeZone": *tz}
+		path := "/candles/csv"
+		if strings.EqualFold(filepath.Ext(*file), ".parquet") {
+			req["data"], path = data, "/candles/parquet"
+		}
+		query := url.Values{"exchange": {*exchange}, "stockType": {*stock}, "period": {*period}}
+		created := 0
+		if err := c.call("POST", path, query, req, &created); err != nil {
+			return err
+		}
+		fmt.Printf("Candles imported: %v new\n", created)
+		return nil
+	}
+}
+
+func candleExport(fs *flag.FlagSet) func(c *client) error {
+	exchange := fs.String("exchange", "", "exchange type")
+	stock := fs.String("stock", "", "stock type, like BTC/USDT")
+	period := fs.String("period", "", "period, like M15")
+	output := fs.String("o", "", "output file, default stdout")
+	begin := fs.String("begin", "", "begin time, RFC3339")
+	end := fs.String("end", "", "end time, RFC3339")
+	return func(c *client) (err error) {
+		if *exchange == "" || *stock == "" || *period == "" {
+			return fmt.Errorf("Exchange, Stock and Period can not be empty")
+		}
+		query := url.Values{"exchange": {*exchange}, "stockType": {*stock}, "period": {*period}, "begin": {*begin}, "end": {*end}}
+		data := ""
+		if err = c.call("GET", "/candles/export", query, nil, &data); err != nil {
+			return
+		}
+		w := os.Stdout
+		if *output != "" {
+			if w, err = os.Create(*output); err != nil {
+				return
+			}
+			defer w.Close()
+		}
+		_, err = io.WriteString(w, data)
+		return
+	}
+}
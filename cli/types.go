@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The CLI decodes the JSON of the REST API into these types instead of importing model,
+// so that it runs without the config.ini and the database of the server
+
+// exchange is the JSON of an exchange
+type exchange struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	Config    string `json:"config"`
+}
+
+// algorithm is the JSON of an algorithm
+type algorithm struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Script      string `json:"script"`
+	EvnDefault  string `json:"evnDefault"`
+}
+
+// trader is the JSON of a trader to create
+type trader struct {
+	Name        string     `json:"name"`
+	AlgorithmID int64      `json:"algorithmId"`
+	Environment string     `json:"environment"`
+	Exchanges   []exchange `json:"exchanges"`
+}
+
+// logEntry is the JSON of a log
+type logEntry struct {
+	ID           int64     `json:"id"`
+	ExchangeType string    `json:"exchangeType"`
+	Type         string    `json:"type"`
+	StockType    string    `json:"stockType"`
+	Price        float64   `json:"price"`
+	Amount       float64   `json:"amount"`
+	Message      string    `json:"message"`
+	Time         time.Time `json:"time"`
+}
+
+// event is the JSON of a server-sent event
+type event struct {
+	TraderID int64           `json:"traderId"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// equityPoint is the JSON of a point of the equity curve
+type equityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// performance is the JSON of the performance of a trader
+type performance struct {
+	Curve           []equityPoint `json:"curve"`
+	Profit          float64       `json:"profit"`
+	Return          float64       `json:"return"`
+	MaxDrawdown     float64       `json:"maxDrawdown"`
+	MaxDrawdownRate float64       `json:"maxDrawdownRate"`
+	Sharpe          float64       `json:"sharpe"`
+	Sortino         float64       `json:"sortino"`
+	WinRate         float64       `json:"winRate"`
+	TradeCount      int64         `json:"tradeCount"`
+}
+
+// snapshot is the JSON of the snapshot of a currency
+type snapshot struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"userId"`
+	ExchangeID int64     `json:"exchangeId"`
+	Currency   string    `json:"currency"`
+	Quote      string    `json:"quote"`
+	Amount     float64   `json:"amount"`
+	Price      float64   `json:"price"`
+	Value      float64   `json:"value"`
+	Time       time.Time `json:"time"`
+}
+
+// portfolio is the JSON of the snapshots taken at the same time
+type portfolio struct {
+	Time      time.Time  `json:"time"`
+	Value     float64    `json:"value"`
+	Snapshots []snapshot `json:"snapshots"`
+}
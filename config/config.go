@@ -1,32 +1,69 @@
 package config
 
 import (
-	"log"
+	"path/filepath"
 	"strings"
 
 	"github.com/go-ini/ini"
 )
 
-var confs = make(map[string]string)
+var (
+	confs    = make(map[string]string)
+	sections = make(map[string]map[string]string)
+
+	// Err is the error of loading config.ini, the server refuses to start with it while the CLI does not need the file
+	Err error
+)
 
 func init() {
-	conf, err := ini.InsensitiveLoad("custom/config.ini")
+	conf, err := load()
 	if err != nil {
-		conf, err = ini.InsensitiveLoad("config.ini")
-		if err != nil {
-			log.Fatalln("Load config.ini error:", err)
-		}
+		Err = err
+		confs["logstimezone"] = "Local"
+		return
 	}
 	keys := conf.Section("").KeyStrings()
 	for _, k := range keys {
 		confs[k] = conf.Section("").Key(k).String()
 	}
+	for _, section := range conf.Sections() {
+		if strings.EqualFold(section.Name(), ini.DEFAULT_SECTION) {
+			continue
+		}
+		sections[section.Name()] = section.KeysHash()
+	}
 	if confs["logstimezone"] == "" {
 		confs["logstimezone"] = "Local"
 	}
 }
 
+// load loads custom/config.ini or config.ini of the working directory, the parent directories are searched
+// too so that the tests of the packages can run in their own directories
+func load() (conf *ini.File, err error) {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return
+	}
+	for {
+		for _, name := range []string{"custom/config.ini", "config.ini"} {
+			if conf, err = ini.InsensitiveLoad(filepath.Join(dir, name)); err == nil {
+				return
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
 // String ...
 func String(key string) string {
 	return confs[strings.ToLower(key)]
 }
+
+// Section returns the keys and values of a named section, like [adapters]
+func Section(name string) map[string]string {
+	return sections[strings.ToLower(name)]
+}
@@ -8,11 +8,15 @@ const (
 	ErrInsufficientPermissions = "Insufficient Permissions"
 )
 
+// AdminLevel is the level of the admin user created with the database
+const AdminLevel = 99
+
 // exchange types
 const (
 	Zb         = "zb"
 	Okex       = "okex"
 	OkexThree  = "okexv3"
+	OkexV3     = "okex-v3-compatible"
 	Xnodes     = "xnodes"
 	Coffee     = "coffee"
 	Huobi      = "huobi"
@@ -47,8 +51,30 @@ const (
 	TradeTypeShortClose = "SHORT_CLOSE"
 )
 
+// notify channel types
+const (
+	NotifyWebhook  = "webhook"
+	NotifyEmail    = "email"
+	NotifyTelegram = "telegram"
+	NotifyDingTalk = "dingtalk"
+	NotifyWeCom    = "wecom"
+)
+
+// notify events besides the log types
+const (
+	NotifyOnStop = "STOP"
+)
+
+// event types
+const (
+	EventLog    = "log"
+	EventStatus = "status"
+	EventPanel  = "panel"
+)
+
 // some variables
 var (
-	Consts        = []string{"M", "M5", "M15", "M30", "H", "D", "W"}
-	ExchangeTypes = []string{Zb, Okex, OkexThree, Xnodes, Coffee, Huobi, Binance, GateIo, Poloniex, OkexFuture, BigOne}
+	Consts        = []string{"M", "M3", "M5", "M15", "M30", "H", "H2", "H4", "H6", "H12", "D", "D3", "W"}
+	ExchangeTypes = []string{Zb, Okex, OkexThree, OkexV3, Xnodes, Coffee, Huobi, Binance, GateIo, Poloniex, OkexFuture, BigOne}
+	NotifyTypes   = []string{NotifyWebhook, NotifyEmail, NotifyTelegram, NotifyDingTalk, NotifyWeCom}
 )
@@ -3,9 +3,9 @@ package handler
 import (
 	"fmt"
 
-	"github.com/hprose/hprose-golang/rpc"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/hprose/hprose-golang/rpc"
 )
 
 type algorithm struct{}
@@ -64,6 +64,10 @@ func (algorithm) Put(req model.Algorithm, ctx rpc.Context) (resp response) {
 			resp.Message = fmt.Sprint(err)
 			return
 		}
+		if _, err := algorithm.SaveVersion(); err != nil {
+			resp.Message = fmt.Sprint(err)
+			return
+		}
 		resp.Success = true
 		return
 	}
@@ -72,6 +76,10 @@ func (algorithm) Put(req model.Algorithm, ctx rpc.Context) (resp response) {
 		resp.Message = fmt.Sprint(err)
 		return
 	}
+	if _, err := req.SaveVersion(); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
 	resp.Success = true
 	return
 }
@@ -104,3 +112,67 @@ func (algorithm) Delete(ids []int64, ctx rpc.Context) (resp response) {
 	}
 	return
 }
+
+// Versions
+func (algorithm) Versions(id int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	versions, err := self.ListAlgorithmVersion(id)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = versions
+	resp.Success = true
+	return
+}
+
+// Diff
+func (algorithm) Diff(id, from, to int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	diff, err := self.DiffAlgorithmVersion(id, from, to)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = diff
+	resp.Success = true
+	return
+}
+
+// Rollback
+func (algorithm) Rollback(id, version int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := self.RollbackAlgorithm(id, version); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/trader"
+	"github.com/hprose/hprose-golang/rpc"
+)
+
+type backtest struct{}
+
+// Run backtests an algorithm over the stored candles, it returns when the backtest is done
+func (backtest) Run(req trader.BacktestRequest, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	result, err := trader.Backtest(self, req)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = result
+	resp.Success = true
+	return
+}
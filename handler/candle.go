@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/hprose/hprose-golang/rpc"
+)
+
+type candle struct{}
+
+// List
+func (candle) List(exchange, stockType, period string, begin, end time.Time, size int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	if _, err := model.GetUser(username); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	from, to := int64(0), int64(0)
+	if !begin.IsZero() {
+		from = begin.Unix()
+	}
+	if !end.IsZero() {
+		to = end.Unix()
+	}
+	candles, err := model.ListCandles(exchange, strings.ToUpper(stockType), period, from, to, int(size))
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = candles
+	resp.Success = true
+	return
+}
+
+// Trades returns the collected public trades of a market, the time is in milliseconds
+func (candle) Trades(exchange, stockType string, begin, end time.Time, size int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	if _, err := model.GetUser(username); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	from, to := int64(0), int64(0)
+	if !begin.IsZero() {
+		from = begin.UnixNano() / int64(time.Millisecond)
+	}
+	if !end.IsZero() {
+		to = end.UnixNano() / int64(time.Millisecond)
+	}
+	trades, err := model.ListMarketTrades(exchange, strings.ToUpper(stockType), from, to, int(size))
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = trades
+	resp.Success = true
+	return
+}
+
+// Import saves the candles of a series, the candles of the existing time are replaced, only the admin can
+// import because the candles are shared by all the users
+func (candle) Import(exchange, stockType, period string, candles []model.Candle, ctx rpc.Context) (resp response) {
+	if resp.Message = checkImporter(ctx); resp.Message != "" {
+		return
+	}
+	if exchange == "" || stockType == "" || api.PeriodSeconds(period) <= 0 {
+		resp.Message = "Exchange, StockType and Period can not be empty"
+		return
+	}
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+	for i := range candles {
+		candles[i].ID = 0
+	}
+	created, err := model.SaveCandles(exchange, strings.ToUpper(stockType), period, candles)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = created
+	resp.Success = true
+	return
+}
+
+// ImportCSV parses the CSV data with a header line and imports the candles like Import, the columns map
+// the fields (time, open, high, low, close, volume) to the column names, the time format is unix, unixms
+// or a Go time layout and is detected when empty, the time zone is used for the times without one
+func (candle) ImportCSV(exchange, stockType, period, data string, columns map[string]string, timeFormat, timeZone string, ctx rpc.Context) (resp response) {
+	if resp.Message = checkImporter(ctx); resp.Message != "" {
+		return
+	}
+	opt, err := csvOption(columns, timeFormat, timeZone)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	candles, err := api.ReadCandlesCSV(strings.NewReader(data), opt)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	return candle{}.Import(exchange, stockType, period, candles, ctx)
+}
+
+// ImportParquet reads the candles from the data of a Parquet file and imports them like ImportCSV, the
+// timestamp and date columns do not need the time format
+func (candle) ImportParquet(exchange, stockType, period string, data []byte, columns map[string]string, timeFormat, timeZone string, ctx rpc.Context) (resp response) {
+	if resp.Message = checkImporter(ctx); resp.Message != "" {
+		return
+	}
+	opt, err := csvOption(columns, timeFormat, timeZone)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	candles, err := api.ReadCandlesParquet(bytes.NewReader(data), int64(len(data)), opt)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	return candle{}.Import(exchange, stockType, period, candles, ctx)
+}
+
+// csvOption returns the option of the columns, the time format and the time zone of an import
+func csvOption(columns map[string]string, timeFormat, timeZone string) (api.CSVOption, error) {
+	opt := api.CSVOption{Columns: make(map[string]string), TimeFormat: timeFormat, Location: time.UTC}
+	for field, name := range columns {
+		opt.Columns[strings.ToLower(strings.TrimSpace(field))] = strings.TrimSpace(name)
+	}
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return opt, err
+		}
+		opt.Location = loc
+	}
+	return opt, nil
+}
+
+// checkImporter returns the error message when the user of ctx is not the admin
+func checkImporter(ctx rpc.Context) string {
+	username := ctx.GetString("username")
+	if username == "" {
+		return constant.ErrAuthorizationError
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		return fmt.Sprint(err)
+	}
+	if self.Level < constant.AdminLevel {
+		return constant.ErrInsufficientPermissions
+	}
+	return ""
+}
+
+// Export returns the candles of a series between begin and end as CSV in the shape of Record
+func (candle) Export(exchange, stockType, period string, begin, end time.Time, ctx rpc.Context) (resp response) {
+	resp = candle{}.List(exchange, stockType, period, begin, end, 0, ctx)
+	if !resp.Success {
+		return
+	}
+	buf := bytes.Buffer{}
+	if err := api.WriteCandlesCSV(&buf, resp.Data.([]model.Candle)); err != nil {
+		resp.Success, resp.Message, resp.Data = false, fmt.Sprint(err), nil
+		return
+	}
+	resp.Data = buf.String()
+	return
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/hprose/hprose-golang/rpc"
+	"github.com/jinzhu/gorm"
+)
+
+func TestCandleImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := model.DB
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		model.DB.Close()
+		model.DB = db
+	}()
+	model.DB.AutoMigrate(&model.User{}, &model.Candle{})
+	model.DB.Create(&model.User{Username: "admin", Level: constant.AdminLevel})
+	model.DB.Create(&model.User{Username: "user", Level: constant.AdminLevel - 1})
+	tests := []struct {
+		username    string
+		wantSuccess bool
+		wantMessage string
+	}{
+		{"", false, constant.ErrAuthorizationError},
+		{"user", false, constant.ErrInsufficientPermissions},
+		{"admin", true, ""},
+	}
+	for _, tt := range tests {
+		ctx := rpc.NewBaseContext()
+		ctx.SetString("username", tt.username)
+		resp := candle{}.Import("okex", "btc/usdt", "M", []model.Candle{{Timestamp: 60}, {ID: 9, Timestamp: 0}}, ctx)
+		if resp.Success != tt.wantSuccess || resp.Message != tt.wantMessage {
+			t.Errorf("Import() by %q = %v %q, want %v %q", tt.username, resp.Success, resp.Message, tt.wantSuccess, tt.wantMessage)
+		}
+	}
+	candles, _ := model.ListCandles("okex", "BTC/USDT", "M", 0, 0, 0)
+	if len(candles) != 2 || candles[0].Timestamp != 0 {
+		t.Errorf("the imported candles are %+v", candles)
+	}
+}
+
+func TestCandleImportCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := model.DB
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		model.DB.Close()
+		model.DB = db
+	}()
+	model.DB.AutoMigrate(&model.User{}, &model.Candle{})
+	model.DB.Create(&model.User{Username: "admin", Level: constant.AdminLevel})
+	model.DB.Create(&model.User{Username: "user", Level: constant.AdminLevel - 1})
+	tests := []struct {
+		username    string
+		data        string
+		columns     map[string]string
+		timeZone    string
+		wantSuccess bool
+		wantData    interface{}
+	}{
+		{"", "Time,Open,High,Low,Close\n1514764800,1,1,1,1\n", nil, "", false, nil},
+		{"user", "Time,Open,High,Low,Close\n1514764800,1,1,1,1\n", nil, "", false, nil},
+		{"admin", "Time,Open,High,Close\n1514764800,1,1,1\n", nil, "", false, nil},
+		{"admin", "Time,Open,High,Low,Close\n1514764800,1,1,1,1\n", nil, "Mars/Olympus", false, nil},
+		{"admin", "Day,Open,High,Low,Close\n2018-01-01 08:00:00,1,1,1,1\n2018-01-01 08:01:00,2,2,2,2\n", map[string]string{"Time": "Day"}, "Asia/Shanghai", true, 2},
+		{"admin", "Time,Open,High,Low,Close\n1514764860,3,3,3,3\n", nil, "", true, 0},
+	}
+	for i, tt := range tests {
+		ctx := rpc.NewBaseContext()
+		ctx.SetString("username", tt.username)
+		resp := candle{}.ImportCSV("okex", "btc/usdt", "M", tt.data, tt.columns, "", tt.timeZone, ctx)
+		if resp.Success != tt.wantSuccess || (tt.wantSuccess && resp.Data != tt.wantData) {
+			t.Errorf("%v: ImportCSV() = %v %v %q, want %v %v", i, resp.Success, resp.Data, resp.Message, tt.wantSuccess, tt.wantData)
+		}
+	}
+	candles, _ := model.ListCandles("okex", "BTC/USDT", "M", 0, 0, 0)
+	if len(candles) != 2 || candles[0].Timestamp != 1514764800 || candles[1].Close != 3 {
+		t.Errorf("the imported candles are %+v", candles)
+	}
+}
+
+func TestCandleImportParquet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := model.DB
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		model.DB.Close()
+		model.DB = db
+	}()
+	model.DB.AutoMigrate(&model.User{}, &model.Candle{})
+	model.DB.Create(&model.User{Username: "admin", Level: constant.AdminLevel})
+	model.DB.Create(&model.User{Username: "user", Level: constant.AdminLevel - 1})
+	// candles.parquet 有 Day(不带时区的纳秒时间戳), Open, High, Low, Close 和 Volume 列, 时间为 2018-01-01 08:00 和 08:01
+	file, err := ioutil.ReadFile(filepath.Join("testdata", "candles.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		username    string
+		data        []byte
+		timeZone    string
+		wantSuccess bool
+		wantData    interface{}
+	}{
+		{"", file, "", false, nil},
+		{"user", file, "", false, nil},
+		{"admin", []byte("Day,Open,High,Low,Close\n2018-01-01 08:00:00,1,1,1,1\n"), "", false, nil},
+		{"admin", file, "Mars/Olympus", false, nil},
+		{"admin", file, "Asia/Shanghai", true, 2},
+	}
+	for i, tt := range tests {
+		ctx := rpc.NewBaseContext()
+		ctx.SetString("username", tt.username)
+		resp := candle{}.ImportParquet("okex", "btc/usdt", "M", tt.data, map[string]string{"time": "Day"}, "", tt.timeZone, ctx)
+		if resp.Success != tt.wantSuccess || (tt.wantSuccess && resp.Data != tt.wantData) {
+			t.Errorf("%v: ImportParquet() = %v %v %q, want %v %v", i, resp.Success, resp.Data, resp.Message, tt.wantSuccess, tt.wantData)
+		}
+	}
+	candles, _ := model.ListCandles("okex", "BTC/USDT", "M", 0, 0, 0)
+	if len(candles) != 2 || candles[0].Timestamp != 1514764800 || candles[1].Close != 2 || candles[0].Volume != 10 || candles[1].Volume != 0 {
+		t.Errorf("the imported candles are %+v", candles)
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/notify"
+	"github.com/hprose/hprose-golang/rpc"
+)
+
+type channel struct{}
+
+// Types ...
+func (channel) Types(_ string, ctx rpc.Context) (resp response) {
+	resp.Data = constant.NotifyTypes
+	resp.Success = true
+	return
+}
+
+// List ...
+func (channel) List(_ string, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	channels, err := self.ListChannel()
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	for i, c := range channels {
+		channels[i] = notify.Mask(c)
+	}
+	resp.Data = channels
+	resp.Success = true
+	return
+}
+
+// Put
+func (channel) Put(req model.Channel, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	channel := req
+	if req.ID > 0 {
+		if err := model.DB.Where("id = ? AND user_id = ?", req.ID, self.ID).First(&channel).Error; err != nil {
+			resp.Message = fmt.Sprint(err)
+			return
+		}
+		req = notify.Unmask(req, channel)
+	}
+	if _, err := notify.New(req); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req.ID > 0 {
+		channel.Name = req.Name
+		channel.Type = req.Type
+		channel.Config = req.Config
+		if err := model.DB.Save(&channel).Error; err != nil {
+			resp.Message = fmt.Sprint(err)
+			return
+		}
+		resp.Success = true
+		return
+	}
+	req.UserID = self.ID
+	if err := model.DB.Create(&req).Error; err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// Delete
+func (channel) Delete(ids []int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := model.DB.Where("id in (?) AND user_id = ?", ids, self.ID).Delete(&model.Channel{}).Error; err != nil {
+		resp.Message = fmt.Sprint(err)
+	} else {
+		resp.Success = true
+	}
+	return
+}
+
+// Test sends a test message to the channel
+func (channel) Test(req model.Channel, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := notify.Send(self.ID, req.Name, fmt.Sprintf("[%v] Test", constant.Banner), "It works!"); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/notify"
+	"github.com/hprose/hprose-golang/rpc"
+	"github.com/jinzhu/gorm"
+)
+
+func TestChannelSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := model.DB
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		model.DB.Close()
+		model.DB = db
+	}()
+	model.DB.AutoMigrate(&model.User{}, &model.Channel{})
+	model.DB.Create(&model.User{Username: "user", Level: 1})
+	ctx := rpc.NewBaseContext()
+	ctx.SetString("username", "user")
+	const config = `{"host":"smtp.example.com","password":"p@ss","port":25,"to":"a@example.com"}`
+	if resp := (channel{}).Put(model.Channel{Name: "mail", Type: constant.NotifyEmail, Config: config}, ctx); !resp.Success {
+		t.Fatalf("Put() error = %v", resp.Message)
+	}
+	resp := channel{}.List("", ctx)
+	channels, _ := resp.Data.([]model.Channel)
+	if len(channels) != 1 || channels[0].Config != `{"host":"smtp.example.com","password":"`+notify.Masked+`","port":25,"to":"a@example.com"}` {
+		t.Fatalf("List() = %+v", resp.Data)
+	}
+	masked := channels[0]
+	masked.Config = `{"host":"smtp.example.com","password":"` + notify.Masked + `","port":25,"to":"b@example.com"}`
+	if resp := (channel{}).Put(masked, ctx); !resp.Success {
+		t.Fatalf("Put() of the masked config error = %v", resp.Message)
+	}
+	stored := model.Channel{}
+	model.DB.First(&stored, masked.ID)
+	if stored.Config != `{"host":"smtp.example.com","password":"p@ss","port":25,"to":"b@example.com"}` {
+		t.Errorf("the stored config is %v", stored.Config)
+	}
+}
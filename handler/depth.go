@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/hprose/hprose-golang/rpc"
+)
+
+type depth struct{}
+
+// Get rebuilds the recorded order book at the time, default now
+func (depth) Get(exchange, stockType string, at time.Time, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	if _, err := model.GetUser(username); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	snapshot, err := api.DepthAt(exchange, strings.ToUpper(stockType), at.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = snapshot
+	resp.Success = true
+	return
+}
+
+// the default and the max snapshots of a replay page
+const (
+	depthReplaySize = 1000
+	depthReplayMax  = 10000
+)
+
+// Replay rebuilds the recorded order books between begin and end, for exporting or replaying, both times are
+// required and at most size books are returned, the next page begins after the time of the last book
+func (depth) Replay(exchange, stockType string, begin, end time.Time, size int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	if _, err := model.GetUser(username); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if begin.IsZero() || end.Before(begin) {
+		resp.Message = "Begin and End are required and End can not be before Begin"
+		return
+	}
+	if size <= 0 {
+		size = depthReplaySize
+	} else if size > depthReplayMax {
+		size = depthReplayMax
+	}
+	from, to := begin.UnixNano()/int64(time.Millisecond), end.UnixNano()/int64(time.Millisecond)
+	snapshots, err := api.ReplayDepth(exchange, strings.ToUpper(stockType), from, to, int(size))
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = snapshots
+	resp.Success = true
+	return
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
-	"github.com/hprose/hprose-golang/rpc"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/hprose/hprose-golang/rpc"
 )
 
 type exchange struct{}
@@ -57,6 +59,10 @@ func (exchange) Put(req model.Exchange, ctx rpc.Context) (resp response) {
 		resp.Message = fmt.Sprint(err)
 		return
 	}
+	if req.Config != "" && !json.Valid([]byte(req.Config)) {
+		resp.Message = "Config must be valid JSON"
+		return
+	}
 	exchange := req
 	if req.ID > 0 {
 		if err := model.DB.First(&exchange, req.ID).Error; err != nil {
@@ -67,6 +73,7 @@ func (exchange) Put(req model.Exchange, ctx rpc.Context) (resp response) {
 		exchange.Type = req.Type
 		exchange.AccessKey = req.AccessKey
 		exchange.SecretKey = req.SecretKey
+		exchange.Config = req.Config
 		if err := model.DB.Save(&exchange).Error; err != nil {
 			resp.Message = fmt.Sprint(err)
 			return
@@ -111,3 +118,25 @@ func (exchange) Delete(ids []int64, ctx rpc.Context) (resp response) {
 	}
 	return
 }
+
+// Portfolio
+func (exchange) Portfolio(begin, end time.Time, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	portfolios, err := self.ListPortfolio(begin, end)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = portfolios
+	resp.Success = true
+	return
+}
@@ -8,15 +8,18 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hprose/hprose-golang/rpc"
 	"github.com/geniustag/QuantBot/config"
 	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/notify"
+	"github.com/geniustag/QuantBot/trader"
+	"github.com/hprose/hprose-golang/rpc"
 )
 
 type response struct {
-	Success bool
-	Message string
-	Data    interface{}
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
 }
 
 type event struct{}
@@ -27,6 +30,10 @@ func (e event) OnSendHeader(ctx *rpc.HTTPContext) {
 
 // Server ...
 func Server() {
+	if config.Err != nil {
+		log.Fatalln("Load config.ini error:", config.Err)
+	}
+	model.Open()
 	port := config.String("port")
 	service := rpc.NewHTTPService()
 	handler := struct {
@@ -35,6 +42,10 @@ func Server() {
 		Algorithm algorithm
 		Trader    runner
 		Log       logger
+		Channel   channel
+		Candle    candle
+		Depth     depth
+		Backtest  backtest
 	}{}
 	service.Event = event{}
 	service.AddBeforeFilterHandler(func(request []byte, ctx rpc.Context, next rpc.NextFilterHandler) (response []byte, err error) {
@@ -48,20 +59,33 @@ func Server() {
 	service.AddInvokeHandler(func(name string, args []reflect.Value, ctx rpc.Context, next rpc.NextInvokeHandler) (results []reflect.Value, err error) {
 		name = strings.Replace(name, "_", ".", 1)
 		results, err = next(name, args, ctx)
-		spend := (time.Now().UnixNano() - ctx.GetInt64("start")) / 1000000
-		spendInfo := ""
-		if spend > 1000 {
-			spendInfo = fmt.Sprintf("%vs", spend/1000)
-		} else {
-			spendInfo = fmt.Sprintf("%vms", spend)
-		}
-		log.Printf("%16s() spend %s", name, spendInfo)
+		logSpend(name+"()", time.Unix(0, ctx.GetInt64("start")))
 		return
 	})
 	service.AddAllMethods(handler)
 	http.Handle("/api", service)
+	http.HandleFunc("/api/stream", stream)
+	http.HandleFunc("/api/v1/", rest)
 	http.Handle("/", http.FileServer(http.Dir("web/dist")))
+	go model.Prune()
+	go trader.Snapshot()
+	go notify.Run()
+	go trader.Schedule()
+	go trader.Collect()
+	go trader.Record()
 	fmt.Printf("%v  Version %v\n", constant.Banner, constant.Version)
 	log.Printf("Running at http://localhost:%v\n", port)
 	http.ListenAndServe(":"+port, nil)
 }
+
+// logSpend prints the time spent by an API call
+func logSpend(name string, start time.Time) {
+	spend := time.Since(start).Nanoseconds() / 1000000
+	spendInfo := ""
+	if spend > 1000 {
+		spendInfo = fmt.Sprintf("%vs", spend/1000)
+	} else {
+		spendInfo = fmt.Sprintf("%vms", spend)
+	}
+	log.Printf("%16s spend %s", name, spendInfo)
+}
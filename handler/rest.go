@@ -0,0 +1,440 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/trader"
+	"github.com/hprose/hprose-golang/rpc"
+)
+
+// restRequest is a parsed request of the REST API
+type restRequest struct {
+	*http.Request
+	params map[string]string
+	ctx    rpc.Context
+}
+
+// route is an endpoint of the REST API, it calls the same handler as the hprose RPC
+type route struct {
+	Method  string
+	Path    string // 路径参数写作 {id}
+	Summary string
+	Query   []string // 查询参数
+	Body    string   // 请求体的 JSON 结构说明, 为空表示没有请求体
+	Public  bool     // 不需要 Bearer token
+	handle  func(r *restRequest) response
+}
+
+var routes = []route{
+	{Method: "POST", Path: "/login", Summary: "Login and get a bearer token", Body: `{"username": "", "password": ""}`, Public: true, handle: func(r *restRequest) response {
+		req := struct{ Username, Password string }{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return user{}.Login(req.Username, req.Password, r.ctx)
+	}},
+	{Method: "GET", Path: "/user", Summary: "Get the current user", handle: func(r *restRequest) response {
+		return user{}.Get("", r.ctx)
+	}},
+	{Method: "GET", Path: "/users", Summary: "List users", Query: []string{"size", "page", "order"}, handle: func(r *restRequest) response {
+		return user{}.List(r.int64("size", -1), r.int64("page", 1), r.string("order", "id"), r.ctx)
+	}},
+	{Method: "POST", Path: "/users", Summary: "Create or update a user", Body: `User and {"password": ""}`, handle: func(r *restRequest) response {
+		req := model.User{}
+		password := struct{ Password string }{}
+		if err := r.decode(&req, &password); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return user{}.Put(req, password.Password, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/users", Summary: "Delete users", Query: []string{"ids"}, handle: func(r *restRequest) response {
+		return user{}.Delete(r.ids(), r.ctx)
+	}},
+	{Method: "GET", Path: "/exchange-types", Summary: "List the supported exchange types", handle: func(r *restRequest) response {
+		return exchange{}.Types("", r.ctx)
+	}},
+	{Method: "GET", Path: "/exchanges", Summary: "List exchanges", Query: []string{"size", "page", "order"}, handle: func(r *restRequest) response {
+		return exchange{}.List(r.int64("size", -1), r.int64("page", 1), r.string("order", "id"), r.ctx)
+	}},
+	{Method: "POST", Path: "/exchanges", Summary: "Create or update an exchange", Body: "Exchange", handle: func(r *restRequest) response {
+		req := model.Exchange{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return exchange{}.Put(req, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/exchanges", Summary: "Delete exchanges", Query: []string{"ids"}, handle: func(r *restRequest) response {
+		return exchange{}.Delete(r.ids(), r.ctx)
+	}},
+	{Method: "GET", Path: "/portfolio", Summary: "Get the account snapshots history", Query: []string{"begin", "end"}, handle: func(r *restRequest) response {
+		return exchange{}.Portfolio(r.time("begin"), r.time("end"), r.ctx)
+	}},
+	{Method: "GET", Path: "/algorithms", Summary: "List algorithms", Query: []string{"size", "page", "order"}, handle: func(r *restRequest) response {
+		return algorithm{}.List(r.int64("size", -1), r.int64("page", 1), r.string("order", "id"), r.ctx)
+	}},
+	{Method: "POST", Path: "/algorithms", Summary: "Create or update an algorithm", Body: "Algorithm", handle: func(r *restRequest) response {
+		req := model.Algorithm{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return algorithm{}.Put(req, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/algorithms", Summary: "Delete algorithms", Query: []string{"ids"}, handle: func(r *restRequest) response {
+		return algorithm{}.Delete(r.ids(), r.ctx)
+	}},
+	{Method: "GET", Path: "/algorithms/{id}/versions", Summary: "List the script versions of an algorithm", handle: func(r *restRequest) response {
+		return algorithm{}.Versions(r.int64("id", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/algorithms/{id}/diff", Summary: "Diff two script versions", Query: []string{"from", "to"}, handle: func(r *restRequest) response {
+		return algorithm{}.Diff(r.int64("id", 0), r.int64("from", 0), r.int64("to", 0), r.ctx)
+	}},
+	{Method: "POST", Path: "/algorithms/{id}/rollback", Summary: "Rollback the script to a version", Query: []string{"version"}, handle: func(r *restRequest) response {
+		return algorithm{}.Rollback(r.int64("id", 0), r.int64("version", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders", Summary: "List the traders of an algorithm", Query: []string{"algorithmId"}, handle: func(r *restRequest) response {
+		return runner{}.List(r.int64("algorithmId", 0), r.ctx)
+	}},
+	{Method: "POST", Path: "/traders", Summary: "Create or update a trader", Body: "Trader", handle: func(r *restRequest) response {
+		req := model.Trader{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return runner{}.Put(req, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/traders/{id}", Summary: "Delete a trader", handle: func(r *restRequest) response {
+		return runner{}.Delete(r.trader(), r.ctx)
+	}},
+	{Method: "POST", Path: "/traders/{id}/switch", Summary: "Start or stop a trader", handle: func(r *restRequest) response {
+		return runner{}.Switch(r.trader(), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/status", Summary: "Get the status and the status panel of a trader", handle: func(r *restRequest) response {
+		return runner{}.Status(r.trader(), r.ctx)
+	}},
+	{Method: "POST", Path: "/traders/{id}/command", Summary: "Send a command to a running trader", Query: []string{"command"}, handle: func(r *restRequest) response {
+		return runner{}.Command(r.trader(), r.string("command", ""), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/executions", Summary: "List the TWAP, VWAP and Iceberg executions of a trader", handle: func(r *restRequest) response {
+		return runner{}.Executions(r.trader(), r.ctx)
+	}},
+	{Method: "DELETE", Path: "/traders/{id}/executions", Summary: "Cancel a running execution of a trader", Query: []string{"execution"}, handle: func(r *restRequest) response {
+		return runner{}.CancelExecution(r.trader(), r.int64("execution", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/conditional-orders", Summary: "List the stop-loss, take-profit and trailing-stop orders of a trader", handle: func(r *restRequest) response {
+		return runner{}.ConditionalOrders(r.trader(), r.ctx)
+	}},
+	{Method: "DELETE", Path: "/traders/{id}/conditional-orders", Summary: "Cancel a waiting conditional order of a trader", Query: []string{"order"}, handle: func(r *restRequest) response {
+		return runner{}.CancelConditionalOrder(r.trader(), r.int64("order", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/performance", Summary: "Get the equity curve and performance of a trader", Query: []string{"begin", "end", "base"}, handle: func(r *restRequest) response {
+		return runner{}.Performance(r.trader(), r.time("begin"), r.time("end"), r.float64("base"), r.ctx)
+	}},
+	{Method: "POST", Path: "/traders/{id}/pin", Summary: "Pin a trader to an algorithm version, 0 means the latest", Query: []string{"version"}, handle: func(r *restRequest) response {
+		req := r.trader()
+		req.Version = r.int64("version", 0)
+		return runner{}.Pin(req, r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/logs", Summary: "List the logs of a trader", Query: []string{"current", "pageSize", "type", "exchangeType", "stockType", "begin", "end", "message"}, handle: func(r *restRequest) response {
+		p := pagination{Current: r.int64("current", 1), PageSize: r.int64("pageSize", 20)}
+		f := filters{
+			Type:         r.strings("type"),
+			ExchangeType: r.strings("exchangeType"),
+			StockType:    r.strings("stockType"),
+			Begin:        r.time("begin"),
+			End:          r.time("end"),
+			Message:      r.string("message", ""),
+		}
+		return logger{}.List(r.trader(), p, f, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/traders/{id}/logs", Summary: "Delete the logs of a trader older than a time", Query: []string{"before"}, handle: func(r *restRequest) response {
+		return logger{}.Delete(r.trader(), r.time("before"), r.ctx)
+	}},
+	{Method: "GET", Path: "/traders/{id}/store", Summary: "List the stored keys and JSON values of a trader", handle: func(r *restRequest) response {
+		return runner{}.Store(r.trader(), r.ctx)
+	}},
+	{Method: "POST", Path: "/traders/{id}/store", Summary: "Set a stored key of a trader, the body is the JSON value", Query: []string{"key"}, Body: "Any", handle: func(r *restRequest) response {
+		value := json.RawMessage{}
+		if err := r.decode(&value); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return runner{}.StoreSet(r.trader(), r.string("key", ""), string(value), r.ctx)
+	}},
+	{Method: "DELETE", Path: "/traders/{id}/store", Summary: "Delete a stored key of a trader", Query: []string{"key"}, handle: func(r *restRequest) response {
+		return runner{}.StoreDelete(r.trader(), r.string("key", ""), r.ctx)
+	}},
+	{Method: "GET", Path: "/candles", Summary: "List the collected candles of a series, the time is in seconds", Query: []string{"exchange", "stockType", "period", "begin", "end", "size"}, handle: func(r *restRequest) response {
+		return candle{}.List(r.string("exchange", ""), r.string("stockType", ""), r.string("period", ""), r.time("begin"), r.time("end"), r.int64("size", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/trades", Summary: "List the collected public trades of a market, the time is in milliseconds", Query: []string{"exchange", "stockType", "begin", "end", "size"}, handle: func(r *restRequest) response {
+		return candle{}.Trades(r.string("exchange", ""), r.string("stockType", ""), r.time("begin"), r.time("end"), r.int64("size", 0), r.ctx)
+	}},
+	{Method: "POST", Path: "/candles", Summary: "Import the candles of a series by the admin, the time is in seconds", Query: []string{"exchange", "stockType", "period"}, Body: "[]Candle", handle: func(r *restRequest) response {
+		candles := []model.Candle{}
+		if err := r.decode(&candles); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return candle{}.Import(r.string("exchange", ""), r.string("stockType", ""), r.string("period", ""), candles, r.ctx)
+	}},
+	{Method: "POST", Path: "/candles/csv", Summary: "Import the candles of a series from CSV by the admin", Query: []string{"exchange", "stockType", "period"}, Body: `{"data": "", "columns": {"time": ""}, "timeFormat": "", "timeZone": ""}`, handle: func(r *restRequest) response {
+		req := struct {
+			Data                 string
+			Columns              map[string]string
+			TimeFormat, TimeZone string
+		}{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return candle{}.ImportCSV(r.string("exchange", ""), r.string("stockType", ""), r.string("period", ""), req.Data, req.Columns, req.TimeFormat, req.TimeZone, r.ctx)
+	}},
+	{Method: "POST", Path: "/candles/parquet", Summary: "Import the candles of a series from a Parquet file by the admin, the data is in base64", Query: []string{"exchange", "stockType", "period"}, Body: `{"data": "", "columns": {"time": ""}, "timeFormat": "", "timeZone": ""}`, handle: func(r *restRequest) response {
+		req := struct {
+			Data                 []byte
+			Columns              map[string]string
+			TimeFormat, TimeZone string
+		}{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return candle{}.ImportParquet(r.string("exchange", ""), r.string("stockType", ""), r.string("period", ""), req.Data, req.Columns, req.TimeFormat, req.TimeZone, r.ctx)
+	}},
+	{Method: "GET", Path: "/candles/export", Summary: "Export the candles of a series as CSV in the shape of Record", Query: []string{"exchange", "stockType", "period", "begin", "end"}, handle: func(r *restRequest) response {
+		return candle{}.Export(r.string("exchange", ""), r.string("stockType", ""), r.string("period", ""), r.time("begin"), r.time("end"), r.ctx)
+	}},
+	{Method: "POST", Path: "/backtests", Summary: "Backtest an algorithm over the stored candles, the logs and the equity curve are returned", Body: "BacktestRequest", handle: func(r *restRequest) response {
+		req := trader.BacktestRequest{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return backtest{}.Run(req, r.ctx)
+	}},
+	{Method: "GET", Path: "/depth", Summary: "The recorded order book at the time, default now", Query: []string{"exchange", "stockType", "time"}, handle: func(r *restRequest) response {
+		return depth{}.Get(r.string("exchange", ""), r.string("stockType", ""), r.time("time"), r.ctx)
+	}},
+	{Method: "GET", Path: "/depth/replay", Summary: "Export at most size (default 1000, max 10000) recorded order books between begin and end, the returned time is in milliseconds", Query: []string{"exchange", "stockType", "begin", "end", "size"}, handle: func(r *restRequest) response {
+		return depth{}.Replay(r.string("exchange", ""), r.string("stockType", ""), r.time("begin"), r.time("end"), r.int64("size", 0), r.ctx)
+	}},
+	{Method: "GET", Path: "/channel-types", Summary: "List the supported notify channel types", handle: func(r *restRequest) response {
+		return channel{}.Types("", r.ctx)
+	}},
+	{Method: "GET", Path: "/channels", Summary: "List notify channels", handle: func(r *restRequest) response {
+		return channel{}.List("", r.ctx)
+	}},
+	{Method: "POST", Path: "/channels", Summary: "Create or update a notify channel", Body: "Channel", handle: func(r *restRequest) response {
+		req := model.Channel{}
+		if err := r.decode(&req); err != nil {
+			return response{Message: fmt.Sprint(err)}
+		}
+		return channel{}.Put(req, r.ctx)
+	}},
+	{Method: "DELETE", Path: "/channels", Summary: "Delete notify channels", Query: []string{"ids"}, handle: func(r *restRequest) response {
+		return channel{}.Delete(r.ids(), r.ctx)
+	}},
+	{Method: "POST", Path: "/channels/test", Summary: "Send a test message to a notify channel", Query: []string{"name"}, handle: func(r *restRequest) response {
+		return channel{}.Test(model.Channel{Name: r.string("name", "")}, r.ctx)
+	}},
+}
+
+// rest serves the REST API at /api/v1/, the request and response bodies are JSON,
+// the Authorization header is "Bearer <token>" with the token returned by /login
+func rest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	if r.Method == "OPTIONS" {
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	if path == "/openapi.json" {
+		writeJSON(w, http.StatusOK, openAPI())
+		return
+	}
+	for _, rt := range routes {
+		params, ok := rt.match(r.Method, path)
+		if !ok {
+			continue
+		}
+		req := &restRequest{Request: r, params: params, ctx: rpc.NewBaseContext()}
+		if username := parseToken(r.Header.Get("Authorization")); username != "" {
+			req.ctx.SetString("username", username)
+		} else if !rt.Public {
+			writeJSON(w, http.StatusUnauthorized, response{Message: constant.ErrAuthorizationError})
+			return
+		}
+		start := time.Now()
+		writeJSON(w, http.StatusOK, rt.handle(req))
+		logSpend(r.Method+" "+path, start)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, response{Message: "Not found"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// match reports whether the request matches the route and returns the path parameters
+func (rt route) match(method, path string) (params map[string]string, ok bool) {
+	if rt.Method != method {
+		return
+	}
+	want := strings.Split(strings.Trim(rt.Path, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return
+	}
+	params = make(map[string]string)
+	for i, w := range want {
+		if strings.HasPrefix(w, "{") && strings.HasSuffix(w, "}") {
+			params[strings.Trim(w, "{}")] = got[i]
+		} else if w != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// string returns the path or query parameter
+func (r *restRequest) string(key, def string) string {
+	if v, ok := r.params[key]; ok {
+		return v
+	}
+	if v := r.URL.Query().Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func (r *restRequest) int64(key string, def int64) int64 {
+	v, err := strconv.ParseInt(r.string(key, ""), 10, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func (r *restRequest) float64(key string) float64 {
+	v, _ := strconv.ParseFloat(r.string(key, ""), 64)
+	return v
+}
+
+// time parses a RFC3339 time, or a unix timestamp in seconds
+func (r *restRequest) time(key string) (t time.Time) {
+	v := r.string(key, "")
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0)
+	}
+	t, _ = time.Parse(time.RFC3339, v)
+	return
+}
+
+// strings returns the comma separated or repeated query parameter
+func (r *restRequest) strings(key string) (values []string) {
+	for _, v := range r.URL.Query()[key] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return
+}
+
+func (r *restRequest) ids() (ids []int64) {
+	for _, s := range r.strings("ids") {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+func (r *restRequest) trader() model.Trader {
+	return model.Trader{ID: r.int64("id", 0)}
+}
+
+// decode decodes the JSON body into every value
+func (r *restRequest) decode(values ...interface{}) (err error) {
+	decoder := json.NewDecoder(r.Body)
+	raw := json.RawMessage{}
+	if err = decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("Request data wrong: %v", err)
+	}
+	for _, v := range values {
+		if err = json.Unmarshal(raw, v); err != nil {
+			return fmt.Errorf("Request data wrong: %v", err)
+		}
+	}
+	return
+}
+
+// openAPI builds the OpenAPI 3 spec of the REST API from the routes
+func openAPI() map[string]interface{} {
+	paths := make(map[string]map[string]interface{})
+	for _, rt := range routes {
+		if _, ok := paths[rt.Path]; !ok {
+			paths[rt.Path] = make(map[string]interface{})
+		}
+		params := []map[string]interface{}{}
+		for _, p := range strings.Split(rt.Path, "/") {
+			if strings.HasPrefix(p, "{") {
+				params = append(params, map[string]interface{}{
+					"name": strings.Trim(p, "{}"), "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+				})
+			}
+		}
+		for _, q := range rt.Query {
+			params = append(params, map[string]interface{}{
+				"name": q, "in": "query", "schema": map[string]string{"type": "string"},
+			})
+		}
+		op := map[string]interface{}{
+			"summary":    rt.Summary,
+			"parameters": params,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": `{"success": Boolean, "message": String, "data": Any}`,
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]string{"$ref": "#/components/schemas/Response"}}},
+				},
+			},
+		}
+		if rt.Body != "" {
+			op["requestBody"] = map[string]interface{}{
+				"description": rt.Body,
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]string{"type": "object"}}},
+			}
+		}
+		if !rt.Public {
+			op["security"] = []map[string][]string{{"bearer": {}}}
+		}
+		paths[rt.Path][strings.ToLower(rt.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]string{"title": constant.Banner, "version": constant.Version},
+		"servers": []map[string]string{{"url": "/api/v1"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+			"schemas": map[string]interface{}{
+				"Response": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"success": map[string]string{"type": "boolean"},
+						"message": map[string]string{"type": "string"},
+						"data":    map[string]string{},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteMatch(t *testing.T) {
+	tests := []struct {
+		route      route
+		method     string
+		path       string
+		wantParams map[string]string
+		wantOK     bool
+	}{
+		{route{Method: "GET", Path: "/traders"}, "GET", "/traders", map[string]string{}, true},
+		{route{Method: "GET", Path: "/traders"}, "GET", "/traders/", map[string]string{}, true},
+		{route{Method: "GET", Path: "/traders"}, "POST", "/traders", nil, false},
+		{route{Method: "GET", Path: "/traders"}, "GET", "/traders/1", nil, false},
+		{route{Method: "POST", Path: "/traders/{id}/switch"}, "POST", "/traders/12/switch", map[string]string{"id": "12"}, true},
+		{route{Method: "POST", Path: "/traders/{id}/switch"}, "POST", "/traders/12/status", nil, false},
+		{route{Method: "GET", Path: "/algorithms/{id}/diff/{from}"}, "GET", "/algorithms/3/diff/1", map[string]string{"id": "3", "from": "1"}, true},
+	}
+	for _, tt := range tests {
+		params, ok := tt.route.match(tt.method, tt.path)
+		if ok != tt.wantOK || !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("%v %v match(%v %v) = %v %v, want %v %v", tt.route.Method, tt.route.Path, tt.method, tt.path, params, ok, tt.wantParams, tt.wantOK)
+		}
+	}
+}
+
+func TestRestRequestParams(t *testing.T) {
+	r := &restRequest{
+		Request: httptest.NewRequest("GET", "/api/v1/traders/7/logs?size=20&bad=x&ids=1,2&ids=3,a&type=BUY&begin=1514764800&end=2018-01-02T00:00:00Z&price=1.5", nil),
+		params:  map[string]string{"id": "7"},
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"path param", r.string("id", ""), "7"},
+		{"query param", r.string("type", ""), "BUY"},
+		{"default string", r.string("missing", "def"), "def"},
+		{"int64", r.int64("size", -1), int64(20)},
+		{"invalid int64", r.int64("bad", -1), int64(-1)},
+		{"float64", r.float64("price"), 1.5},
+		{"unix time", r.time("begin"), time.Unix(1514764800, 0)},
+		{"RFC3339 time", r.time("end").UTC(), time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"missing time", r.time("missing"), time.Time{}},
+		{"ids", r.ids(), []int64{1, 2, 3}},
+		{"trader", r.trader().ID, int64(7)},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%v = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestRestDecode(t *testing.T) {
+	r := &restRequest{Request: httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{"username": "a", "password": "b"}`))}
+	user, password := struct{ Username string }{}, struct{ Password string }{}
+	if err := r.decode(&user, &password); err != nil || user.Username != "a" || password.Password != "b" {
+		t.Errorf("decode() = %v %v %v", user, password, err)
+	}
+	r = &restRequest{Request: httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{`))}
+	if err := r.decode(&user); err == nil {
+		t.Error("decode() of a broken body should fail")
+	}
+}
+
+func TestRest(t *testing.T) {
+	tests := []struct {
+		method, path string
+		header       string
+		wantStatus   int
+	}{
+		{"OPTIONS", "/api/v1/traders", "", http.StatusOK},
+		{"GET", "/api/v1/traders", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/traders", "Bearer broken", http.StatusUnauthorized},
+		{"GET", "/api/v1/nothing", "", http.StatusNotFound},
+		{"GET", "/api/v1/openapi.json", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		rest(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%v %v status = %v, want %v", tt.method, tt.path, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	data, err := json.Marshal(openAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := struct {
+		Paths map[string]map[string]struct {
+			Security []interface{}
+		}
+	}{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	for _, rt := range routes {
+		op, ok := spec.Paths[rt.Path][strings.ToLower(rt.Method)]
+		if !ok {
+			t.Errorf("%v %v is not in the spec", rt.Method, rt.Path)
+			continue
+		}
+		if rt.Public != (len(op.Security) == 0) {
+			t.Errorf("%v %v security = %v, public %v", rt.Method, rt.Path, op.Security, rt.Public)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/trader"
+)
+
+// stream pushes the logs and status of a trader to the client as server-sent events
+// GET /api/stream?trader=1&token=xxx, the token can also be passed in the Authorization header
+func stream(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	username := parseToken(token)
+	if username == "" {
+		http.Error(w, constant.ErrAuthorizationError, http.StatusUnauthorized)
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusUnauthorized)
+		return
+	}
+	td, err := self.GetTrader(r.URL.Query().Get("trader"))
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := model.Subscribe(td.ID)
+	defer model.Unsubscribe(td.ID, ch)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	send := func(e model.Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	if err := send(model.Event{TraderID: td.ID, Type: constant.EventStatus, Data: trader.GetTraderStatus(td.ID)}); err != nil {
+		return
+	}
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e := <-ch:
+			if err := send(e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
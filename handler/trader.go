@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
-	"github.com/hprose/hprose-golang/rpc"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
 	"github.com/geniustag/QuantBot/trader"
+	"github.com/hprose/hprose-golang/rpc"
 )
 
 type runner struct{}
@@ -48,6 +50,10 @@ func (runner) Put(req model.Trader, ctx rpc.Context) (resp response) {
 		resp.Message = fmt.Sprint(err)
 		return
 	}
+	if err := trader.ValidateSchedule(req); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
 	db, err := model.NewOrm()
 	if err != nil {
 		resp.Message = fmt.Sprint(err)
@@ -136,3 +142,281 @@ func (runner) Switch(req model.Trader, ctx rpc.Context) (resp response) {
 	resp.Success = true
 	return
 }
+
+// Status
+func (runner) Status(req model.Trader, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = struct {
+		Status int64
+		Panel  trader.Panel
+	}{
+		Status: trader.GetTraderStatus(req.ID),
+		Panel:  trader.GetTraderPanel(req.ID),
+	}
+	resp.Success = true
+	return
+}
+
+// Performance
+func (runner) Performance(req model.Trader, begin, end time.Time, base float64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	perf, err := self.GetPerformance(req.ID, begin, end, base, 100)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = perf
+	resp.Success = true
+	return
+}
+
+// Pin
+func (runner) Pin(req model.Trader, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := self.PinTrader(req.ID, req.Version); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// Store
+func (runner) Store(req model.Trader, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	stores, err := model.ListStore(req.ID)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = stores
+	resp.Success = true
+	return
+}
+
+// StoreSet
+func (runner) StoreSet(req model.Trader, key, value string, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if key == "" || !json.Valid([]byte(value)) {
+		resp.Message = "Key can not be empty and Value must be valid JSON"
+		return
+	}
+	if err := model.SetStore(req.ID, key, value); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// StoreDelete
+func (runner) StoreDelete(req model.Trader, key string, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if key == "" {
+		resp.Message = "Key can not be empty"
+		return
+	}
+	if err := model.DeleteStore(req.ID, key); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// Command
+func (runner) Command(req model.Trader, cmd string, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if cmd == "" {
+		resp.Message = "Command can not be empty"
+		return
+	}
+	if err := trader.Command(req.ID, cmd); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// Executions lists the TWAP, VWAP and Iceberg executions of a trader
+func (runner) Executions(req model.Trader, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = trader.GetExecutions(req.ID)
+	resp.Success = true
+	return
+}
+
+// CancelExecution cancels a running execution of a trader
+func (runner) CancelExecution(req model.Trader, executionID int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := trader.CancelExecution(req.ID, executionID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// ConditionalOrders lists the stop-loss, take-profit and trailing-stop orders of a trader
+func (runner) ConditionalOrders(req model.Trader, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	orders, err := model.ListConditionalOrders(req.ID, "")
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Data = orders
+	resp.Success = true
+	return
+}
+
+// CancelConditionalOrder cancels a waiting conditional order of a trader
+func (runner) CancelConditionalOrder(req model.Trader, orderID int64, ctx rpc.Context) (resp response) {
+	username := ctx.GetString("username")
+	if username == "" {
+		resp.Message = constant.ErrAuthorizationError
+		return
+	}
+	self, err := model.GetUser(username)
+	if err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if req, err = self.GetTrader(req.ID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	if err := trader.CancelConditionalOrder(req.ID, orderID); err != nil {
+		resp.Message = fmt.Sprint(err)
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -1,7 +1,12 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/jinzhu/gorm"
 )
 
 // Algorithm struct
@@ -39,3 +44,109 @@ func (user User) ListAlgorithm(size, page int64, order string) (total int64, alg
 	err = DB.Where("user_id in (?)", userIDs).Order(toUnderScoreCase(order)).Limit(size).Offset((page - 1) * size).Find(&algorithms).Error
 	return
 }
+
+// AlgorithmVersion struct
+type AlgorithmVersion struct {
+	ID          int64     `gorm:"primary_key" json:"id"`
+	AlgorithmID int64     `gorm:"index" json:"algorithmId"`
+	Version     int64     `json:"version"`
+	Script      string    `gorm:"type:text" json:"script,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// GetAlgorithm ...
+func (user User) GetAlgorithm(id interface{}) (algorithm Algorithm, err error) {
+	if err = DB.Where("id = ?", id).First(&algorithm).Error; err != nil {
+		return
+	}
+	_, users, err := user.ListUser(-1, 1, "id")
+	if err != nil {
+		return
+	}
+	for _, u := range users {
+		if u.ID == algorithm.UserID {
+			return
+		}
+	}
+	err = fmt.Errorf(constant.ErrInsufficientPermissions)
+	return
+}
+
+// SaveVersion stores the current script as a new version if it differs from the latest one
+func (algorithm Algorithm) SaveVersion() (version AlgorithmVersion, err error) {
+	err = DB.Where("algorithm_id = ?", algorithm.ID).Order("version desc").First(&version).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return
+	}
+	if err == nil && version.Script == algorithm.Script {
+		return
+	}
+	version = AlgorithmVersion{
+		AlgorithmID: algorithm.ID,
+		Version:     version.Version + 1,
+		Script:      algorithm.Script,
+	}
+	err = DB.Create(&version).Error
+	return
+}
+
+// GetVersion returns the given version of the script, 0 means the latest one
+func (algorithm Algorithm) GetVersion(version int64) (algorithmVersion AlgorithmVersion, err error) {
+	if version <= 0 {
+		return algorithm.SaveVersion()
+	}
+	err = DB.Where("algorithm_id = ? AND version = ?", algorithm.ID, version).First(&algorithmVersion).Error
+	if err == gorm.ErrRecordNotFound {
+		err = fmt.Errorf("Can not found the version %v", version)
+	}
+	return
+}
+
+// ListAlgorithmVersion ...
+func (user User) ListAlgorithmVersion(id int64) (versions []AlgorithmVersion, err error) {
+	algorithm, err := user.GetAlgorithm(id)
+	if err != nil {
+		return
+	}
+	if _, err = algorithm.SaveVersion(); err != nil {
+		return
+	}
+	err = DB.Select("id, algorithm_id, version, created_at").Where("algorithm_id = ?", algorithm.ID).Order("version desc").Find(&versions).Error
+	return
+}
+
+// DiffAlgorithmVersion ...
+func (user User) DiffAlgorithmVersion(id, from, to int64) (diff []string, err error) {
+	algorithm, err := user.GetAlgorithm(id)
+	if err != nil {
+		return
+	}
+	a, err := algorithm.GetVersion(from)
+	if err != nil {
+		return
+	}
+	b, err := algorithm.GetVersion(to)
+	if err != nil {
+		return
+	}
+	diff = diffLines(strings.Split(a.Script, "\n"), strings.Split(b.Script, "\n"))
+	return
+}
+
+// RollbackAlgorithm makes the given version the latest script of the algorithm
+func (user User) RollbackAlgorithm(id, version int64) (err error) {
+	algorithm, err := user.GetAlgorithm(id)
+	if err != nil {
+		return
+	}
+	v, err := algorithm.GetVersion(version)
+	if err != nil {
+		return
+	}
+	algorithm.Script = v.Script
+	if err = DB.Save(&algorithm).Error; err != nil {
+		return
+	}
+	_, err = algorithm.SaveVersion()
+	return
+}
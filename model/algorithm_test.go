@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestRollbackAlgorithm(t *testing.T) {
+	user := admin(t)
+	algorithm := Algorithm{UserID: user.ID, Name: "rollback", Script: "v1"}
+	if err := DB.Create(&algorithm).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, script := range []string{"v1", "v2", "v2", "v3"} {
+		algorithm.Script = script
+		if _, err := algorithm.SaveVersion(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name        string
+		version     int64
+		wantScript  string
+		wantVersion int64
+		wantErr     bool
+	}{
+		{"to the first version", 1, "v1", 4, false},
+		{"to the same script", 1, "v1", 4, false},
+		{"to another version", 2, "v2", 5, false},
+		{"to a missing version", 9, "v2", 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := user.RollbackAlgorithm(algorithm.ID, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RollbackAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, err := user.GetAlgorithm(algorithm.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Script != tt.wantScript {
+				t.Errorf("Script = %q, want %q", got.Script, tt.wantScript)
+			}
+			latest := AlgorithmVersion{}
+			if err := DB.Where("algorithm_id = ?", algorithm.ID).Order("version desc").First(&latest).Error; err != nil {
+				t.Fatal(err)
+			}
+			if latest.Version != tt.wantVersion || latest.Script != tt.wantScript {
+				t.Errorf("latest version = %v %q, want %v %q", latest.Version, latest.Script, tt.wantVersion, tt.wantScript)
+			}
+		})
+	}
+}
+
+func TestRollbackAlgorithmPermission(t *testing.T) {
+	owner := admin(t)
+	algorithm := Algorithm{UserID: owner.ID, Name: "private", Script: "v1"}
+	if err := DB.Create(&algorithm).Error; err != nil {
+		t.Fatal(err)
+	}
+	guest := User{Username: "guest", Password: "guest", Level: 1}
+	if err := DB.Create(&guest).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := guest.RollbackAlgorithm(algorithm.ID, 1); err == nil {
+		t.Error("RollbackAlgorithm() of another user's algorithm should fail")
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"sync"
+)
+
+// Event struct
+type Event struct {
+	TraderID int64       `json:"traderId"`
+	Type     string      `json:"type"` // ["log", "status", "panel"]
+	Data     interface{} `json:"data"`
+}
+
+// broker dispatches the events of traders to their subscribers
+type broker struct {
+	sync.RWMutex
+	subscribers map[int64]map[chan Event]bool
+}
+
+var defaultBroker = broker{subscribers: make(map[int64]map[chan Event]bool)}
+
+// Subscribe returns a channel which receives all the events of the trader, 0 means all the traders
+func Subscribe(traderID int64) chan Event {
+	ch := make(chan Event, 64)
+	defaultBroker.Lock()
+	defer defaultBroker.Unlock()
+	if _, ok := defaultBroker.subscribers[traderID]; !ok {
+		defaultBroker.subscribers[traderID] = make(map[chan Event]bool)
+	}
+	defaultBroker.subscribers[traderID][ch] = true
+	return ch
+}
+
+// Unsubscribe ...
+func Unsubscribe(traderID int64, ch chan Event) {
+	defaultBroker.Lock()
+	defer defaultBroker.Unlock()
+	delete(defaultBroker.subscribers[traderID], ch)
+	if len(defaultBroker.subscribers[traderID]) == 0 {
+		delete(defaultBroker.subscribers, traderID)
+	}
+}
+
+// Publish sends the event to the subscribers of its trader, slow subscribers miss it
+func Publish(e Event) {
+	defaultBroker.RLock()
+	defer defaultBroker.RUnlock()
+	for _, id := range []int64{e.TraderID, 0} {
+		for ch := range defaultBroker.subscribers[id] {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package model
+
+// Candle struct, the K-lines collected from the exchanges or imported from files
+type Candle struct {
+	ID        int64   `gorm:"primary_key;AUTO_INCREMENT" json:"id"`
+	Exchange  string  `gorm:"type:varchar(50);unique_index:idx_candle_series" json:"exchange"` //交易所类型
+	StockType string  `gorm:"type:varchar(20);unique_index:idx_candle_series" json:"stockType"`
+	Period    string  `gorm:"type:varchar(10);unique_index:idx_candle_series" json:"period"`
+	Timestamp int64   `gorm:"unique_index:idx_candle_series" json:"time"` //unix 秒
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+}
+
+// SaveCandles saves the candles of a series which are sorted by time, the candles of the existing time are updated,
+// it returns the amount of the new candles
+func SaveCandles(exchange, stockType, period string, candles []Candle) (created int, err error) {
+	if len(candles) == 0 {
+		return
+	}
+	existing := []Candle{}
+	err = DB.Where("exchange = ? AND stock_type = ? AND period = ? AND timestamp >= ? AND timestamp <= ?",
+		exchange, stockType, period, candles[0].Timestamp, candles[len(candles)-1].Timestamp).Find(&existing).Error
+	if err != nil {
+		return
+	}
+	saved := make(map[int64]Candle)
+	for _, c := range existing {
+		saved[c.Timestamp] = c
+	}
+	db := DB.Begin()
+	for _, c := range candles {
+		c.Exchange, c.StockType, c.Period = exchange, stockType, period
+		old, ok := saved[c.Timestamp]
+		if ok {
+			c.ID = old.ID
+			if c == old {
+				continue
+			}
+			err = db.Save(&c).Error
+		} else {
+			err = db.Create(&c).Error
+			created++
+		}
+		if err != nil {
+			db.Rollback()
+			return 0, err
+		}
+		saved[c.Timestamp] = c
+	}
+	err = db.Commit().Error
+	return
+}
+
+// ListCandles returns the latest candles of a series in [begin, end) which are sorted by time,
+// a zero begin or end means no limit, size <= 0 means no limit
+func ListCandles(exchange, stockType, period string, begin, end int64, size int) (candles []Candle, err error) {
+	db := DB.Where("exchange = ? AND stock_type = ? AND period = ?", exchange, stockType, period)
+	if begin > 0 {
+		db = db.Where("timestamp >= ?", begin)
+	}
+	if end > 0 {
+		db = db.Where("timestamp < ?", end)
+	}
+	if size > 0 {
+		db = db.Limit(size)
+	}
+	if err = db.Order("timestamp desc").Find(&candles).Error; err != nil {
+		return
+	}
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+	return
+}
+
+// LastCandle returns the latest candle of a series
+func LastCandle(exchange, stockType, period string) (candle Candle, err error) {
+	err = DB.Where("exchange = ? AND stock_type = ? AND period = ?", exchange, stockType, period).Order("timestamp desc").First(&candle).Error
+	return
+}
+
+// CandleGaps returns the missing ranges (begin, end) between the stored candles of a series, the time is in seconds
+func CandleGaps(exchange, stockType, period string, seconds int64) (gaps [][2]int64, err error) {
+	timestamps := []int64{}
+	err = DB.Model(&Candle{}).Where("exchange = ? AND stock_type = ? AND period = ?", exchange, stockType, period).
+		Order("timestamp").Pluck("timestamp", &timestamps).Error
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i]-timestamps[i-1] > seconds {
+			gaps = append(gaps, [2]int64{timestamps[i-1] + seconds, timestamps[i]})
+		}
+	}
+	return
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSaveCandles(t *testing.T) {
+	steps := []struct {
+		candles     []Candle
+		wantCreated int
+		wantTotal   int
+	}{
+		{[]Candle{{Timestamp: 60, Close: 1}, {Timestamp: 120, Close: 2}}, 2, 2},
+		{[]Candle{{Timestamp: 120, Close: 3}, {Timestamp: 180, Close: 4}}, 1, 3},
+		{[]Candle{{Timestamp: 180, Close: 4}}, 0, 3},
+	}
+	for i, s := range steps {
+		created, err := SaveCandles("test", "BTC/USDT", "M", s.candles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		candles, _ := ListCandles("test", "BTC/USDT", "M", 0, 0, 0)
+		if created != s.wantCreated || len(candles) != s.wantTotal {
+			t.Errorf("step %v, created %v of %v candles, want %v of %v", i, created, len(candles), s.wantCreated, s.wantTotal)
+		}
+	}
+	if last, _ := LastCandle("test", "BTC/USDT", "M"); last.Timestamp != 180 {
+		t.Errorf("LastCandle() = %v, want 180", last.Timestamp)
+	}
+	if candles, _ := ListCandles("test", "BTC/USDT", "M", 0, 0, 0); candles[1].Close != 3 {
+		t.Errorf("the candle of an existing time is not updated, close = %v", candles[1].Close)
+	}
+}
+
+func TestCandleGaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []int64
+		want       [][2]int64
+	}{
+		{"empty", nil, nil},
+		{"continuous", []int64{60, 120, 180}, nil},
+		{"one gap", []int64{60, 120, 300}, [][2]int64{{180, 300}}},
+		{"two gaps", []int64{60, 180, 240, 600}, [][2]int64{{120, 180}, {300, 600}}},
+	}
+	for i, tt := range tests {
+		stock := string(rune('A'+i)) + "/USDT"
+		candles := []Candle{}
+		for _, ts := range tt.timestamps {
+			candles = append(candles, Candle{Timestamp: ts})
+		}
+		SaveCandles("gaps", stock, "M", candles)
+		got, err := CandleGaps("gaps", stock, "M", 60)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: CandleGaps() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// Channel struct
+type Channel struct {
+	ID        int64      `gorm:"primary_key" json:"id"`
+	UserID    int64      `gorm:"index" json:"userId"`
+	Name      string     `gorm:"type:varchar(50)" json:"name"`
+	Type      string     `gorm:"type:varchar(50)" json:"type"`
+	Config    string     `gorm:"type:text" json:"config"` // JSON, 参考 notify 包中各类型的配置
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `sql:"index" json:"-"`
+}
+
+// ListChannel ...
+func (user User) ListChannel() (channels []Channel, err error) {
+	err = DB.Where("user_id = ?", user.ID).Order("id").Find(&channels).Error
+	return
+}
+
+// GetChannel ...
+func (user User) GetChannel(name string) (channel Channel, err error) {
+	err = DB.Where("user_id = ? AND name = ?", user.ID, name).First(&channel).Error
+	return
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+)
+
+// ConditionalOrder struct, a stop-loss, take-profit or trailing-stop order watched by its trader
+type ConditionalOrder struct {
+	ID        int64     `gorm:"primary_key;AUTO_INCREMENT" json:"id"`
+	TraderID  int64     `gorm:"index" json:"traderId"`
+	Exchange  string    `gorm:"type:varchar(50)" json:"exchange"` //交易所名称
+	Type      string    `gorm:"type:varchar(20)" json:"type"`     //STOP, TAKE_PROFIT 或 TRAILING
+	TradeType string    `gorm:"type:varchar(20)" json:"tradeType"`
+	StockType string    `gorm:"type:varchar(20)" json:"stockType"`
+	Amount    float64   `json:"amount"`
+	StopPrice float64   `json:"stopPrice"`                      //触发价, TRAILING 为当前的止损价
+	Price     float64   `json:"price"`                          //触发后的下单价, 0 为对手价
+	Trailing  float64   `json:"trailing"`                       //TRAILING 的回撤比例
+	Extreme   float64   `json:"extreme"`                        //TRAILING 以来的最高价(卖出)或最低价(买入)
+	OCO       int64     `json:"oco"`                            //关联的条件单, 一个触发后另一个被取消
+	Status    string    `gorm:"type:varchar(20)" json:"status"` //waiting, triggered, canceled, failed
+	OrderID   string    `gorm:"type:varchar(100)" json:"orderId"`
+	Message   string    `gorm:"type:text" json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListConditionalOrders returns the conditional orders of a trader from the newest, an empty status means all
+func ListConditionalOrders(traderID int64, status string) (orders []ConditionalOrder, err error) {
+	db := DB.Where("trader_id = ?", traderID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	err = db.Order("id desc").Find(&orders).Error
+	return
+}
+
+// GetConditionalOrder returns a conditional order of a trader
+func GetConditionalOrder(traderID, id int64) (order ConditionalOrder, err error) {
+	err = DB.Where("trader_id = ? AND id = ?", traderID, id).First(&order).Error
+	return
+}
+
+// SaveConditionalOrder creates or updates a conditional order
+func SaveConditionalOrder(order *ConditionalOrder) error {
+	return DB.Save(order).Error
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Depth struct, an order book sample of the recorder, a keyframe stores the whole book and
+// the others store the levels changed since the previous sample
+type Depth struct {
+	ID        int64  `gorm:"primary_key;AUTO_INCREMENT" json:"id"`
+	Exchange  string `gorm:"type:varchar(50);index:idx_depth_series" json:"exchange"` //交易所类型
+	StockType string `gorm:"type:varchar(20);index:idx_depth_series" json:"stockType"`
+	Timestamp int64  `gorm:"index:idx_depth_series" json:"time"` //unix 毫秒
+	Keyframe  bool   `json:"keyframe"`
+	Data      string `gorm:"type:text" json:"data"`
+}
+
+// SaveDepth saves an order book sample
+func SaveDepth(depth *Depth) error {
+	return DB.Create(depth).Error
+}
+
+// ListDepths returns the samples of a series in [begin, end] which are sorted by time, starting from the
+// last keyframe at or before begin so that the book can be rebuilt, a zero end means no limit,
+// size > 0 limits the samples since begin
+func ListDepths(exchange, stockType string, begin, end int64, size int) (depths []Depth, err error) {
+	db := DB.Where("exchange = ? AND stock_type = ?", exchange, stockType)
+	keyframe := Depth{}
+	err = db.Where("keyframe = ? AND timestamp <= ?", true, begin).Order("timestamp desc").First(&keyframe).Error
+	from := begin
+	if err == nil {
+		from = keyframe.Timestamp
+	} else if err != gorm.ErrRecordNotFound {
+		return
+	}
+	if size > 0 {
+		before := 0
+		if err = db.Model(&Depth{}).Where("timestamp >= ? AND timestamp < ?", from, begin).Count(&before).Error; err != nil {
+			return
+		}
+		db = db.Limit(size + before)
+	}
+	db = db.Where("timestamp >= ?", from)
+	if end > 0 {
+		db = db.Where("timestamp <= ?", end)
+	}
+	err = db.Order("timestamp").Order("id").Find(&depths).Error
+	return
+}
@@ -12,6 +12,7 @@ type Exchange struct {
 	Type      string     `gorm:"type:varchar(50)" json:"type"`
 	AccessKey string     `gorm:"type:varchar(200)" json:"accessKey"`
 	SecretKey string     `gorm:"type:varchar(200)" json:"secretKey"`
+	Config    string     `gorm:"type:text" json:"config"` // JSON, 如 OKEx v3 兼容交易所的 host, passphrase, symbols
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	DeletedAt *time.Time `sql:"index" json:"-"`
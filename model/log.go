@@ -3,10 +3,15 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"reflect"
+	"strings"
 	"time"
 
+	"github.com/geniustag/QuantBot/config"
 	"github.com/geniustag/QuantBot/constant"
+	"github.com/jinzhu/gorm"
+	"github.com/miaolz123/conver"
 )
 
 // Log struct
@@ -24,56 +29,170 @@ type Log struct {
 	Time time.Time `gorm:"-" json:"time"`
 }
 
+// LogFilter struct
+type LogFilter struct {
+	Type         []string
+	ExchangeType []string
+	StockType    []string
+	Begin        time.Time
+	End          time.Time
+	Message      string
+}
+
+// where applies the filter conditions to the query of a trader's logs
+func (f LogFilter) where(id int64) *gorm.DB {
+	db := DB.Model(&Log{}).Where("trader_id = ?", id)
+	if len(f.Type) > 0 {
+		db = db.Where("type in (?)", f.Type)
+	}
+	if len(f.ExchangeType) > 0 {
+		db = db.Where("exchange_type in (?)", f.ExchangeType)
+	}
+	if len(f.StockType) > 0 {
+		db = db.Where("stock_type in (?)", f.StockType)
+	}
+	if !f.Begin.IsZero() {
+		db = db.Where("timestamp >= ?", f.Begin.UnixNano())
+	}
+	if !f.End.IsZero() {
+		db = db.Where("timestamp < ?", f.End.UnixNano())
+	}
+	if f.Message != "" {
+		replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+		db = db.Where("message LIKE ? ESCAPE '!'", "%"+replacer.Replace(f.Message)+"%")
+	}
+	return db
+}
+
 // ListLog ...
-func (user User) ListLog(id, size, page int64) (total int64, logs []Log, err error) {
-	err = DB.Model(&Log{}).Where("trader_id = ?", id).Count(&total).Error
+func (user User) ListLog(id, size, page int64, filter LogFilter) (total int64, logs []Log, err error) {
+	err = filter.where(id).Count(&total).Error
 	if err != nil {
 		return
 	}
 	if size == -1 {
 		size = 1000
 	}
-	err = DB.Where("trader_id = ?", id).Order("timestamp desc, id desc").Limit(size).Offset((page - 1) * size).Find(&logs).Error
+	err = filter.where(id).Order("timestamp desc, id desc").Limit(size).Offset((page - 1) * size).Find(&logs).Error
 	for i, l := range logs {
 		logs[i].Time = time.Unix(0, l.Timestamp)
 	}
 	return
 }
 
+// DeleteLog deletes the logs of a trader which are older than the given time
+func (user User) DeleteLog(id int64, before time.Time) (err error) {
+	return DB.Where("trader_id = ? AND timestamp < ?", id, before.UnixNano()).Delete(&Log{}).Error
+}
+
+// pruneLogs applies the retention of every trader to its logs, the zero retention of a trader uses the config
+func pruneLogs() {
+	days := conver.Int64Must(config.String("logsretentiondays"))
+	rows := conver.Int64Must(config.String("logsmaxrows"))
+	ids := []int64{}
+	if err := DB.Model(&Log{}).Group("trader_id").Pluck("trader_id", &ids).Error; err != nil {
+		log.Println("Prune logs error:", err)
+		return
+	}
+	traders := []Trader{}
+	if err := DB.Unscoped().Where("id in (?)", ids).Find(&traders).Error; err != nil {
+		log.Println("Prune logs error:", err)
+		return
+	}
+	retentions := make(map[int64]Trader)
+	for _, t := range traders {
+		retentions[t.ID] = t
+	}
+	for _, id := range ids {
+		d, r := days, rows
+		if t := retentions[id]; t.LogsRetentionDays != 0 {
+			d = t.LogsRetentionDays
+		}
+		if t := retentions[id]; t.LogsMaxRows != 0 {
+			r = t.LogsMaxRows
+		}
+		if err := pruneTraderLogs(id, d, r); err != nil {
+			log.Println("Prune logs error:", err)
+		}
+	}
+}
+
+// pruneTraderLogs deletes the logs of a trader which are older than days or beyond the latest rows,
+// a value <= 0 means no limit
+func pruneTraderLogs(id, days, rows int64) (err error) {
+	if days > 0 {
+		before := time.Now().AddDate(0, 0, -int(days)).UnixNano()
+		if err = DB.Where("trader_id = ? AND timestamp < ?", id, before).Delete(&Log{}).Error; err != nil {
+			return
+		}
+	}
+	if rows <= 0 {
+		return
+	}
+	last := Log{}
+	err = DB.Where("trader_id = ?", id).Order("timestamp desc, id desc").Offset(rows - 1).Limit(1).Find(&last).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	} else if err != nil {
+		return
+	}
+	return DB.Where("trader_id = ? AND timestamp < ?", id, last.Timestamp).Delete(&Log{}).Error
+}
+
+// Prune prunes the logs every hour, it is started by the server
+func Prune() {
+	for {
+		pruneLogs()
+		time.Sleep(time.Hour)
+	}
+}
+
 // Logger struct
 type Logger struct {
 	TraderID     int64
 	ExchangeType string
+	Sink         func(Log) //不为空时日志同步交给它, 不保存也不推送, 回测使用
 }
 
 // Log ...
 func (l Logger) Log(method string, stockType string, price, amount float64, messages ...interface{}) {
 	now := time.Now().UnixNano()
+	if l.Sink != nil {
+		l.Sink(l.build(now, method, stockType, price, amount, messages))
+		return
+	}
 	go func(now int64) {
-		message := ""
-		for _, m := range messages {
-			if method != constant.ERROR {
-				v := reflect.ValueOf(m)
-				switch v.Kind() {
-				case reflect.Struct, reflect.Map, reflect.Slice:
-					if bs, err := json.Marshal(m); err == nil {
-						message += string(bs)
-						continue
-					}
+		log := l.build(now, method, stockType, price, amount, messages)
+		DB.Create(&log)
+		log.Time = time.Unix(0, now)
+		Publish(Event{TraderID: l.TraderID, Type: constant.EventLog, Data: log})
+	}(now)
+}
+
+// build formats the messages into a log
+func (l Logger) build(now int64, method string, stockType string, price, amount float64, messages []interface{}) Log {
+	message := ""
+	for _, m := range messages {
+		if method != constant.ERROR {
+			v := reflect.ValueOf(m)
+			switch v.Kind() {
+			case reflect.Struct, reflect.Map, reflect.Slice:
+				if bs, err := json.Marshal(m); err == nil {
+					message += string(bs)
+					continue
 				}
 			}
-			message += fmt.Sprintf("%+v", m)
 		}
-		log := Log{
-			TraderID:     l.TraderID,
-			Timestamp:    now,
-			ExchangeType: l.ExchangeType,
-			Type:         method,
-			StockType:    stockType,
-			Price:        price,
-			Amount:       amount,
-			Message:      message,
-		}
-		DB.Create(&log)
-	}(now)
+		message += fmt.Sprintf("%+v", m)
+	}
+	return Log{
+		TraderID:     l.TraderID,
+		Timestamp:    now,
+		ExchangeType: l.ExchangeType,
+		Type:         method,
+		StockType:    stockType,
+		Price:        price,
+		Amount:       amount,
+		Message:      message,
+	}
 }
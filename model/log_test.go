@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneTraderLogs(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		days, rows int64
+		ages       []int // 日志的天数
+		want       int
+	}{
+		{"no limit", 0, 0, []int{0, 1, 5, 30}, 4},
+		{"negative is no limit", -1, -1, []int{0, 1, 5, 30}, 4},
+		{"days", 3, 0, []int{0, 1, 5, 30}, 2},
+		{"rows", 0, 3, []int{0, 1, 5, 30}, 3},
+		{"rows more than logs", 0, 10, []int{0, 1}, 2},
+		{"days and rows", 10, 1, []int{0, 1, 5, 30}, 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := int64(1000 + i)
+			for _, age := range tt.ages {
+				DB.Create(&Log{TraderID: id, Timestamp: now.AddDate(0, 0, -age).UnixNano()})
+			}
+			if err := pruneTraderLogs(id, tt.days, tt.rows); err != nil {
+				t.Fatal(err)
+			}
+			count := 0
+			DB.Model(&Log{}).Where("trader_id = ?", id).Count(&count)
+			if count != tt.want {
+				t.Errorf("%v logs are kept, want %v", count, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneLogsPerTrader(t *testing.T) {
+	custom := Trader{Name: "custom", LogsMaxRows: 2}
+	unlimited := Trader{Name: "unlimited", LogsMaxRows: -1}
+	plain := Trader{Name: "plain"}
+	for _, trader := range []*Trader{&custom, &unlimited, &plain} {
+		if err := DB.Create(trader).Error; err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			DB.Create(&Log{TraderID: trader.ID, Timestamp: int64(i + 1)})
+		}
+	}
+	pruneLogs()
+	tests := []struct {
+		trader Trader
+		want   int
+	}{
+		{custom, 2},
+		{unlimited, 5},
+		{plain, 5}, // config.ini 中 logsMaxRows 为 0
+	}
+	for _, tt := range tests {
+		count := 0
+		DB.Model(&Log{}).Where("trader_id = ?", tt.trader.ID).Count(&count)
+		if count != tt.want {
+			t.Errorf("%v keeps %v logs, want %v", tt.trader.Name, count, tt.want)
+		}
+	}
+}
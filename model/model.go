@@ -5,13 +5,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/geniustag/QuantBot/config"
+	"github.com/geniustag/QuantBot/constant"
 	"github.com/hprose/hprose-golang/io"
 	"github.com/jinzhu/gorm"
 	// for db SQL
 	_ "github.com/jinzhu/gorm/dialects/mysql"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
-	"github.com/geniustag/QuantBot/config"
 )
 
 var (
@@ -25,8 +26,23 @@ func init() {
 	io.Register((*User)(nil), "User", "json")
 	io.Register((*Exchange)(nil), "Exchange", "json")
 	io.Register((*Algorithm)(nil), "Algorithm", "json")
+	io.Register((*AlgorithmVersion)(nil), "AlgorithmVersion", "json")
 	io.Register((*Trader)(nil), "Trader", "json")
 	io.Register((*Log)(nil), "Log", "json")
+	io.Register((*EquityPoint)(nil), "EquityPoint", "json")
+	io.Register((*Performance)(nil), "Performance", "json")
+	io.Register((*Snapshot)(nil), "Snapshot", "json")
+	io.Register((*Portfolio)(nil), "Portfolio", "json")
+	io.Register((*Channel)(nil), "Channel", "json")
+	io.Register((*Store)(nil), "Store", "json")
+	io.Register((*Candle)(nil), "Candle", "json")
+	io.Register((*Depth)(nil), "Depth", "json")
+	io.Register((*ConditionalOrder)(nil), "ConditionalOrder", "json")
+}
+
+// Open connects to the database of config.ini, migrates the tables and creates the admin user if there is
+// no user, it is called by the server only so that importing this package does not touch the database
+func Open() {
 	var err error
 	DB, err = gorm.Open(strings.ToLower(dbType), dbURL)
 	if err != nil {
@@ -38,14 +54,14 @@ func init() {
 			log.Fatalln("Connect to database error:", err)
 		}
 	}
-	DB.AutoMigrate(&User{}, &Exchange{}, &Algorithm{}, &TraderExchange{}, &Trader{}, &Log{})
+	DB.AutoMigrate(&User{}, &Exchange{}, &Algorithm{}, &AlgorithmVersion{}, &TraderExchange{}, &Trader{}, &Log{}, &Snapshot{}, &Channel{}, &Store{}, &Candle{}, &Depth{}, &ConditionalOrder{}, &MarketTrade{})
 	users := []User{}
 	DB.Find(&users)
 	if len(users) == 0 {
 		admin := User{
 			Username: "admin",
 			Password: "admin",
-			Level:    99,
+			Level:    constant.AdminLevel,
 		}
 		if err := DB.Create(&admin).Error; err != nil {
 			log.Fatalln("Create admin error:", err)
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain opens a temporary sqlite database for the tests
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		panic(err)
+	}
+	dbType, dbURL = "sqlite3", filepath.Join(dir, "test.db")
+	Open()
+	code := m.Run()
+	DB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// admin returns the admin user created by Open
+func admin(t *testing.T) User {
+	user, err := GetUser("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+)
+
+// EquityPoint struct
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Performance struct
+type Performance struct {
+	Curve           []EquityPoint `json:"curve"`
+	Profit          float64       `json:"profit"`          //窗口内的收益
+	Return          float64       `json:"return"`          //收益率, 需要提供初始资金
+	MaxDrawdown     float64       `json:"maxDrawdown"`     //最大回撤
+	MaxDrawdownRate float64       `json:"maxDrawdownRate"` //最大回撤率
+	Sharpe          float64       `json:"sharpe"`          //按日收益年化的夏普比率
+	Sortino         float64       `json:"sortino"`         //按日收益年化的索提诺比率
+	WinRate         float64       `json:"winRate"`         //盈利的 LogProfit 次数占比
+	TradeCount      int64         `json:"tradeCount"`      //交易日志的数量
+}
+
+// GetPerformance builds the equity curve of a trader from its PROFIT logs and computes the statistics,
+// base is the initial capital added to every profit, size is the max amount of the returned curve points
+func (user User) GetPerformance(id int64, begin, end time.Time, base float64, size int) (perf Performance, err error) {
+	filter := LogFilter{Type: []string{constant.PROFIT}, Begin: begin, End: end}
+	logs := []Log{}
+	if err = filter.where(id).Order("timestamp, id").Find(&logs).Error; err != nil {
+		return
+	}
+	points := []EquityPoint{}
+	for _, l := range logs {
+		points = append(points, EquityPoint{Time: time.Unix(0, l.Timestamp), Equity: base + l.Amount})
+	}
+	perf = CalcPerformance(points, base)
+	filter.Type = []string{constant.BUY, constant.SELL, constant.LONG, constant.SHORT, constant.LONGCLOSE, constant.SHORTCLOSE}
+	if err = filter.where(id).Count(&perf.TradeCount).Error; err != nil {
+		return
+	}
+	if size > 0 && len(perf.Curve) > size {
+		curve := []EquityPoint{}
+		for i := 1; i <= size; i++ {
+			curve = append(curve, perf.Curve[i*len(perf.Curve)/size-1])
+		}
+		perf.Curve = curve
+	}
+	return
+}
+
+// CalcPerformance computes the statistics of an equity curve which is sorted by time
+func CalcPerformance(points []EquityPoint, base float64) (perf Performance) {
+	perf.Curve = points
+	if len(points) == 0 {
+		return
+	}
+	first, last := points[0].Equity, points[len(points)-1].Equity
+	if base > 0 {
+		first = base
+	}
+	perf.Profit = last - first
+	if first > 0 {
+		perf.Return = perf.Profit / first
+	}
+	peak := first
+	wins, rounds := 0, 0
+	for i, p := range points {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if dd := peak - p.Equity; dd > perf.MaxDrawdown {
+			perf.MaxDrawdown = dd
+			if peak > 0 {
+				perf.MaxDrawdownRate = dd / peak
+			}
+		}
+		if i > 0 {
+			rounds++
+			if p.Equity > points[i-1].Equity {
+				wins++
+			}
+		}
+	}
+	if rounds > 0 {
+		perf.WinRate = float64(wins) / float64(rounds)
+	}
+	perf.Sharpe, perf.Sortino = ratios(dailyReturns(points, base > 0))
+	return
+}
+
+// dailyReturns returns the change of the last equity of every day,
+// the change is a rate when relative is true, otherwise it is the raw profit
+func dailyReturns(points []EquityPoint, relative bool) (returns []float64) {
+	days := []float64{}
+	lastDay := ""
+	for _, p := range points {
+		day := p.Time.Format("2006-01-02")
+		if day == lastDay {
+			days[len(days)-1] = p.Equity
+			continue
+		}
+		days = append(days, p.Equity)
+		lastDay = day
+	}
+	for i := 1; i < len(days); i++ {
+		if !relative {
+			returns = append(returns, days[i]-days[i-1])
+		} else if days[i-1] > 0 {
+			returns = append(returns, days[i]/days[i-1]-1)
+		}
+	}
+	return
+}
+
+// ratios returns the annualized sharpe and sortino ratios of the daily returns
+func ratios(returns []float64) (sharpe, sortino float64) {
+	if len(returns) < 2 {
+		return
+	}
+	mean, variance, downside := 0.0, 0.0, 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	annual := math.Sqrt(365)
+	if std := math.Sqrt(variance / float64(len(returns)-1)); std > 0 {
+		sharpe = mean / std * annual
+	}
+	if dev := math.Sqrt(downside / float64(len(returns))); dev > 0 {
+		sortino = mean / dev * annual
+	}
+	return
+}
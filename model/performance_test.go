@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalcPerformance(t *testing.T) {
+	day := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	curve := func(equities ...float64) (points []EquityPoint) {
+		for i, e := range equities {
+			points = append(points, EquityPoint{Time: day.AddDate(0, 0, i), Equity: e})
+		}
+		return
+	}
+	tests := []struct {
+		name   string
+		points []EquityPoint
+		base   float64
+		want   Performance
+	}{
+		{"empty", nil, 100, Performance{}},
+		{"single point", curve(120), 100, Performance{Profit: 20, Return: 0.2}},
+		{"with base", curve(100, 110, 99, 121), 100, Performance{
+			Profit: 21, Return: 0.21, MaxDrawdown: 11, MaxDrawdownRate: 0.1,
+			Sharpe: 8.699820940459645, Sortino: 24.51169201151749, WinRate: 2.0 / 3,
+		}},
+		{"without base", curve(100, 110, 99, 121), 0, Performance{
+			Profit: 21, Return: 0.21, MaxDrawdown: 11, MaxDrawdownRate: 0.1,
+			Sharpe: 8.00649378019678, Sortino: 21.057771773530924, WinRate: 2.0 / 3,
+		}},
+		{"base below the first point", curve(110, 120), 100, Performance{
+			Profit: 20, Return: 0.2, WinRate: 1,
+		}},
+		{"no loss has no sortino", curve(100, 101, 103, 104), 100, Performance{
+			Profit: 4, Return: 0.04, WinRate: 1, Sharpe: 43.796796695011416,
+		}},
+		{"last equity of a day", []EquityPoint{
+			{Time: day, Equity: 100},
+			{Time: day.Add(12 * time.Hour), Equity: 90},
+			{Time: day.AddDate(0, 0, 1), Equity: 95},
+		}, 100, Performance{
+			Profit: -5, Return: -0.05, MaxDrawdown: 10, MaxDrawdownRate: 0.1, WinRate: 0.5,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalcPerformance(tt.points, tt.base)
+			check := func(field string, got, want float64) {
+				if math.Abs(got-want) > 1e-9 {
+					t.Errorf("%v = %v, want %v", field, got, want)
+				}
+			}
+			check("Profit", got.Profit, tt.want.Profit)
+			check("Return", got.Return, tt.want.Return)
+			check("MaxDrawdown", got.MaxDrawdown, tt.want.MaxDrawdown)
+			check("MaxDrawdownRate", got.MaxDrawdownRate, tt.want.MaxDrawdownRate)
+			check("Sharpe", got.Sharpe, tt.want.Sharpe)
+			check("Sortino", got.Sortino, tt.want.Sortino)
+			check("WinRate", got.WinRate, tt.want.WinRate)
+			if len(got.Curve) != len(tt.points) {
+				t.Errorf("len(Curve) = %v, want %v", len(got.Curve), len(tt.points))
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+)
+
+// Snapshot struct
+type Snapshot struct {
+	ID         int64   `gorm:"primary_key;AUTO_INCREMENT" json:"id"`
+	UserID     int64   `gorm:"index" json:"userId"`
+	ExchangeID int64   `gorm:"index" json:"exchangeId"`
+	Timestamp  int64   `gorm:"index" json:"-"`
+	Currency   string  `gorm:"type:varchar(20)" json:"currency"`
+	Quote      string  `gorm:"type:varchar(20)" json:"quote"`
+	Amount     float64 `json:"amount"` //包括冻结的数量
+	Price      float64 `json:"price"`  //以 Quote 计价的价格, 0 表示无法估值
+	Value      float64 `json:"value"`
+
+	Time time.Time `gorm:"-" json:"time"`
+}
+
+// Portfolio struct
+type Portfolio struct {
+	Time      time.Time  `json:"time"`
+	Value     float64    `json:"value"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// ListPortfolio returns the snapshots of all the exchanges of the user grouped by time
+func (user User) ListPortfolio(begin, end time.Time) (portfolios []Portfolio, err error) {
+	db := DB.Where("user_id = ?", user.ID)
+	if !begin.IsZero() {
+		db = db.Where("timestamp >= ?", begin.UnixNano())
+	}
+	if !end.IsZero() {
+		db = db.Where("timestamp < ?", end.UnixNano())
+	}
+	snapshots := []Snapshot{}
+	if err = db.Order("timestamp, id").Find(&snapshots).Error; err != nil {
+		return
+	}
+	for _, s := range snapshots {
+		s.Time = time.Unix(0, s.Timestamp)
+		if len(portfolios) == 0 || !portfolios[len(portfolios)-1].Time.Equal(s.Time) {
+			portfolios = append(portfolios, Portfolio{Time: s.Time})
+		}
+		p := &portfolios[len(portfolios)-1]
+		p.Value += s.Value
+		p.Snapshots = append(p.Snapshots, s)
+	}
+	return
+}
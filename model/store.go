@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Store struct
+type Store struct {
+	ID        int64     `gorm:"primary_key" json:"id"`
+	TraderID  int64     `gorm:"unique_index:idx_store_trader_key" json:"traderId"`
+	Key       string    `gorm:"type:varchar(200);unique_index:idx_store_trader_key" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"` // JSON
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListStore ...
+func ListStore(traderID int64) (stores []Store, err error) {
+	err = DB.Where("trader_id = ?", traderID).Order("id").Find(&stores).Error
+	return
+}
+
+// errEmptyKey is returned for an empty key, which would match all the keys of the trader in a struct condition
+var errEmptyKey = fmt.Errorf("Key can not be empty")
+
+// storeKey is the condition of the key, a map condition keeps the zero values and quotes the reserved column key
+func storeKey(traderID int64, key string) map[string]interface{} {
+	return map[string]interface{}{"trader_id": traderID, "key": key}
+}
+
+// GetStore ...
+func GetStore(traderID int64, key string) (store Store, err error) {
+	if key == "" {
+		return store, errEmptyKey
+	}
+	err = DB.Where(storeKey(traderID, key)).First(&store).Error
+	return
+}
+
+// SetStore creates or updates the value of the key
+func SetStore(traderID int64, key, value string) (err error) {
+	store, err := GetStore(traderID, key)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return
+	}
+	store.TraderID = traderID
+	store.Key = key
+	store.Value = value
+	return DB.Save(&store).Error
+}
+
+// DeleteStore ...
+func DeleteStore(traderID int64, key string) (err error) {
+	if key == "" {
+		return errEmptyKey
+	}
+	return DB.Where(storeKey(traderID, key)).Delete(&Store{}).Error
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	const id = 2000
+	for _, key := range []string{"a", "b", "c"} {
+		if err := SetStore(id, key, `"`+key+`"`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetStore(id, "a", `1`); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		key       string
+		wantValue string
+		wantErr   bool
+	}{
+		{"updated", "a", `1`, false},
+		{"created", "b", `"b"`, false},
+		{"missing", "d", "", true},
+		{"empty key does not match the first key", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := GetStore(id, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetStore(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if store.Value != tt.wantValue {
+				t.Errorf("GetStore(%q) = %v, want %v", tt.key, store.Value, tt.wantValue)
+			}
+		})
+	}
+	if err := SetStore(id, "", `1`); err == nil {
+		t.Error("SetStore() with an empty key should fail")
+	}
+}
+
+func TestDeleteStore(t *testing.T) {
+	const id = 2001
+	for _, key := range []string{"a", "b", "c"} {
+		SetStore(id, key, `0`)
+	}
+	SetStore(id+1, "a", `0`)
+	tests := []struct {
+		key      string
+		wantErr  bool
+		wantKeys int
+	}{
+		{"", true, 3},
+		{"missing", false, 3},
+		{"a", false, 2},
+	}
+	for _, tt := range tests {
+		if err := DeleteStore(id, tt.key); (err != nil) != tt.wantErr {
+			t.Errorf("DeleteStore(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+		if stores, _ := ListStore(id); len(stores) != tt.wantKeys {
+			t.Errorf("after DeleteStore(%q), %v keys are kept, want %v", tt.key, len(stores), tt.wantKeys)
+		}
+	}
+	if stores, _ := ListStore(id + 1); len(stores) != 1 {
+		t.Error("DeleteStore() should not delete the keys of the other traders")
+	}
+}
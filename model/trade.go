@@ -0,0 +1,71 @@
+package model
+
+// MarketTrade struct, the public trades of a market collected from the exchanges
+type MarketTrade struct {
+	ID        int64   `gorm:"primary_key;AUTO_INCREMENT" json:"id"`
+	Exchange  string  `gorm:"type:varchar(50);unique_index:idx_market_trade" json:"exchange"` //交易所类型
+	StockType string  `gorm:"type:varchar(20);unique_index:idx_market_trade;index:idx_market_trade_time" json:"stockType"`
+	TradeID   string  `gorm:"type:varchar(50);unique_index:idx_market_trade" json:"tradeId"` //交易所的成交编号
+	Timestamp int64   `gorm:"index:idx_market_trade_time" json:"time"`                       //unix 毫秒
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+	Type      string  `gorm:"type:varchar(10)" json:"type"` //主动成交的方向, BUY 或 SELL
+}
+
+// SaveMarketTrades saves the public trades of a market, the trades which are saved already are skipped,
+// it returns the amount of the new trades
+func SaveMarketTrades(exchange, stockType string, trades []MarketTrade) (created int, err error) {
+	if len(trades) == 0 {
+		return
+	}
+	ids := []string{}
+	for _, t := range trades {
+		ids = append(ids, t.TradeID)
+	}
+	saved := []string{}
+	err = DB.Model(&MarketTrade{}).Where("exchange = ? AND stock_type = ? AND trade_id in (?)", exchange, stockType, ids).Pluck("trade_id", &saved).Error
+	if err != nil {
+		return
+	}
+	skip := make(map[string]bool)
+	for _, id := range saved {
+		skip[id] = true
+	}
+	db := DB.Begin()
+	for _, t := range trades {
+		if skip[t.TradeID] {
+			continue
+		}
+		t.ID, t.Exchange, t.StockType = 0, exchange, stockType
+		if err = db.Create(&t).Error; err != nil {
+			db.Rollback()
+			return 0, err
+		}
+		skip[t.TradeID] = true
+		created++
+	}
+	err = db.Commit().Error
+	return
+}
+
+// ListMarketTrades returns the latest public trades of a market in [begin, end) which are sorted by time,
+// the time is in milliseconds, a zero begin or end means no limit, size <= 0 means no limit
+func ListMarketTrades(exchange, stockType string, begin, end int64, size int) (trades []MarketTrade, err error) {
+	db := DB.Where("exchange = ? AND stock_type = ?", exchange, stockType)
+	if begin > 0 {
+		db = db.Where("timestamp >= ?", begin)
+	}
+	if end > 0 {
+		db = db.Where("timestamp < ?", end)
+	}
+	if size > 0 {
+		db = db.Limit(size)
+	}
+	if err = db.Order("timestamp desc").Order("id desc").Find(&trades).Error; err != nil {
+		return
+	}
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+	return
+}
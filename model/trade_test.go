@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestSaveMarketTrades(t *testing.T) {
+	steps := []struct {
+		trades      []MarketTrade
+		wantCreated int
+		wantTotal   int
+	}{
+		{[]MarketTrade{{TradeID: "1", Timestamp: 1000}, {TradeID: "2", Timestamp: 2000}}, 2, 2},
+		{[]MarketTrade{{TradeID: "2", Timestamp: 2000}, {TradeID: "3", Timestamp: 3000}}, 1, 3},
+		{[]MarketTrade{{TradeID: "4", Timestamp: 4000}, {TradeID: "4", Timestamp: 4000}}, 1, 4},
+		{nil, 0, 4},
+	}
+	for i, s := range steps {
+		created, err := SaveMarketTrades("test", "BTC/USDT", s.trades)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trades, _ := ListMarketTrades("test", "BTC/USDT", 0, 0, 0)
+		if created != s.wantCreated || len(trades) != s.wantTotal {
+			t.Errorf("step %v, created %v of %v trades, want %v of %v", i, created, len(trades), s.wantCreated, s.wantTotal)
+		}
+	}
+	// 同一个成交编号在其它交易对中不算重复
+	if created, _ := SaveMarketTrades("test", "ETH/USDT", []MarketTrade{{TradeID: "1"}}); created != 1 {
+		t.Errorf("created %v trades of another stock type, want 1", created)
+	}
+	tests := []struct {
+		begin, end int64
+		size       int
+		want       []string
+	}{
+		{0, 0, 0, []string{"1", "2", "3", "4"}},
+		{2000, 0, 0, []string{"2", "3", "4"}},
+		{0, 3000, 0, []string{"1", "2"}},
+		{0, 0, 2, []string{"3", "4"}},
+	}
+	for _, tt := range tests {
+		trades, err := ListMarketTrades("test", "BTC/USDT", tt.begin, tt.end, tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, trade := range trades {
+			ids = append(ids, trade.TradeID)
+		}
+		if len(ids) != len(tt.want) || (len(ids) > 0 && (ids[0] != tt.want[0] || ids[len(ids)-1] != tt.want[len(tt.want)-1])) {
+			t.Errorf("ListMarketTrades(%v, %v, %v) = %v, want %v", tt.begin, tt.end, tt.size, ids, tt.want)
+		}
+	}
+}
@@ -14,11 +14,20 @@ type Trader struct {
 	AlgorithmID int64      `gorm:"index" json:"algorithmId"`
 	Name        string     `gorm:"type:varchar(200)" json:"name"`
 	Environment string     `gorm:"type:text" json:"environment"`
+	Version     int64      `json:"version"` //固定使用的策略版本, 0 表示总是使用最新版本
 	LastRunAt   time.Time  `json:"lastRunAt"`
+	LastVersion int64      `json:"lastVersion"`                        //最近一次运行时使用的策略版本
+	NotifyTo    string     `gorm:"type:varchar(50)" json:"notifyTo"`   //自动通知使用的通知渠道名称
+	NotifyOn    string     `gorm:"type:varchar(200)" json:"notifyOn"`  //自动通知的事件, 逗号分隔, 如 "ERROR,BUY,SELL,STOP"
+	StartCron   string     `gorm:"type:varchar(100)" json:"startCron"` //定时启动的 cron 表达式
+	StopCron    string     `gorm:"type:varchar(100)" json:"stopCron"`  //定时停止的 cron 表达式
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 	DeletedAt   *time.Time `sql:"index" json:"-"`
 
+	LogsRetentionDays int64 `json:"logsRetentionDays"` //日志保留的天数, 0 使用 config.ini 的 logsRetentionDays, 负数表示永久保留
+	LogsMaxRows       int64 `json:"logsMaxRows"`       //最多保留的日志数量, 0 使用 config.ini 的 logsMaxRows, 负数表示不限制
+
 	Exchanges []Exchange `gorm:"-" json:"exchanges"`
 	Status    int64      `gorm:"-" json:"status"`
 	Algorithm Algorithm  `gorm:"-" json:"algorithm"`
@@ -56,6 +65,7 @@ func (user User) GetTrader(id interface{}) (trader Trader, err error) {
 	}
 	if user.Level < self.Level || user.ID != self.ID {
 		err = fmt.Errorf(constant.ErrInsufficientPermissions)
+		return
 	}
 	if trader.AlgorithmID > 0 {
 		if err = DB.Where("id = ?", trader.AlgorithmID).First(&trader.Algorithm).Error; err != nil {
@@ -98,6 +108,12 @@ func (user User) UpdateTrader(req Trader) (err error) {
 	}
 	runner.Name = req.Name
 	runner.Environment = req.Environment
+	runner.NotifyTo = req.NotifyTo
+	runner.NotifyOn = req.NotifyOn
+	runner.StartCron = req.StartCron
+	runner.StopCron = req.StopCron
+	runner.LogsRetentionDays = req.LogsRetentionDays
+	runner.LogsMaxRows = req.LogsMaxRows
 	rs, err := user.GetTraderExchanges(runner.ID)
 	if err != nil {
 		db.Rollback()
@@ -143,3 +159,17 @@ func (user User) UpdateTrader(req Trader) (err error) {
 	}
 	return
 }
+
+// PinTrader pins the trader to the given algorithm version, 0 means the latest one
+func (user User) PinTrader(id, version int64) (err error) {
+	trader, err := user.GetTrader(id)
+	if err != nil {
+		return
+	}
+	if version > 0 {
+		if _, err = trader.Algorithm.GetVersion(version); err != nil {
+			return
+		}
+	}
+	return DB.Model(&trader).UpdateColumn("version", version).Error
+}
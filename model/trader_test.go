@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestGetTraderPermission(t *testing.T) {
+	owner := admin(t)
+	other := User{Username: "other", Password: "other", Level: 99}
+	if err := DB.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	trader := Trader{UserID: owner.ID, Name: "owned"}
+	if err := DB.Create(&trader).Error; err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		user    User
+		id      int64
+		wantErr bool
+	}{
+		{"owner", owner, trader.ID, false},
+		{"another user", other, trader.ID, true},
+		{"missing trader", owner, trader.ID + 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.user.GetTrader(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTrader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.ID != tt.id {
+				t.Errorf("GetTrader() = %v, want %v", got.ID, tt.id)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{"equal", "a\nb", "a\nb", []string{" a", " b"}},
+		{"empty", "", "", []string{" "}},
+		{"add", "a\nc", "a\nb\nc", []string{" a", "+b", " c"}},
+		{"remove", "a\nb\nc", "a\nc", []string{" a", "-b", " c"}},
+		{"change", "a\nb\nc", "a\nx\nc", []string{" a", "-b", "+x", " c"}},
+		{"append", "a", "a\nb", []string{" a", "+b"}},
+		{"prepend", "b", "a\nb", []string{"+a", " b"}},
+		{"replace all", "a\nb", "c\nd", []string{"-a", "-b", "+c", "+d"}},
+		{"keep common middle", "a\nx\nb\ny\nc", "a\nb\nc", []string{" a", "-x", " b", "-y", " c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffLines(strings.Split(tt.a, "\n"), strings.Split(tt.b, "\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToUnderScoreCase(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"id", "id"},
+		{"createdAt", "created_at"},
+		{"UserID", "user_id"},
+		{"LastRunAt", "last_run_at"},
+	}
+	for _, tt := range tests {
+		if got := toUnderScoreCase(tt.in); got != tt.want {
+			t.Errorf("toUnderScoreCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// email sends the message by SMTP, port 465 (implicit TLS) is not supported, use 25 or 587
+type email struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	To       string `json:"to"` // 多个收件人用逗号分隔
+}
+
+// Send ...
+func (e *email) Send(title, message string) error {
+	to := []string{}
+	for _, t := range strings.Split(e.To, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			to = append(to, t)
+		}
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("Empty email receivers")
+	}
+	port := e.Port
+	if port == 0 {
+		port = 25
+	}
+	from := e.From
+	if from == "" {
+		from = e.Username
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%v:%v", e.Host, port), auth, from, to, mail(from, to, title, message))
+}
+
+// mail builds the mail, the subject is encoded by RFC 2047 so that it can be non-ASCII
+func mail(from string, to []string, title, message string) []byte {
+	return []byte(fmt.Sprintf("From: %v\r\nTo: %v\r\nSubject: %v\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%v\r\n",
+		from, strings.Join(to, ","), mime.QEncoding.Encode("UTF-8", title), message))
+}
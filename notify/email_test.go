@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"mime"
+	"strings"
+	"testing"
+)
+
+func TestMail(t *testing.T) {
+	tests := []struct {
+		name, title, subject string
+	}{
+		{"ascii", "[QuantBot] bot1", "Subject: [QuantBot] bot1\r\n"},
+		{"non-ascii", "[QuantBot] 机器人", "Subject: =?UTF-8?q?[QuantBot]_=E6=9C=BA=E5=99=A8=E4=BA=BA?=\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := string(mail("bot@example.com", []string{"a@example.com", "b@example.com"}, tt.title, "hello"))
+			if !strings.Contains(msg, tt.subject) {
+				t.Errorf("mail() = %q, want the subject %q", msg, tt.subject)
+			}
+			if !strings.Contains(msg, "To: a@example.com,b@example.com\r\n") || !strings.HasSuffix(msg, "\r\n\r\nhello\r\n") {
+				t.Errorf("mail() = %q", msg)
+			}
+			header := strings.TrimSuffix(strings.TrimPrefix(tt.subject, "Subject: "), "\r\n")
+			if decoded, err := new(mime.WordDecoder).DecodeHeader(header); err != nil || decoded != tt.title {
+				t.Errorf("decoded subject = %q, %v, want %q", decoded, err, tt.title)
+			}
+		})
+	}
+}
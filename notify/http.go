@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// webhook posts {"title": "", "message": ""} to the url
+type webhook struct {
+	URL string `json:"url"`
+}
+
+// Send ...
+func (w *webhook) Send(title, message string) error {
+	return postJSON(w.URL, map[string]string{"title": title, "message": message})
+}
+
+// telegram sends the message by the sendMessage method of a bot
+type telegram struct {
+	API    string `json:"api"` // 默认为 https://api.telegram.org
+	Token  string `json:"token"`
+	ChatID string `json:"chatId"`
+}
+
+// Send ...
+func (t *telegram) Send(title, message string) error {
+	url := fmt.Sprintf("%v/bot%v/sendMessage", strings.TrimSuffix(t.API, "/"), t.Token)
+	return postJSON(url, map[string]string{"chat_id": t.ChatID, "text": title + "\n" + message})
+}
+
+// robot is the group robot webhook of DingTalk or WeCom, they share the same text message format
+type robot struct {
+	URL string `json:"url"`
+}
+
+// Send ...
+func (r *robot) Send(title, message string) error {
+	return postJSON(r.URL, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": title + "\n" + message},
+	})
+}
+
+func postJSON(url string, data interface{}) (err error) {
+	if url == "" {
+		return fmt.Errorf("Empty notify url")
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("[POST %s] HTTP Error Info: %v", url, err)
+	}
+	defer resp.Body.Close()
+	ret, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("[POST %s] HTTP Status: %d, Info: %s", url, resp.StatusCode, ret)
+	}
+	result := struct {
+		OK      *bool  `json:"ok"`
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	if json.Unmarshal(ret, &result) == nil {
+		if result.OK != nil && !*result.OK {
+			return fmt.Errorf("[POST %s] Error Info: %s", url, ret)
+		}
+		if result.ErrCode != 0 {
+			return fmt.Errorf("[POST %s] Error Info: %v %v", url, result.ErrCode, result.ErrMsg)
+		}
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPSinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		sink     func(url string) Sink
+		path     string
+		response string
+		status   int
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "webhook",
+			sink:     func(url string) Sink { return &webhook{URL: url + "/hook"} },
+			path:     "/hook",
+			response: "ok",
+			want:     map[string]interface{}{"title": "T", "message": "M"},
+		},
+		{
+			name:   "webhook status error",
+			sink:   func(url string) Sink { return &webhook{URL: url + "/hook"} },
+			path:   "/hook",
+			status: http.StatusInternalServerError,
+			want:   map[string]interface{}{"title": "T", "message": "M"}, wantErr: true,
+		},
+		{
+			name:     "telegram",
+			sink:     func(url string) Sink { return &telegram{API: url + "/", Token: "123:abc", ChatID: "42"} },
+			path:     "/bot123:abc/sendMessage",
+			response: `{"ok": true, "result": {}}`,
+			want:     map[string]interface{}{"chat_id": "42", "text": "T\nM"},
+		},
+		{
+			name:     "telegram error",
+			sink:     func(url string) Sink { return &telegram{API: url, Token: "123:abc", ChatID: "42"} },
+			path:     "/bot123:abc/sendMessage",
+			response: `{"ok": false, "description": "chat not found"}`,
+			want:     map[string]interface{}{"chat_id": "42", "text": "T\nM"}, wantErr: true,
+		},
+		{
+			name:     "robot",
+			sink:     func(url string) Sink { return &robot{URL: url + "/robot/send?access_token=x"} },
+			path:     "/robot/send",
+			response: `{"errcode": 0, "errmsg": "ok"}`,
+			want:     map[string]interface{}{"msgtype": "text", "text": map[string]interface{}{"content": "T\nM"}},
+		},
+		{
+			name:     "robot error",
+			sink:     func(url string) Sink { return &robot{URL: url + "/robot/send"} },
+			path:     "/robot/send",
+			response: `{"errcode": 310000, "errmsg": "keywords not in content"}`,
+			want:     map[string]interface{}{"msgtype": "text", "text": map[string]interface{}{"content": "T\nM"}}, wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != tt.path || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("request = %v %v %v, want POST %v", r.Method, r.URL.Path, r.Header.Get("Content-Type"), tt.path)
+				}
+				body, _ := ioutil.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("body %s: %v", body, err)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+			err := tt.sink(server.URL).Send("T", "M")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyURL(t *testing.T) {
+	for _, sink := range []Sink{&webhook{}, &robot{}} {
+		if err := sink.Send("T", "M"); err == nil {
+			t.Errorf("%T.Send() with an empty url should fail", sink)
+		}
+	}
+}
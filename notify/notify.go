@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// Sink sends messages to a notification channel
+type Sink interface {
+	Send(title, message string) error
+}
+
+// New creates the sink of a channel
+func New(channel model.Channel) (sink Sink, err error) {
+	switch channel.Type {
+	case constant.NotifyWebhook:
+		s := &webhook{}
+		err = json.Unmarshal([]byte(channel.Config), s)
+		sink = s
+	case constant.NotifyEmail:
+		s := &email{}
+		err = json.Unmarshal([]byte(channel.Config), s)
+		sink = s
+	case constant.NotifyTelegram:
+		s := &telegram{API: "https://api.telegram.org"}
+		err = json.Unmarshal([]byte(channel.Config), s)
+		sink = s
+	case constant.NotifyDingTalk, constant.NotifyWeCom:
+		s := &robot{}
+		err = json.Unmarshal([]byte(channel.Config), s)
+		sink = s
+	default:
+		err = fmt.Errorf("Unrecognized notify channel type: %v", channel.Type)
+	}
+	return
+}
+
+// Send sends the message to the named channel of the user
+func Send(userID int64, name, title, message string) (err error) {
+	user, err := model.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+	channel, err := user.GetChannel(name)
+	if err != nil {
+		return fmt.Errorf("Can not found the notify channel %v", name)
+	}
+	sink, err := New(channel)
+	if err != nil {
+		return
+	}
+	return sink.Send(title, message)
+}
+
+const (
+	notifyWorkers  = 4                // 发送通知的协程数
+	notifyQueue    = 256              // 等待发送的事件数, 队列满时丢弃新事件
+	notifyInterval = 10 * time.Second // 同一渠道两次通知的最小间隔
+	notifyRepeat   = 5 * time.Minute  // 同一渠道重复消息的合并时间
+)
+
+// notification is an event waiting to be sent
+type notification struct {
+	event model.Event
+	on    string
+}
+
+// Run sends the notifications of the events which match the rules of their traders
+func Run() {
+	queue := make(chan notification, notifyQueue)
+	t := newThrottle(notifyInterval, notifyRepeat)
+	for i := 0; i < notifyWorkers; i++ {
+		go func() {
+			for n := range queue {
+				notify(t, n.event, n.on)
+			}
+		}()
+	}
+	events := model.Subscribe(0)
+	for e := range events {
+		on := ""
+		switch e.Type {
+		case constant.EventLog:
+			if l, ok := e.Data.(model.Log); ok && l.Type != constant.INFO {
+				on = l.Type
+			}
+		case constant.EventStatus:
+			if status, ok := e.Data.(int64); ok && status == 0 {
+				on = constant.NotifyOnStop
+			}
+		}
+		if on == "" {
+			continue
+		}
+		select {
+		case queue <- notification{event: e, on: on}:
+		default:
+			log.Printf("Notify trader %v error: the queue is full\n", e.TraderID)
+		}
+	}
+}
+
+// notify checks the rules of the trader and sends the event
+func notify(t *throttle, e model.Event, on string) {
+	trader := model.Trader{}
+	if err := model.DB.First(&trader, e.TraderID).Error; err != nil || trader.NotifyTo == "" {
+		return
+	}
+	matched := false
+	for _, r := range strings.Split(trader.NotifyOn, ",") {
+		if strings.EqualFold(strings.TrimSpace(r), on) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return
+	}
+	title := fmt.Sprintf("[%v] %v %v", constant.Banner, trader.Name, on)
+	message := "The trader has stopped"
+	if l, ok := e.Data.(model.Log); ok {
+		message = fmt.Sprintf("%v %v %v %v %v", l.ExchangeType, l.StockType, l.Price, l.Amount, l.Message)
+	}
+	message, ok := t.allow(fmt.Sprint(trader.UserID, "/", trader.NotifyTo), title+"\n"+message, message, time.Now())
+	if !ok {
+		return
+	}
+	if err := Send(trader.UserID, trader.NotifyTo, title, message); err != nil {
+		log.Printf("Notify trader %v error: %v\n", trader.ID, err)
+	}
+}
+
+// throttle limits the notifications of every channel and merges the repeated ones
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	repeat   time.Duration
+	channels map[string]*sent
+}
+
+// sent is the last notification of a channel
+type sent struct {
+	at         time.Time
+	content    string
+	suppressed int // 上次发送后被丢弃的通知数
+}
+
+func newThrottle(interval, repeat time.Duration) *throttle {
+	return &throttle{interval: interval, repeat: repeat, channels: make(map[string]*sent)}
+}
+
+// allow reports whether the content can be sent to the channel now,
+// the returned message tells how many notifications are suppressed since the last one
+func (t *throttle) allow(channel, content, message string, now time.Time) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.channels[channel]
+	if !ok {
+		t.channels[channel] = &sent{at: now, content: content}
+		return message, true
+	}
+	if now.Sub(s.at) < t.interval || (content == s.content && now.Sub(s.at) < t.repeat) {
+		s.suppressed++
+		return message, false
+	}
+	if s.suppressed > 0 {
+		message = fmt.Sprintf("%v (%v notifications are suppressed)", message, s.suppressed)
+	}
+	s.at, s.content, s.suppressed = now, content, 0
+	return message, true
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	tests := []struct {
+		channel, content string
+		after            time.Duration
+		want             string
+		wantOK           bool
+	}{
+		{"1/a", "error 1", 0, "error 1", true},
+		{"1/b", "error 1", 0, "error 1", true},
+		{"1/a", "error 2", time.Second, "", false},
+		{"1/a", "error 1", 20 * time.Second, "", false},
+		{"1/a", "error 3", 30 * time.Second, "error 3 (2 notifications are suppressed)", true},
+		{"1/a", "error 3", 50 * time.Second, "", false},
+		{"1/a", "error 3", 6 * time.Minute, "error 3 (1 notifications are suppressed)", true},
+	}
+	th := newThrottle(10*time.Second, 5*time.Minute)
+	for i, tt := range tests {
+		got, ok := th.allow(tt.channel, tt.content, tt.content, start.Add(tt.after))
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("%v: allow(%v, %v) = %q %v, want %q %v", i, tt.channel, tt.content, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// Masked replaces the secret fields of a channel config on read
+const Masked = "******"
+
+// secretFields are the config fields which carry the credentials of every channel type
+var secretFields = map[string][]string{
+	constant.NotifyEmail:    {"password"},
+	constant.NotifyTelegram: {"token"},
+	constant.NotifyDingTalk: {"url"}, // 机器人地址中带有 access_token
+	constant.NotifyWeCom:    {"url"}, // 机器人地址中带有 key
+}
+
+// Mask returns the channel with the secret fields of its config masked
+func Mask(channel model.Channel) model.Channel {
+	config := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
+		channel.Config = ""
+		return channel
+	}
+	for _, k := range secretFields[channel.Type] {
+		if v, ok := config[k]; ok && v != "" {
+			config[k] = Masked
+		}
+	}
+	data, _ := json.Marshal(config)
+	channel.Config = string(data)
+	return channel
+}
+
+// Unmask restores the masked secret fields of a saved channel from its stored config of the same type
+func Unmask(channel, stored model.Channel) model.Channel {
+	if channel.Type != stored.Type {
+		return channel
+	}
+	config := make(map[string]interface{})
+	old := make(map[string]interface{})
+	if json.Unmarshal([]byte(channel.Config), &config) != nil || json.Unmarshal([]byte(stored.Config), &old) != nil {
+		return channel
+	}
+	changed := false
+	for _, k := range secretFields[channel.Type] {
+		if config[k] == Masked {
+			config[k] = old[k]
+			changed = true
+		}
+	}
+	if changed {
+		data, _ := json.Marshal(config)
+		channel.Config = string(data)
+	}
+	return channel
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		channel model.Channel
+		want    string
+	}{
+		{model.Channel{Type: constant.NotifyEmail, Config: `{"host":"h","password":"p"}`}, `{"host":"h","password":"******"}`},
+		{model.Channel{Type: constant.NotifyTelegram, Config: `{"chatId":"42","token":"123:abc"}`}, `{"chatId":"42","token":"******"}`},
+		{model.Channel{Type: constant.NotifyDingTalk, Config: `{"url":"https://x/send?access_token=y"}`}, `{"url":"******"}`},
+		{model.Channel{Type: constant.NotifyWebhook, Config: `{"url":"https://x/hook"}`}, `{"url":"https://x/hook"}`},
+		{model.Channel{Type: constant.NotifyEmail, Config: `{"host":"h","password":""}`}, `{"host":"h","password":""}`},
+		{model.Channel{Type: constant.NotifyEmail, Config: `password`}, ``},
+	}
+	for _, tt := range tests {
+		if got := Mask(tt.channel).Config; got != tt.want {
+			t.Errorf("Mask(%v) = %v, want %v", tt.channel.Config, got, tt.want)
+		}
+	}
+}
+
+func TestUnmask(t *testing.T) {
+	stored := model.Channel{Type: constant.NotifyTelegram, Config: `{"chatId":"42","token":"123:abc"}`}
+	tests := []struct {
+		channel model.Channel
+		want    string
+	}{
+		{model.Channel{Type: constant.NotifyTelegram, Config: `{"chatId":"43","token":"******"}`}, `{"chatId":"43","token":"123:abc"}`},
+		{model.Channel{Type: constant.NotifyTelegram, Config: `{"chatId":"43","token":"456:def"}`}, `{"chatId":"43","token":"456:def"}`},
+		{model.Channel{Type: constant.NotifyEmail, Config: `{"token":"******"}`}, `{"token":"******"}`},
+	}
+	for _, tt := range tests {
+		if got := Unmask(tt.channel, stored).Config; got != tt.want {
+			t.Errorf("Unmask(%v) = %v, want %v", tt.channel.Config, got, tt.want)
+		}
+	}
+}
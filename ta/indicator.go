@@ -0,0 +1,177 @@
+package ta
+
+import (
+	"math"
+)
+
+// the values before an indicator has enough data are NaN
+
+// nan returns a slice of n NaN
+func nan(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// MA is the simple moving average
+func MA(values []float64, n int) []float64 {
+	result := nan(len(values))
+	if n <= 0 {
+		return result
+	}
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			result[i] = sum / float64(n)
+		}
+	}
+	return result
+}
+
+// EMA is the exponential moving average, seeded with the simple average of the first n values,
+// leading NaN values are skipped
+func EMA(values []float64, n int) []float64 {
+	result := nan(len(values))
+	if n <= 0 {
+		return result
+	}
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < n {
+		return result
+	}
+	alpha := 2.0 / float64(n+1)
+	sum := 0.0
+	for i := start; i < start+n; i++ {
+		sum += values[i]
+	}
+	result[start+n-1] = sum / float64(n)
+	for i := start + n; i < len(values); i++ {
+		result[i] = alpha*values[i] + (1-alpha)*result[i-1]
+	}
+	return result
+}
+
+// smma is the Wilder's smoothed moving average used by RSI and ATR, it starts from values[1]
+func smma(values []float64, n int) []float64 {
+	result := nan(len(values))
+	if n <= 0 || len(values) <= n {
+		return result
+	}
+	sum := 0.0
+	for i := 1; i <= n; i++ {
+		sum += values[i]
+	}
+	result[n] = sum / float64(n)
+	for i := n + 1; i < len(values); i++ {
+		result[i] = (result[i-1]*float64(n-1) + values[i]) / float64(n)
+	}
+	return result
+}
+
+// MACD returns the DIF, DEA and the histogram (DIF - DEA)
+func MACD(values []float64, fast, slow, signal int) (dif, dea, hist []float64) {
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+	dif = nan(len(values))
+	for i := range values {
+		if !math.IsNaN(fastEMA[i]) && !math.IsNaN(slowEMA[i]) {
+			dif[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+	dea = EMA(dif, signal)
+	hist = nan(len(values))
+	for i := range values {
+		if !math.IsNaN(dea[i]) {
+			hist[i] = dif[i] - dea[i]
+		}
+	}
+	return
+}
+
+// RSI is the relative strength index with Wilder's smoothing, it is 50 when the prices do not move
+func RSI(values []float64, n int) []float64 {
+	gains, losses := make([]float64, len(values)), make([]float64, len(values))
+	for i := 1; i < len(values); i++ {
+		if d := values[i] - values[i-1]; d > 0 {
+			gains[i] = d
+		} else {
+			losses[i] = -d
+		}
+	}
+	avgGain, avgLoss := smma(gains, n), smma(losses, n)
+	result := nan(len(values))
+	for i := range values {
+		if math.IsNaN(avgGain[i]) {
+			continue
+		}
+		if avgGain[i] == 0 && avgLoss[i] == 0 {
+			result[i] = 50
+		} else if avgLoss[i] == 0 {
+			result[i] = 100
+		} else {
+			result[i] = 100 - 100/(1+avgGain[i]/avgLoss[i])
+		}
+	}
+	return result
+}
+
+// BOLL returns the upper, middle and lower Bollinger bands, the deviation is the population standard deviation
+func BOLL(values []float64, n int, multiplier float64) (up, mid, down []float64) {
+	mid = MA(values, n)
+	up, down = nan(len(values)), nan(len(values))
+	for i := range values {
+		if math.IsNaN(mid[i]) {
+			continue
+		}
+		variance := 0.0
+		for _, v := range values[i-n+1 : i+1] {
+			variance += (v - mid[i]) * (v - mid[i])
+		}
+		deviation := math.Sqrt(variance / float64(n))
+		up[i] = mid[i] + multiplier*deviation
+		down[i] = mid[i] - multiplier*deviation
+	}
+	return
+}
+
+// ATR is the average true range with Wilder's smoothing
+func ATR(high, low, close []float64, n int) []float64 {
+	tr := make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		tr[i] = math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1])))
+	}
+	return smma(tr, n)
+}
+
+// KDJ returns the K, D and J lines of the stochastic oscillator, K and D start from 50
+func KDJ(high, low, close []float64, n, m1, m2 int) (k, d, j []float64) {
+	k, d, j = nan(len(close)), nan(len(close)), nan(len(close))
+	if n <= 0 || m1 <= 0 || m2 <= 0 {
+		return
+	}
+	prevK, prevD := 50.0, 50.0
+	for i := n - 1; i < len(close); i++ {
+		highest, lowest := high[i], low[i]
+		for x := i - n + 1; x < i; x++ {
+			highest = math.Max(highest, high[x])
+			lowest = math.Min(lowest, low[x])
+		}
+		rsv := 50.0
+		if highest > lowest {
+			rsv = (close[i] - lowest) / (highest - lowest) * 100
+		}
+		k[i] = (prevK*float64(m1-1) + rsv) / float64(m1)
+		d[i] = (prevD*float64(m2-1) + k[i]) / float64(m2)
+		j[i] = 3*k[i] - 2*d[i]
+		prevK, prevD = k[i], d[i]
+	}
+	return
+}
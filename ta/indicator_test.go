@@ -0,0 +1,146 @@
+package ta
+
+import (
+	"math"
+	"testing"
+)
+
+// the reference values are computed by the textbook formulas of the indicators
+var (
+	testClose = []float64{10, 11, 12, 11, 13, 14, 13, 15, 16, 15, 14, 16}
+	testHigh  = shift(testClose, 1)
+	testLow   = shift(testClose, -1)
+)
+
+func shift(values []float64, d float64) []float64 {
+	result := []float64{}
+	for _, v := range values {
+		result = append(result, v+d)
+	}
+	return result
+}
+
+// checkSeries compares the length, the count of the leading NaN and the last values
+func checkSeries(t *testing.T, name string, got []float64, length, leading int, last []float64) {
+	t.Helper()
+	if len(got) != length {
+		t.Fatalf("%v has %v values, want %v", name, len(got), length)
+	}
+	nans := 0
+	for nans < len(got) && math.IsNaN(got[nans]) {
+		nans++
+	}
+	if nans != leading {
+		t.Errorf("%v has %v leading NaN, want %v", name, nans, leading)
+	}
+	for i, want := range last {
+		if v := got[len(got)-len(last)+i]; math.Abs(v-want) > 1e-9 {
+			t.Errorf("%v[%v] = %v, want %v", name, len(got)-len(last)+i, v, want)
+		}
+	}
+}
+
+func TestMA(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		n       int
+		leading int
+		last    []float64
+	}{
+		{"n 3", testClose, 3, 2, []float64{15.333333333333334, 15, 15}},
+		{"n 1", testClose, 1, 0, []float64{15, 14, 16}},
+		{"n equals the length", testClose[:3], 3, 2, []float64{11}},
+		{"n longer than the input", testClose[:2], 3, 2, nil},
+		{"invalid n", testClose, 0, 12, nil},
+		{"empty", nil, 3, 0, nil},
+	}
+	for _, tt := range tests {
+		checkSeries(t, tt.name, MA(tt.values, tt.n), len(tt.values), tt.leading, tt.last)
+	}
+}
+
+func TestEMA(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		n       int
+		leading int
+		last    []float64
+	}{
+		{"n 3", testClose, 3, 2, []float64{15, 14.5, 15.25}},
+		{"seeded by the average", testClose[:3], 3, 2, []float64{11}},
+		{"leading NaN are skipped", append([]float64{math.NaN()}, testClose[:4]...), 3, 3, []float64{11, 11}},
+		{"n longer than the input", testClose[:2], 3, 2, nil},
+		{"invalid n", testClose, -1, 12, nil},
+	}
+	for _, tt := range tests {
+		checkSeries(t, tt.name, EMA(tt.values, tt.n), len(tt.values), tt.leading, tt.last)
+	}
+}
+
+func TestMACD(t *testing.T) {
+	dif, dea, hist := MACD(testClose, 3, 6, 3)
+	checkSeries(t, "DIF", dif, 12, 5, []float64{0.591058289204895})
+	checkSeries(t, "DEA", dea, 12, 7, []float64{0.6289463550240313})
+	checkSeries(t, "histogram", hist, 12, 7, []float64{-0.037888065819136285})
+	dif, dea, hist = MACD(testClose[:5], 3, 6, 3)
+	checkSeries(t, "short DIF", dif, 5, 5, nil)
+	checkSeries(t, "short DEA", dea, 5, 5, nil)
+	checkSeries(t, "short histogram", hist, 5, 5, nil)
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		n       int
+		leading int
+		last    []float64
+	}{
+		{"n 5", testClose, 5, 5, []float64{68.23591642422639, 56.55104389281604, 69.57581106229495}},
+		{"all equal", []float64{5, 5, 5, 5, 5}, 3, 3, []float64{50, 50}},
+		{"only gains", []float64{1, 2, 3, 4}, 3, 3, []float64{100}},
+		{"only losses", []float64{4, 3, 2, 1}, 3, 3, []float64{0}},
+		{"n equals the length", testClose[:5], 5, 5, nil},
+		{"invalid n", testClose, 0, 12, nil},
+		{"empty", nil, 14, 0, nil},
+	}
+	for _, tt := range tests {
+		checkSeries(t, tt.name, RSI(tt.values, tt.n), len(tt.values), tt.leading, tt.last)
+	}
+}
+
+func TestBOLL(t *testing.T) {
+	up, mid, down := BOLL(testClose, 4, 2)
+	checkSeries(t, "up", up, 12, 3, []float64{16.9083123951777})
+	checkSeries(t, "middle", mid, 12, 3, []float64{15.25})
+	checkSeries(t, "down", down, 12, 3, []float64{13.591687604822301})
+	up, mid, down = BOLL([]float64{5, 5, 5}, 2, 2)
+	checkSeries(t, "all equal up", up, 3, 1, []float64{5, 5})
+	checkSeries(t, "all equal down", down, 3, 1, []float64{5, 5})
+	up, mid, down = BOLL(testClose[:3], 4, 2)
+	checkSeries(t, "short up", up, 3, 3, nil)
+	checkSeries(t, "short middle", mid, 3, 3, nil)
+	checkSeries(t, "short down", down, 3, 3, nil)
+}
+
+func TestATR(t *testing.T) {
+	checkSeries(t, "n 5", ATR(testHigh, testLow, testClose, 5), 12, 5, []float64{2.2099200000000003, 2.167936, 2.3343488})
+	checkSeries(t, "n equals the length", ATR(testHigh[:5], testLow[:5], testClose[:5], 5), 5, 5, nil)
+	checkSeries(t, "invalid n", ATR(testHigh, testLow, testClose, 0), 12, 12, nil)
+}
+
+func TestKDJ(t *testing.T) {
+	k, d, j := KDJ(testHigh, testLow, testClose, 5, 3, 3)
+	checkSeries(t, "K", k, 12, 4, []float64{64.65249199817102})
+	checkSeries(t, "D", d, 12, 4, []float64{64.87349489407103})
+	checkSeries(t, "J", j, 12, 4, []float64{64.210486206371})
+	k, d, j = KDJ([]float64{5, 5}, []float64{5, 5}, []float64{5, 5}, 1, 3, 3)
+	checkSeries(t, "flat K", k, 2, 0, []float64{50, 50})
+	checkSeries(t, "flat J", j, 2, 0, []float64{50, 50})
+	k, _, _ = KDJ(testHigh[:3], testLow[:3], testClose[:3], 5, 3, 3)
+	checkSeries(t, "short K", k, 3, 3, nil)
+	k, _, _ = KDJ(testHigh, testLow, testClose, 5, 0, 3)
+	checkSeries(t, "invalid m1", k, 12, 12, nil)
+}
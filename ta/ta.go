@@ -0,0 +1,104 @@
+package ta
+
+import (
+	"reflect"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/miaolz123/conver"
+)
+
+// TA is the indicator library of the js runtime, the data can be the records returned by GetRecords,
+// an array of {Open, High, Low, Close, Volume} objects or an array of numbers which are used as close prices
+type TA struct{}
+
+// MA ...
+func (TA) MA(data interface{}, args ...interface{}) []float64 {
+	_, _, close := toSeries(data)
+	return MA(close, param(args, 0, 9))
+}
+
+// EMA ...
+func (TA) EMA(data interface{}, args ...interface{}) []float64 {
+	_, _, close := toSeries(data)
+	return EMA(close, param(args, 0, 9))
+}
+
+// MACD returns [DIF, DEA, histogram]
+func (TA) MACD(data interface{}, args ...interface{}) [][]float64 {
+	_, _, close := toSeries(data)
+	dif, dea, hist := MACD(close, param(args, 0, 12), param(args, 1, 26), param(args, 2, 9))
+	return [][]float64{dif, dea, hist}
+}
+
+// RSI ...
+func (TA) RSI(data interface{}, args ...interface{}) []float64 {
+	_, _, close := toSeries(data)
+	return RSI(close, param(args, 0, 14))
+}
+
+// BOLL returns [up, middle, down]
+func (TA) BOLL(data interface{}, args ...interface{}) [][]float64 {
+	_, _, close := toSeries(data)
+	multiplier := 2.0
+	if len(args) > 1 {
+		multiplier = conver.Float64Must(args[1], multiplier)
+	}
+	up, mid, down := BOLL(close, param(args, 0, 20), multiplier)
+	return [][]float64{up, mid, down}
+}
+
+// ATR ...
+func (TA) ATR(data interface{}, args ...interface{}) []float64 {
+	high, low, close := toSeries(data)
+	return ATR(high, low, close, param(args, 0, 14))
+}
+
+// KDJ returns [K, D, J]
+func (TA) KDJ(data interface{}, args ...interface{}) [][]float64 {
+	high, low, close := toSeries(data)
+	k, d, j := KDJ(high, low, close, param(args, 0, 9), param(args, 1, 3), param(args, 2, 3))
+	return [][]float64{k, d, j}
+}
+
+// param returns the i-th int argument or the default value
+func param(args []interface{}, i, def int) int {
+	if len(args) > i {
+		return conver.IntMust(args[i], def)
+	}
+	return def
+}
+
+// toSeries converts the data to the high, low and close series
+func toSeries(data interface{}) (high, low, close []float64) {
+	if records, ok := data.([]api.Record); ok {
+		for _, r := range records {
+			high = append(high, r.High)
+			low = append(low, r.Low)
+			close = append(close, r.Close)
+		}
+		return
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		switch item := v.Index(i).Interface().(type) {
+		case api.Record:
+			high = append(high, item.High)
+			low = append(low, item.Low)
+			close = append(close, item.Close)
+		case map[string]interface{}:
+			c := conver.Float64Must(item["Close"])
+			high = append(high, conver.Float64Must(item["High"], c))
+			low = append(low, conver.Float64Must(item["Low"], c))
+			close = append(close, c)
+		default:
+			c := conver.Float64Must(item)
+			high = append(high, c)
+			low = append(low, c)
+			close = append(close, c)
+		}
+	}
+	return
+}
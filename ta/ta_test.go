@@ -0,0 +1,40 @@
+package ta
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geniustag/QuantBot/api"
+)
+
+func TestToSeries(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            interface{}
+		high, low, last []float64
+	}{
+		{"records", []api.Record{{High: 3, Low: 1, Close: 2}, {High: 4, Low: 2, Close: 3}}, []float64{3, 4}, []float64{1, 2}, []float64{2, 3}},
+		{"js objects", []interface{}{map[string]interface{}{"High": 3, "Low": 1, "Close": 2}, map[string]interface{}{"Close": 5}}, []float64{3, 5}, []float64{1, 5}, []float64{2, 5}},
+		{"numbers", []interface{}{1, "2", 3.5}, []float64{1, 2, 3.5}, []float64{1, 2, 3.5}, []float64{1, 2, 3.5}},
+		{"typed numbers", []int64{1, 2}, []float64{1, 2}, []float64{1, 2}, []float64{1, 2}},
+		{"not an array", 1, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		high, low, close := toSeries(tt.data)
+		if !reflect.DeepEqual(high, tt.high) || !reflect.DeepEqual(low, tt.low) || !reflect.DeepEqual(close, tt.last) {
+			t.Errorf("%v: toSeries() = %v, %v, %v", tt.name, high, low, close)
+		}
+	}
+}
+
+func TestTAParams(t *testing.T) {
+	ta := TA{}
+	checkSeries(t, "default MA", ta.MA(testClose), 12, 8, []float64{127 / 9.0})
+	checkSeries(t, "MA", ta.MA(testClose, 3), 12, 2, []float64{15})
+	bands := ta.BOLL(testClose, 4, 2)
+	if len(bands) != 3 {
+		t.Fatalf("BOLL() returns %v series, want 3", len(bands))
+	}
+	checkSeries(t, "BOLL up", bands[0], 12, 3, []float64{16.9083123951777})
+	checkSeries(t, "KDJ K", ta.KDJ([]interface{}{1, 2, 3})[0], 3, 3, nil)
+}
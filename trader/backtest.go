@@ -0,0 +1,229 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+const (
+	backtestTimeout    = 5 * time.Minute //回测脚本最长的运行时间
+	backtestMaxCandles = 500000          //每个交易对最多的K线数量
+	backtestWarmup     = 200             //开始时间之前加载的K线数量, 供脚本计算指标
+	backtestCurveSize  = 1000            //返回的资金曲线最多的点数
+)
+
+var errBacktestTimeout = fmt.Errorf("The backtest is timeout after %v", backtestTimeout)
+
+// BacktestExchange is a simulated exchange of a backtest, its K-lines are the stored candles of the type
+type BacktestExchange struct {
+	Type    string
+	Stocks  []string           //交易对, 如 BTC/USDT
+	Balance map[string]float64 //初始资金, 如 {"USDT": 1000}
+	Fee     float64            //手续费率
+}
+
+// BacktestRequest is a backtest of an algorithm over the stored candles in [Begin, End)
+type BacktestRequest struct {
+	AlgorithmID int64
+	Version     int64 //算法的版本, 0 为最新版本
+	Exchanges   []BacktestExchange
+	Period      string //K线周期, 时钟按这个周期撮合订单和记录资金
+	Begin       time.Time
+	End         time.Time
+}
+
+// BacktestResult is the logs and the performance of a backtest
+type BacktestResult struct {
+	Logs        []model.Log
+	Performance model.Performance
+	Accounts    []interface{} //各交易所结束时的账户
+	Error       string        //脚本的错误, 回测仍然返回已有的结果
+}
+
+// backtest is the simulated clock of a backtest, it only moves when the script sleeps or waits for a loop
+type backtest struct {
+	now       int64 //毫秒
+	end       int64
+	step      int64 //K线周期的毫秒数
+	exchanges []*api.Backtest
+	curve     []model.EquityPoint
+	stop      func() //到达结束时间时停止脚本
+}
+
+// advance moves the clock to the time, the orders are matched and the equity is recorded at every bar,
+// the script is stopped like a live trader when the clock reaches the end
+func (b *backtest) advance(to int64) {
+	if to > b.end {
+		to = b.end
+	}
+	if b.now >= b.end {
+		return
+	}
+	for next := (b.now/b.step + 1) * b.step; next <= to; next += b.step {
+		b.now = next
+		b.sample()
+	}
+	if to > b.now {
+		b.now = to
+	}
+	if b.now >= b.end {
+		b.stop()
+	}
+}
+
+// sample matches the orders and records the equity of all the exchanges
+func (b *backtest) sample() {
+	equity := 0.0
+	for _, e := range b.exchanges {
+		e.Match()
+		equity += e.Equity()
+	}
+	b.curve = append(b.curve, model.EquityPoint{Time: time.Unix(0, b.now*int64(time.Millisecond)), Equity: equity})
+}
+
+// Backtest runs the algorithm over the stored candles with the simulated exchanges, the logs and the
+// store are kept in memory, StopLoss, TakeProfit, TrailingStop, TWAP, VWAP, Iceberg and Notify are not supported
+func Backtest(user model.User, req BacktestRequest) (result BacktestResult, err error) {
+	seconds := api.PeriodSeconds(req.Period)
+	if seconds <= 0 {
+		return result, fmt.Errorf("Invalid period: %v", req.Period)
+	}
+	if !req.End.After(req.Begin) {
+		return result, fmt.Errorf("The end time must be after the begin time")
+	}
+	if len(req.Exchanges) == 0 {
+		return result, fmt.Errorf("Please add at least one exchange")
+	}
+	algorithm, err := user.GetAlgorithm(req.AlgorithmID)
+	if err != nil {
+		return
+	}
+	version, err := algorithm.GetVersion(req.Version)
+	if err != nil {
+		return
+	}
+	g := &Global{}
+	g.Name = "backtest"
+	g.UserID = user.ID
+	g.Algorithm = algorithm
+	logMu := sync.Mutex{}
+	g.Logger = model.Logger{ExchangeType: "global", Sink: func(l model.Log) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		l.Timestamp = g.backtest.now * int64(time.Millisecond)
+		l.Time = time.Unix(0, l.Timestamp)
+		result.Logs = append(result.Logs, l)
+	}}
+	g.Store = &storage{logger: g.Logger, memory: make(map[string]string)}
+	g.backtest = &backtest{
+		now:  req.Begin.UnixNano() / int64(time.Millisecond),
+		end:  req.End.UnixNano() / int64(time.Millisecond),
+		step: seconds * 1000,
+	}
+	for i, x := range req.Exchanges {
+		opt := api.BacktestOption{
+			Type:    x.Type,
+			Name:    fmt.Sprintf("%v#%v", x.Type, i),
+			Period:  req.Period,
+			Candles: make(map[string][]model.Candle),
+			Balance: x.Balance,
+			Fee:     x.Fee,
+			Clock:   func() int64 { return g.backtest.now / 1000 },
+			Logger:  g.Logger,
+		}
+		for _, stock := range x.Stocks {
+			stock = strings.ToUpper(stock)
+			if strings.Count(stock, "/") != 1 {
+				return result, fmt.Errorf("Invalid stock type: %v", stock)
+			}
+			candles, err := model.ListCandles(x.Type, stock, req.Period, req.Begin.Unix()-backtestWarmup*seconds, req.End.Unix(), backtestMaxCandles+backtestWarmup+1)
+			if err != nil {
+				return result, err
+			}
+			if len(candles) > backtestMaxCandles+backtestWarmup {
+				return result, fmt.Errorf("Too many candles of %v %v, please narrow the time range", x.Type, stock)
+			}
+			if len(candles) == 0 {
+				return result, fmt.Errorf("There is no candle of %v %v %v, please collect or import them first", x.Type, stock, req.Period)
+			}
+			opt.Candles[stock] = candles
+		}
+		e := api.NewBacktest(opt)
+		g.backtest.exchanges = append(g.backtest.exchanges, e)
+		g.es = append(g.es, e)
+	}
+	g.bind()
+	g.backtest.stop = g.interrupt
+	g.backtest.sample()
+	base := g.backtest.curve[0].Equity
+	timer := time.AfterFunc(backtestTimeout, func() {
+		select {
+		case g.ctx.Interrupt <- func() { panic(errBacktestTimeout) }:
+		default:
+		}
+	})
+	defer timer.Stop()
+	result.Error = g.runBacktest(version.Script)
+	if result.Error == "" {
+		g.backtest.advance(g.backtest.end)
+	}
+	for _, e := range g.backtest.exchanges {
+		result.Accounts = append(result.Accounts, e.GetAccount())
+	}
+	result.Performance = model.CalcPerformance(g.backtest.curve, base)
+	for _, l := range result.Logs {
+		switch l.Type {
+		case constant.BUY, constant.SELL:
+			result.Performance.TradeCount++
+		}
+	}
+	if curve := result.Performance.Curve; len(curve) > backtestCurveSize {
+		result.Performance.Curve = []model.EquityPoint{}
+		for i := 1; i <= backtestCurveSize; i++ {
+			result.Performance.Curve = append(result.Performance.Curve, curve[i*len(curve)/backtestCurveSize-1])
+		}
+	}
+	return
+}
+
+// runBacktest runs the script like run does, but in the calling goroutine, it returns the error of the script,
+// the error of the interrupt at the end time is ignored even if the script catches and wraps it
+func (g *Global) runBacktest(script string) (msg string) {
+	defer func() {
+		if err := recover(); err != nil && err != errHalt && !g.halted() {
+			msg = fmt.Sprint(err)
+			g.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
+		}
+	}()
+	if _, err := g.ctx.Run(script); err != nil && !g.halted() {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
+		return err.Error()
+	}
+	if main, err := g.ctx.Get("main"); err != nil || !main.IsFunction() {
+		if len(g.loops) == 0 {
+			g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Can not get the main function")
+			return "Can not get the main function"
+		}
+	} else if _, err := main.Call(main); err != nil && !g.halted() {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
+		return err.Error()
+	}
+	g.runLoops()
+	return
+}
+
+// halted reports whether the trader is stopped
+func (g *Global) halted() bool {
+	select {
+	case <-g.halt:
+		return true
+	default:
+		return false
+	}
+}
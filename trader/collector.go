@@ -0,0 +1,182 @@
+package trader
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/config"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// collectorTrades is the period of a series which collects the public trades instead of the K-lines
+const collectorTrades = "trades"
+
+// series is a K-line series or the public trades pulled by the collector
+type series struct {
+	exchange  api.Exchange
+	stockType string
+	period    string
+	seconds   int64
+}
+
+// Collect pulls the series declared in the [collector] section of config.ini into the database, like
+// `okex = BTC/USDT:M15, ETH/USDT:H, BTC/USDT:trades`, the key is the name or the type of an exchange,
+// the gaps of the stored candles are backfilled at the start and whenever they appear if the exchange returns
+// the history, the interval (seconds) is collectorInterval and the max records of one request is collectorBackfill
+func Collect() {
+	list := collectorSeries()
+	if len(list) == 0 {
+		return
+	}
+	interval := conver.Int64Must(config.String("collectorinterval"))
+	if interval <= 0 {
+		interval = 60
+	}
+	max := conver.IntMust(config.String("collectorbackfill"))
+	if max <= 0 {
+		max = 1000
+	}
+	for _, s := range list {
+		s.backfillGaps()
+	}
+	for {
+		for _, s := range list {
+			s.collect(max)
+		}
+		time.Sleep(time.Duration(interval) * time.Second)
+	}
+}
+
+// collectorSeries parses the [collector] section of config.ini
+func collectorSeries() (list []series) {
+	for key, value := range config.Section("collector") {
+		opt := exchangeOption(key)
+		maker, ok := exchangeMaker[opt.Type]
+		if !ok {
+			log.Printf("Collector error: unrecognized exchange %v\n", key)
+			continue
+		}
+		exchange := maker(opt)
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ":")
+			if len(parts) != 2 || (parts[1] != collectorTrades && api.PeriodSeconds(parts[1]) <= 0) {
+				log.Printf("Collector error: invalid series %v of %v\n", item, key)
+				continue
+			}
+			if _, ok := exchange.(api.MarketTradeProvider); parts[1] == collectorTrades && !ok {
+				log.Printf("Collector error: %v does not provide the public trades\n", key)
+				continue
+			}
+			list = append(list, series{
+				exchange:  exchange,
+				stockType: strings.ToUpper(parts[0]),
+				period:    parts[1],
+				seconds:   api.PeriodSeconds(parts[1]),
+			})
+		}
+	}
+	return
+}
+
+// exchangeOption returns the option of the saved exchange whose name or type is the key for the collector and
+// the recorder, so that the config of a white-label venue or an adapter is used, a type which is not saved gets
+// the option without keys
+func exchangeOption(key string) api.Option {
+	e := model.Exchange{}
+	if err := model.DB.Where("name = ?", key).Order("id").First(&e).Error; err != nil {
+		if err := model.DB.Where("type = ?", key).Order("id").First(&e).Error; err != nil {
+			return api.Option{Type: key, Name: key}
+		}
+	}
+	return api.Option{
+		Type:      e.Type,
+		Name:      e.Name,
+		AccessKey: e.AccessKey,
+		SecretKey: e.SecretKey,
+		Config:    e.Config,
+	}
+}
+
+// collect requests the records since the last stored candle or the recent public trades and saves them
+func (s series) collect(max int) {
+	typ := s.exchange.GetType()
+	if s.period == collectorTrades {
+		trades, ok := s.exchange.(api.MarketTradeProvider).GetMarketTrades(s.stockType).([]api.MarketTrade)
+		if !ok {
+			return
+		}
+		if _, err := model.SaveMarketTrades(typ, s.stockType, api.ToMarketTrades(trades)); err != nil {
+			log.Printf("Collector error: %v\n", err)
+		}
+		return
+	}
+	// 只请求最后一根K线之后的数据, 较小的 size 不会影响机器人: 采集器使用 collectorSeries 中自己创建的交易所实例,
+	// 不与机器人共享K线缓存, 而且交易所的缓存至少保留 200 根 (见 api.cacheRecords)
+	size := max
+	last, err := model.LastCandle(typ, s.stockType, s.period)
+	if err == nil {
+		if need := int((time.Now().Unix()-last.Timestamp)/s.seconds) + 2; need < size {
+			size = need
+		}
+	}
+	records, ok := s.exchange.GetRecords(s.stockType, s.period, size).([]api.Record)
+	if !ok || len(records) == 0 {
+		return
+	}
+	candles := api.ToCandles(records)
+	if _, err := model.SaveCandles(typ, s.stockType, s.period, candles); err != nil {
+		log.Printf("Collector error: %v\n", err)
+		return
+	}
+	if last.Timestamp > 0 && candles[0].Timestamp > last.Timestamp+s.seconds {
+		s.backfill(last.Timestamp+s.seconds, candles[0].Timestamp)
+	}
+}
+
+// backfillGaps backfills the gaps between the stored candles of the series
+func (s series) backfillGaps() {
+	if s.period == collectorTrades {
+		return
+	}
+	gaps, err := model.CandleGaps(s.exchange.GetType(), s.stockType, s.period, s.seconds)
+	if err != nil {
+		log.Printf("Collector error: %v\n", err)
+		return
+	}
+	for _, gap := range gaps {
+		s.backfill(gap[0], gap[1])
+	}
+}
+
+// backfill requests the records in [begin, end) from the exchange which returns the history, the pages are
+// requested backward from the end because the exchange may return less records than the range, the part
+// of the gap which the exchange can not return is logged
+func (s series) backfill(begin, end int64) {
+	typ := s.exchange.GetType()
+	h, ok := s.exchange.(api.HistoryProvider)
+	for ok && begin < end {
+		records, isRecords := h.GetRecordsBetween(s.stockType, s.period, begin, end).([]api.Record)
+		if !isRecords || len(records) == 0 {
+			break
+		}
+		candles := api.ToCandles(records)
+		if _, err := model.SaveCandles(typ, s.stockType, s.period, candles); err != nil {
+			log.Printf("Collector error: %v\n", err)
+			break
+		}
+		if candles[0].Timestamp >= end {
+			break
+		}
+		end = candles[0].Timestamp
+		if end <= begin {
+			return
+		}
+	}
+	if begin < end {
+		log.Printf("Collector: %v %v %v has a gap from %v to %v which the exchange does not return\n", typ, s.stockType, s.period,
+			time.Unix(begin, 0), time.Unix(end, 0))
+	}
+}
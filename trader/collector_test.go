@@ -0,0 +1,113 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// t0 is the time of the first candle in the tests, the time of the records is in milliseconds
+const t0 = 1500000000
+
+// historyExchange returns at most limit records from the end of the requested range like OKEx v3
+type historyExchange struct {
+	api.Exchange
+	limit    int
+	first    int64 //最早能返回的K线时间
+	requests [][2]int64
+	trades   []api.MarketTrade
+}
+
+func (e *historyExchange) GetType() string {
+	return "history"
+}
+
+func (e *historyExchange) GetRecordsBetween(stockType, period string, begin, end int64) interface{} {
+	e.requests = append(e.requests, [2]int64{begin, end})
+	records := []api.Record{}
+	for t := end - 60; t >= begin && t >= e.first && len(records) < e.limit; t -= 60 {
+		records = append([]api.Record{{Time: t * 1000, Close: float64(t)}}, records...)
+	}
+	return records
+}
+
+func (e *historyExchange) GetMarketTrades(stockType string) interface{} {
+	return e.trades
+}
+
+func TestBackfill(t *testing.T) {
+	tests := []struct {
+		name         string
+		first        int64
+		begin, end   int64
+		wantCandles  int
+		wantRequests int
+	}{
+		{"one page", 0, t0, t0 + 180, 3, 1},
+		{"pages backward", 0, t0, t0 + 600, 10, 4},
+		{"the exchange does not return the beginning", t0 + 240, t0, t0 + 600, 6, 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &historyExchange{limit: 3, first: tt.first}
+			s := series{exchange: e, stockType: string(rune('A'+i)) + "/USDT", period: "M", seconds: 60}
+			s.backfill(tt.begin, tt.end)
+			candles, _ := model.ListCandles("history", s.stockType, "M", 0, 0, 0)
+			if len(candles) != tt.wantCandles || len(e.requests) != tt.wantRequests {
+				t.Errorf("%v candles by %v requests %v, want %v by %v", len(candles), len(e.requests), e.requests, tt.wantCandles, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestBackfillGaps(t *testing.T) {
+	model.SaveCandles("history", "GAP/USDT", "M", []model.Candle{{Timestamp: t0}, {Timestamp: t0 + 60}, {Timestamp: t0 + 360}, {Timestamp: t0 + 420}, {Timestamp: t0 + 660}})
+	s := series{exchange: &historyExchange{limit: 100}, stockType: "GAP/USDT", period: "M", seconds: 60}
+	s.backfillGaps()
+	if gaps, _ := model.CandleGaps("history", "GAP/USDT", "M", 60); len(gaps) != 0 {
+		t.Errorf("the gaps %v are not backfilled", gaps)
+	}
+	// 不能返回历史K线的交易所只记录缺口
+	model.SaveCandles("fake", "BTC/USDT", "M", []model.Candle{{Timestamp: t0}, {Timestamp: t0 + 540}})
+	s = series{exchange: fakeExchange(nil, map[string]float64{"BTC/USDT": 1}), stockType: "BTC/USDT", period: "M", seconds: 60}
+	s.backfillGaps()
+	if gaps, _ := model.CandleGaps("fake", "BTC/USDT", "M", 60); len(gaps) != 1 {
+		t.Errorf("%v gaps, want 1", len(gaps))
+	}
+}
+
+func TestCollectTrades(t *testing.T) {
+	e := &historyExchange{trades: []api.MarketTrade{{ID: "1", Time: 1000, Price: 1}, {ID: "2", Time: 2000, Price: 2}}}
+	s := series{exchange: e, stockType: "BTC/USDT", period: collectorTrades}
+	s.collect(100)
+	e.trades = append(e.trades, api.MarketTrade{ID: "3", Time: 3000, Price: 3})
+	s.collect(100)
+	trades, _ := model.ListMarketTrades("history", "BTC/USDT", 0, 0, 0)
+	if len(trades) != 3 || trades[2].TradeID != "3" || trades[2].Price != 3 {
+		t.Errorf("the collected trades are %+v", trades)
+	}
+}
+
+func TestExchangeOption(t *testing.T) {
+	venue := model.Exchange{Name: "my venue", Type: "okexv3", Config: `{"host": "https://venue"}`}
+	second := model.Exchange{Name: "second", Type: "okexv3", Config: `{"host": "https://second"}`}
+	model.DB.Create(&venue)
+	model.DB.Create(&second)
+	defer model.DB.Unscoped().Delete(&model.Exchange{}, "id in (?)", []int64{venue.ID, second.ID})
+	tests := []struct {
+		key                  string
+		wantType, wantConfig string
+	}{
+		{"my venue", "okexv3", `{"host": "https://venue"}`},
+		{"second", "okexv3", `{"host": "https://second"}`},
+		{"okexv3", "okexv3", `{"host": "https://venue"}`},
+		{"okex", "okex", ""},
+	}
+	for _, tt := range tests {
+		opt := exchangeOption(tt.key)
+		if opt.Type != tt.wantType || opt.Config != tt.wantConfig {
+			t.Errorf("exchangeOption(%q) = %+v, want type %v and config %v", tt.key, opt, tt.wantType, tt.wantConfig)
+		}
+	}
+}
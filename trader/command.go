@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"fmt"
+
+	"github.com/geniustag/QuantBot/constant"
+)
+
+// commandSize is the max amount of the pending commands of a trader
+const commandSize = 64
+
+// Command queues a command to the running trader, the script reads it by G.GetCommand() or onCommand(cmd)
+func Command(id int64, cmd string) (err error) {
+	t := getRunning(id)
+	if t == nil {
+		return fmt.Errorf("The Trader is not running")
+	}
+	select {
+	case t.commands <- cmd:
+	default:
+		return fmt.Errorf("Too many pending commands")
+	}
+	t.Logger.Log(constant.INFO, "", 0.0, 0.0, "Command received: ", cmd)
+	return
+}
+
+// GetCommand returns the oldest pending command, or an empty string if there is no command
+func (g *Global) GetCommand() string {
+	select {
+	case cmd := <-g.commands:
+		return cmd
+	default:
+		return ""
+	}
+}
+
+// commandQueue returns the pending commands if the script defines onCommand(cmd),
+// otherwise nil so that the commands are kept for GetCommand
+func (g *Global) commandQueue() chan string {
+	if g.running {
+		return nil
+	}
+	if fn, err := g.ctx.Get("onCommand"); err == nil && fn.IsFunction() {
+		return g.commands
+	}
+	return nil
+}
+
+// dispatchCommands calls onCommand(cmd) with every pending command
+func (g *Global) dispatchCommands() {
+	q := g.commandQueue()
+	for q != nil && len(q) > 0 {
+		g.callCommand(<-q)
+	}
+}
+
+// callCommand calls onCommand(cmd) of the script, errors are logged and do not stop the trader
+func (g *Global) callCommand(cmd string) {
+	defer func() {
+		if err := recover(); err != nil {
+			if err == errHalt {
+				panic(err)
+			}
+			g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "onCommand error, ", err)
+		}
+	}()
+	fn, err := g.ctx.Get("onCommand")
+	if err != nil || !fn.IsFunction() {
+		return
+	}
+	if _, err := fn.Call(fn, cmd); err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "onCommand error, ", err)
+	}
+}
@@ -0,0 +1,336 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// conditionInterval is how often the conditional orders check the tickers
+const conditionInterval = time.Second
+
+// the types and the status of a conditional order
+const (
+	conditionStop       = "STOP"
+	conditionTakeProfit = "TAKE_PROFIT"
+	conditionTrailing   = "TRAILING"
+
+	conditionWaiting   = "waiting"
+	conditionTriggered = "triggered"
+	conditionCanceled  = "canceled"
+	conditionFailed    = "failed"
+)
+
+// StopLoss places an order when the price moves against the position to the stop price,
+// a SELL triggers when the buy price <= stopPrice and a BUY when the sell price >= stopPrice
+func (g *Global) StopLoss(exchange api.Exchange, tradeType, stockType string, amount, stopPrice interface{}, price ...interface{}) interface{} {
+	return g.addCondition(conditionStop, exchange, tradeType, stockType, amount, stopPrice, 0, price)
+}
+
+// TakeProfit places an order when the price moves in favor of the position to the stop price,
+// a SELL triggers when the buy price >= stopPrice and a BUY when the sell price <= stopPrice
+func (g *Global) TakeProfit(exchange api.Exchange, tradeType, stockType string, amount, stopPrice interface{}, price ...interface{}) interface{} {
+	return g.addCondition(conditionTakeProfit, exchange, tradeType, stockType, amount, stopPrice, 0, price)
+}
+
+// TrailingStop places an order when the price retraces by the ratio from its best price since the order is added
+func (g *Global) TrailingStop(exchange api.Exchange, tradeType, stockType string, amount, ratio interface{}, price ...interface{}) interface{} {
+	return g.addCondition(conditionTrailing, exchange, tradeType, stockType, amount, 0, ratio, price)
+}
+
+// LinkOCO links two waiting conditional orders, when one of them is triggered the other is canceled
+func (g *Global) LinkOCO(id1, id2 interface{}) bool {
+	g.conditionMu.Lock()
+	a, b := g.condition(conver.Int64Must(id1)), g.condition(conver.Int64Must(id2))
+	if a == nil || b == nil || a == b {
+		g.conditionMu.Unlock()
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "LinkOCO(), Invalid conditional order")
+		return false
+	}
+	a.OCO, b.OCO = b.ID, a.ID
+	g.conditionMu.Unlock()
+	g.saveCondition(a)
+	g.saveCondition(b)
+	return true
+}
+
+// GetConditionalOrders returns all the conditional orders of the trader from the newest
+func (g *Global) GetConditionalOrders() interface{} {
+	orders, err := model.ListConditionalOrders(g.ID, "")
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "GetConditionalOrders() error, ", err)
+		return false
+	}
+	return orders
+}
+
+// CancelConditionalOrder cancels a waiting conditional order
+func (g *Global) CancelConditionalOrder(id interface{}) bool {
+	g.conditionMu.Lock()
+	o := g.condition(conver.Int64Must(id))
+	if o != nil {
+		g.finishCondition(o, conditionCanceled, "")
+	}
+	g.conditionMu.Unlock()
+	if o == nil {
+		return false
+	}
+	g.saveCondition(o)
+	return true
+}
+
+// CancelConditionalOrder cancels a waiting conditional order of a trader, which is running or not
+func CancelConditionalOrder(traderID, id int64) (err error) {
+	if t := getRunning(traderID); t != nil {
+		if !t.CancelConditionalOrder(id) {
+			return fmt.Errorf("The conditional order is not waiting")
+		}
+		return
+	}
+	o, err := model.GetConditionalOrder(traderID, id)
+	if err != nil {
+		return
+	}
+	if o.Status != conditionWaiting {
+		return fmt.Errorf("The conditional order is not waiting")
+	}
+	o.Status = conditionCanceled
+	return model.SaveConditionalOrder(&o)
+}
+
+func (g *Global) addCondition(typ string, exchange api.Exchange, tradeType, stockType string, amount, stopPrice, trailing interface{}, price []interface{}) interface{} {
+	if exchange == nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Invalid exchange")
+		return false
+	}
+	if g.backtest != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "The ", typ, " order is not supported in a backtest")
+		return false
+	}
+	o := &model.ConditionalOrder{
+		TraderID:  g.ID,
+		Exchange:  exchange.GetName(),
+		Type:      typ,
+		TradeType: strings.ToUpper(tradeType),
+		StockType: strings.ToUpper(stockType),
+		Amount:    conver.Float64Must(amount),
+		StopPrice: conver.Float64Must(stopPrice),
+		Trailing:  conver.Float64Must(trailing),
+		Status:    conditionWaiting,
+	}
+	if len(price) > 0 {
+		o.Price = conver.Float64Must(price[0])
+	}
+	if o.TradeType != constant.TradeTypeBuy && o.TradeType != constant.TradeTypeSell {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Unrecognized tradeType: ", tradeType)
+		return false
+	}
+	if o.Amount <= 0 || (typ == conditionTrailing && (o.Trailing <= 0 || o.Trailing >= 1)) || (typ != conditionTrailing && o.StopPrice <= 0) {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Invalid amount, price or ratio of the ", typ, " order")
+		return false
+	}
+	if err := model.SaveConditionalOrder(o); err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Save the ", typ, " order error, ", err)
+		return false
+	}
+	g.conditionMu.Lock()
+	g.conditions = append(g.conditions, o)
+	g.conditionMu.Unlock()
+	return o.ID
+}
+
+// loadConditions loads the waiting conditional orders saved by the last run
+func (g *Global) loadConditions() {
+	orders, err := model.ListConditionalOrders(g.ID, conditionWaiting)
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Load the conditional orders error, ", err)
+		return
+	}
+	for i := len(orders) - 1; i >= 0; i-- {
+		o := orders[i]
+		g.conditions = append(g.conditions, &o)
+	}
+}
+
+// watchConditions checks the conditional orders until the trader is stopped
+func (g *Global) watchConditions() {
+	for {
+		select {
+		case <-g.halt:
+			return
+		case <-time.After(conditionInterval):
+		}
+		g.checkConditions()
+	}
+}
+
+// trigger is a triggered conditional order taken from the waiting list, its order is placed without g.conditionMu
+type trigger struct {
+	order    *model.ConditionalOrder
+	oco      *model.ConditionalOrder //同时取出的 OCO 条件单, 下单失败时放回
+	exchange api.Exchange
+	price    float64
+}
+
+// checkConditions gets the ticker of every exchange and stock type once and triggers the conditional orders,
+// the orders are placed and saved after g.conditionMu is released
+func (g *Global) checkConditions() {
+	g.conditionMu.Lock()
+	waiting := append([]*model.ConditionalOrder{}, g.conditions...)
+	g.conditionMu.Unlock()
+	tickers := make(map[string]interface{})
+	for _, o := range waiting {
+		key := o.Exchange + "#" + o.StockType
+		if _, ok := tickers[key]; ok {
+			continue
+		}
+		tickers[key] = false
+		if e := g.exchange(o.Exchange); e != nil {
+			tickers[key] = e.GetTicker(o.StockType)
+		}
+	}
+	triggers, changed := g.evaluateConditions(waiting, tickers)
+	for _, o := range changed {
+		g.saveCondition(o)
+	}
+	for _, t := range triggers {
+		g.fireCondition(t)
+	}
+}
+
+// evaluateConditions updates the trailing stop prices and takes the triggered orders and their OCO orders
+// out of the waiting list, it returns the triggers and the orders to save
+func (g *Global) evaluateConditions(waiting []*model.ConditionalOrder, tickers map[string]interface{}) (triggers []trigger, changed []*model.ConditionalOrder) {
+	g.conditionMu.Lock()
+	defer g.conditionMu.Unlock()
+	for _, o := range waiting {
+		if g.condition(o.ID) != o {
+			continue
+		}
+		e := g.exchange(o.Exchange)
+		if e == nil {
+			g.finishCondition(o, conditionFailed, "Can not found the exchange "+o.Exchange)
+			changed = append(changed, o)
+			continue
+		}
+		ticker, ok := tickers[o.Exchange+"#"+o.StockType].(api.Ticker)
+		if !ok {
+			continue
+		}
+		// 卖出时按买一价判断, 买入时按卖一价判断
+		price, sell := ticker.Sell, o.TradeType == constant.TradeTypeSell
+		if sell {
+			price = ticker.Buy
+		}
+		if price <= 0 {
+			continue
+		}
+		triggered := false
+		switch o.Type {
+		case conditionStop:
+			triggered = (sell && price <= o.StopPrice) || (!sell && price >= o.StopPrice)
+		case conditionTakeProfit:
+			triggered = (sell && price >= o.StopPrice) || (!sell && price <= o.StopPrice)
+		case conditionTrailing:
+			if o.Extreme <= 0 || (sell && price > o.Extreme) || (!sell && price < o.Extreme) {
+				o.Extreme = price
+				if sell {
+					o.StopPrice = price * (1 - o.Trailing)
+				} else {
+					o.StopPrice = price * (1 + o.Trailing)
+				}
+				changed = append(changed, o)
+			}
+			triggered = (sell && price <= o.StopPrice) || (!sell && price >= o.StopPrice)
+		}
+		if triggered {
+			t := trigger{order: o, oco: g.condition(o.OCO), exchange: e, price: price}
+			g.removeCondition(o)
+			g.removeCondition(t.oco)
+			triggers = append(triggers, t)
+		}
+	}
+	return
+}
+
+// fireCondition places the order of a triggered conditional order, the OCO order is canceled if the order is
+// placed and is put back to the waiting list if not
+func (g *Global) fireCondition(t trigger) {
+	o, price := t.order, t.price
+	if o.Price > 0 {
+		price = o.Price
+	}
+	id := t.exchange.Trade(o.TradeType, o.StockType, price, o.Amount, o.Type, " #", o.ID, " triggered at ", o.StopPrice)
+	g.conditionMu.Lock()
+	if ok, isBool := id.(bool); isBool && !ok {
+		o.Status, o.Message = conditionFailed, "Trade() failed"
+		if t.oco != nil {
+			g.conditions = append(g.conditions, t.oco)
+			t.oco = nil
+		}
+	} else {
+		o.Status, o.OrderID = conditionTriggered, fmt.Sprint(id)
+		if t.oco != nil {
+			t.oco.Status, t.oco.Message = conditionCanceled, fmt.Sprintf("OCO #%v triggered", o.ID)
+		}
+	}
+	g.conditionMu.Unlock()
+	g.saveCondition(o)
+	if t.oco != nil {
+		g.saveCondition(t.oco)
+	}
+}
+
+// finishCondition sets the final status of a conditional order and stops watching it, g.conditionMu must be held
+func (g *Global) finishCondition(o *model.ConditionalOrder, status, msg string) {
+	o.Status, o.Message = status, msg
+	g.removeCondition(o)
+}
+
+// removeCondition removes a conditional order from the waiting list, g.conditionMu must be held
+func (g *Global) removeCondition(o *model.ConditionalOrder) {
+	for i, c := range g.conditions {
+		if c == o {
+			g.conditions = append(g.conditions[:i], g.conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// saveCondition saves the latest state of a conditional order, it must be called without g.conditionMu,
+// the saves are serialized so that a stale copy never overwrites a newer one
+func (g *Global) saveCondition(o *model.ConditionalOrder) {
+	g.conditionSaveMu.Lock()
+	defer g.conditionSaveMu.Unlock()
+	g.conditionMu.Lock()
+	c := *o
+	g.conditionMu.Unlock()
+	if err := model.SaveConditionalOrder(&c); err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Save the ", c.Type, " order error, ", err)
+	}
+}
+
+// condition returns the waiting conditional order, g.conditionMu must be held
+func (g *Global) condition(id int64) *model.ConditionalOrder {
+	for _, o := range g.conditions {
+		if o.ID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+// exchange returns the exchange of the trader by its name
+func (g *Global) exchange(name string) api.Exchange {
+	for _, e := range g.es {
+		if e.GetName() == name {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package trader
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// tickerExchange returns the ticker set by the test and records the orders
+type tickerExchange struct {
+	api.Exchange
+	name   string
+	mu     sync.Mutex
+	ticker api.Ticker
+	fail   bool
+	hook   func() // Trade 时调用
+	trades []string
+}
+
+func (e *tickerExchange) GetName() string {
+	return e.name
+}
+
+func (e *tickerExchange) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ticker
+}
+
+func (e *tickerExchange) Trade(tradeType string, stockType string, price, amount interface{}, msgs ...interface{}) interface{} {
+	if e.hook != nil {
+		e.hook()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.fail {
+		return false
+	}
+	e.trades = append(e.trades, fmt.Sprint(tradeType, " ", price, " ", amount))
+	return fmt.Sprint(len(e.trades))
+}
+
+func (e *tickerExchange) set(buy, sell float64, fail bool) {
+	e.mu.Lock()
+	e.ticker, e.fail = api.Ticker{Buy: buy, Sell: sell}, fail
+	e.mu.Unlock()
+}
+
+// conditionGlobal returns a trader of the exchanges which checks whether Trade is called with conditionMu held
+func conditionGlobal(t *testing.T, id int64, exchanges ...*tickerExchange) *Global {
+	g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}}
+	g.ID = id
+	for _, e := range exchanges {
+		g.es = append(g.es, e)
+		e.hook = func() {
+			locked := make(chan struct{})
+			go func() {
+				g.conditionMu.Lock()
+				g.conditionMu.Unlock()
+				close(locked)
+			}()
+			select {
+			case <-locked:
+			case <-time.After(time.Second):
+				t.Errorf("Trade() is called with conditionMu held")
+			}
+		}
+	}
+	return g
+}
+
+func TestCheckConditions(t *testing.T) {
+	type tick struct {
+		buy, sell float64
+		fail      bool
+	}
+	tests := []struct {
+		name        string
+		add         func(g *Global, e api.Exchange) interface{}
+		ticks       []tick
+		wantStatus  string
+		wantMessage string
+		wantTrades  []string
+		wantStop    float64
+	}{
+		{
+			name:       "stop sell",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.StopLoss(e, "sell", "btc/usdt", 1, 95) },
+			ticks:      []tick{{100, 101, false}, {96, 97, false}, {95, 96, false}},
+			wantStatus: conditionTriggered,
+			wantTrades: []string{"SELL 95 1"},
+			wantStop:   95,
+		},
+		{
+			name:       "stop buy",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.StopLoss(e, "buy", "btc/usdt", 2, 105) },
+			ticks:      []tick{{103, 104, false}, {104, 105.5, false}},
+			wantStatus: conditionTriggered,
+			wantTrades: []string{"BUY 105.5 2"},
+			wantStop:   105,
+		},
+		{
+			name:       "take profit sell waits",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.TakeProfit(e, "sell", "btc/usdt", 1, 110) },
+			ticks:      []tick{{100, 101, false}, {109.9, 111, false}},
+			wantStatus: conditionWaiting,
+			wantStop:   110,
+		},
+		{
+			name:       "take profit buy at the limit price",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.TakeProfit(e, "buy", "btc/usdt", 1, 90, 89.5) },
+			ticks:      []tick{{88, 91, false}, {88, 90, false}},
+			wantStatus: conditionTriggered,
+			wantTrades: []string{"BUY 89.5 1"},
+			wantStop:   90,
+		},
+		{
+			name:       "trailing sell follows the highest price",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.TrailingStop(e, "sell", "btc/usdt", 1, 0.1) },
+			ticks:      []tick{{100, 101, false}, {120, 121, false}, {109, 110, false}, {108, 109, false}},
+			wantStatus: conditionTriggered,
+			wantTrades: []string{"SELL 108 1"},
+			wantStop:   108,
+		},
+		{
+			name:       "trailing buy follows the lowest price",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.TrailingStop(e, "buy", "btc/usdt", 1, 0.1) },
+			ticks:      []tick{{99, 100, false}, {79, 80, false}, {87, 87.5, false}},
+			wantStatus: conditionWaiting,
+			wantStop:   88,
+		},
+		{
+			name:        "the trade fails",
+			add:         func(g *Global, e api.Exchange) interface{} { return g.StopLoss(e, "sell", "btc/usdt", 1, 95) },
+			ticks:       []tick{{90, 91, true}, {90, 91, false}},
+			wantStatus:  conditionFailed,
+			wantMessage: "Trade() failed",
+			wantStop:    95,
+		},
+		{
+			name: "the exchange is not in the trader",
+			add: func(g *Global, e api.Exchange) interface{} {
+				return g.StopLoss(&tickerExchange{name: "gone"}, "sell", "btc/usdt", 1, 95)
+			},
+			ticks:       []tick{{100, 101, false}},
+			wantStatus:  conditionFailed,
+			wantMessage: "Can not found the exchange gone",
+			wantStop:    95,
+		},
+		{
+			name:       "no ticker",
+			add:        func(g *Global, e api.Exchange) interface{} { return g.StopLoss(e, "sell", "btc/usdt", 1, 95) },
+			ticks:      []tick{{0, 0, false}},
+			wantStatus: conditionWaiting,
+			wantStop:   95,
+		},
+	}
+	for i, tt := range tests {
+		e := &tickerExchange{name: "venue"}
+		g := conditionGlobal(t, int64(4000+i), e)
+		id, ok := tt.add(g, e).(int64)
+		if !ok {
+			t.Errorf("%v: the conditional order is not added", tt.name)
+			continue
+		}
+		for _, tick := range tt.ticks {
+			e.set(tick.buy, tick.sell, tick.fail)
+			g.checkConditions()
+		}
+		o, err := model.GetConditionalOrder(g.ID, id)
+		if err != nil {
+			t.Errorf("%v: GetConditionalOrder() error = %v", tt.name, err)
+			continue
+		}
+		if o.Status != tt.wantStatus || o.Message != tt.wantMessage || o.StopPrice != tt.wantStop {
+			t.Errorf("%v: saved order = %v %q stop %v, want %v %q stop %v", tt.name, o.Status, o.Message, o.StopPrice, tt.wantStatus, tt.wantMessage, tt.wantStop)
+		}
+		if !reflect.DeepEqual(e.trades, tt.wantTrades) {
+			t.Errorf("%v: trades = %v, want %v", tt.name, e.trades, tt.wantTrades)
+		}
+		if waiting := len(g.conditions) == 1; waiting != (tt.wantStatus == conditionWaiting) {
+			t.Errorf("%v: %v orders are watched", tt.name, len(g.conditions))
+		}
+	}
+}
+
+func TestLinkOCO(t *testing.T) {
+	type tick struct {
+		buy, sell float64
+		fail      bool
+	}
+	tests := []struct {
+		name         string
+		stop, target float64
+		ticks        []tick
+		wantStop     string
+		wantTarget   string
+		wantTrades   []string
+	}{
+		{"the target cancels the stop", 95, 110, []tick{{100, 101, false}, {110, 111, false}}, conditionCanceled, conditionTriggered, []string{"SELL 110 1"}},
+		{"the stop cancels the target", 95, 110, []tick{{94, 95, false}, {110, 111, false}}, conditionTriggered, conditionCanceled, []string{"SELL 94 1"}},
+		{"both trigger at the same time", 95, 90, []tick{{92, 93, false}}, conditionTriggered, conditionCanceled, []string{"SELL 92 1"}},
+		{"the other stays after a failed trade", 95, 110, []tick{{94, 95, true}, {110, 111, false}}, conditionFailed, conditionTriggered, []string{"SELL 110 1"}},
+	}
+	for i, tt := range tests {
+		e := &tickerExchange{name: "venue"}
+		g := conditionGlobal(t, int64(4100+i), e)
+		stop, _ := g.StopLoss(e, "sell", "btc/usdt", 1, tt.stop).(int64)
+		target, _ := g.TakeProfit(e, "sell", "btc/usdt", 1, tt.target).(int64)
+		if !g.LinkOCO(stop, target) {
+			t.Errorf("%v: LinkOCO() = false", tt.name)
+			continue
+		}
+		for _, tick := range tt.ticks {
+			e.set(tick.buy, tick.sell, tick.fail)
+			g.checkConditions()
+		}
+		s, _ := model.GetConditionalOrder(g.ID, stop)
+		o, _ := model.GetConditionalOrder(g.ID, target)
+		if s.Status != tt.wantStop || o.Status != tt.wantTarget || s.OCO != target || o.OCO != stop {
+			t.Errorf("%v: stop %v oco %v, target %v oco %v, want %v %v", tt.name, s.Status, s.OCO, o.Status, o.OCO, tt.wantStop, tt.wantTarget)
+		}
+		if !reflect.DeepEqual(e.trades, tt.wantTrades) {
+			t.Errorf("%v: trades = %v, want %v", tt.name, e.trades, tt.wantTrades)
+		}
+		if len(g.conditions) != 0 {
+			t.Errorf("%v: %v orders are still watched", tt.name, len(g.conditions))
+		}
+	}
+
+	e := &tickerExchange{name: "venue"}
+	g := conditionGlobal(t, 4200, e)
+	stop, _ := g.StopLoss(e, "sell", "btc/usdt", 1, 95).(int64)
+	if g.LinkOCO(stop, stop) || g.LinkOCO(stop, 999999) {
+		t.Errorf("LinkOCO() of an invalid order = true")
+	}
+	target, _ := g.TakeProfit(e, "sell", "btc/usdt", 1, 110).(int64)
+	g.LinkOCO(stop, target)
+	restarted := conditionGlobal(t, 4200, e)
+	restarted.loadConditions()
+	if len(restarted.conditions) != 2 || restarted.conditions[0].ID != stop || restarted.conditions[0].OCO != target {
+		t.Errorf("the loaded conditional orders are %+v", restarted.conditions)
+	}
+	if !restarted.CancelConditionalOrder(stop) || restarted.CancelConditionalOrder(stop) {
+		t.Errorf("CancelConditionalOrder() should cancel a waiting order once")
+	}
+	if err := CancelConditionalOrder(4200, target); err != nil {
+		t.Errorf("CancelConditionalOrder() of a stopped trader error = %v", err)
+	}
+	if err := CancelConditionalOrder(4200, stop); err == nil {
+		t.Errorf("CancelConditionalOrder() of a canceled order error = nil")
+	}
+}
@@ -0,0 +1,59 @@
+package trader
+
+import (
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/miaolz123/conver"
+)
+
+// SetFee sets the fee rate of an exchange used by GetConsolidatedTicker, like 0.001 for 0.1%
+func (g *Global) SetFee(exchange api.Exchange, rate interface{}) bool {
+	if exchange == nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "SetFee(), Invalid exchange")
+		return false
+	}
+	if g.fees == nil {
+		g.fees = make(map[api.Exchange]float64)
+	}
+	g.fees[exchange] = conver.Float64Must(rate)
+	return true
+}
+
+// feeRates returns the fee rates of the exchanges in the order of Es
+func (g *Global) feeRates() []float64 {
+	fees := make([]float64, len(g.es))
+	for i, e := range g.es {
+		fees[i] = g.fees[e]
+	}
+	return fees
+}
+
+// GetConsolidatedTicker merges the depth of all the exchanges of the stock type, the prices are adjusted by the fees
+func (g *Global) GetConsolidatedTicker(stockType string, sizes ...interface{}) interface{} {
+	size := 20
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	fees := g.feeRates()
+	ticker := api.Consolidate(g.es, fees, stockType, size)
+	if ticker.BuyIndex < 0 && ticker.SellIndex < 0 {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "GetConsolidatedTicker(), no exchange returns the depth of ", stockType)
+		return false
+	}
+	return ticker
+}
+
+// Route splits an order across the exchanges by their consolidated depth and returns the aggregate fill
+func (g *Global) Route(tradeType, stockType string, amount interface{}, sizes ...interface{}) interface{} {
+	size := 20
+	if len(sizes) > 0 && conver.IntMust(sizes[0]) > 0 {
+		size = conver.IntMust(sizes[0])
+	}
+	fees := g.feeRates()
+	result, err := api.Route(g.es, fees, tradeType, stockType, conver.Float64Must(amount), size)
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Route() error, ", err)
+		return false
+	}
+	return result
+}
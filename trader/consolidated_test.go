@@ -0,0 +1,56 @@
+package trader
+
+import (
+	"math"
+	"testing"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/model"
+)
+
+func TestGetConsolidatedTicker(t *testing.T) {
+	cheap := fakeExchange(nil, map[string]float64{"BTC/USDT": 100})
+	dear := fakeExchange(nil, map[string]float64{"BTC/USDT": 101})
+	tests := []struct {
+		name                        string
+		fees                        map[api.Exchange]interface{}
+		stockType                   string
+		wantBuy, wantSell           float64
+		wantBuyIndex, wantSellIndex int
+		wantOK                      bool
+	}{
+		{"no fees", nil, "BTC/USDT", 101, 100, 1, 0, true},
+		{"a fee on the cheap venue", map[api.Exchange]interface{}{cheap: 0.02}, "BTC/USDT", 101, 101, 1, 1, true},
+		{"fees on both venues", map[api.Exchange]interface{}{cheap: "0.001", dear: 0.001}, "BTC/USDT", 100.899, 100.1, 1, 0, true},
+		{"no depth", nil, "ETH/USDT", 0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}, es: []api.Exchange{cheap, dear}}
+		for e, fee := range tt.fees {
+			if !g.SetFee(e, fee) {
+				t.Errorf("%v: SetFee() = false", tt.name)
+			}
+		}
+		got := g.GetConsolidatedTicker(tt.stockType, 5)
+		ticker, ok := got.(api.ConsolidatedTicker)
+		if ok != tt.wantOK {
+			t.Errorf("%v: GetConsolidatedTicker() = %+v, want ok %v", tt.name, got, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if round8(ticker.Buy) != tt.wantBuy || round8(ticker.Sell) != tt.wantSell || ticker.BuyIndex != tt.wantBuyIndex || ticker.SellIndex != tt.wantSellIndex {
+			t.Errorf("%v: GetConsolidatedTicker() = %v@%v %v@%v, want %v@%v %v@%v", tt.name, ticker.Buy, ticker.BuyIndex, ticker.Sell, ticker.SellIndex, tt.wantBuy, tt.wantBuyIndex, tt.wantSell, tt.wantSellIndex)
+		}
+	}
+	g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}}
+	if g.SetFee(nil, 0.001) {
+		t.Errorf("SetFee(nil) = true, want false")
+	}
+}
+
+// round8 rounds away the float error of the fee adjusted prices
+func round8(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
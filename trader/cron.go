@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cron is a parsed standard cron expression: minute hour day-of-month month day-of-week
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronFields are the ranges of the cron fields
+var cronFields = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCron parses a cron expression like "30 9 * * 1-5", supports "*", "a-b", "*/n", "a-b/n" and lists
+func parseCron(expr string) (c cron, err error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return c, fmt.Errorf("Invalid cron expression %q, it must have 5 fields", expr)
+	}
+	bits := [5]uint64{}
+	for i, f := range fields {
+		if bits[i], err = parseCronField(f, cronFields[i][0], cronFields[i][1]); err != nil {
+			return c, fmt.Errorf("Invalid cron expression %q, %v", expr, err)
+		}
+	}
+	if bits[4]&(1<<7) > 0 {
+		bits[4] |= 1 //7 和 0 都表示周日
+	}
+	c = cron{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}
+	return
+}
+
+func parseCronField(field string, min, max int) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			part = part[:i]
+		}
+		begin, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			if begin, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			end = begin
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				end = max
+			}
+		}
+		if begin < min || end > max || begin > end {
+			return 0, fmt.Errorf("value %q out of range [%v, %v]", part, min, max)
+		}
+		for v := begin; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return
+}
+
+// match reports whether the time matches the cron expression, to the minute
+func (c cron) match(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<uint(t.Day())) > 0
+	dow := c.dow&(1<<uint(t.Weekday())) > 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
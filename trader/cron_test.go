@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"30 9 * * 1-5", false},
+		{"*/15 0-6,18-23 1 */2 0,7", false},
+		{"0 9-17/2 * * *", false},
+		{"5/10 * * * *", false},
+		{"", true},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"1-a * * * *", true},
+	}
+	for _, tt := range tests {
+		if _, err := parseCron(tt.expr); (err != nil) != tt.wantErr {
+			t.Errorf("parseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCronMatch(t *testing.T) {
+	monday := time.Date(2018, 1, 1, 9, 30, 0, 0, time.UTC)
+	sunday := time.Date(2018, 1, 7, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		time time.Time
+		want bool
+	}{
+		{"* * * * *", monday, true},
+		{"30 9 * * *", monday, true},
+		{"31 9 * * *", monday, false},
+		{"30 10 * * *", monday, false},
+		{"30 9 * * 1-5", monday, true},
+		{"30 9 * * 1-5", sunday, false},
+		{"30 9 * * 0", sunday, true},
+		{"30 9 * * 7", sunday, true},
+		{"*/15 * * * *", monday, true},
+		{"*/20 * * * *", monday, false},
+		{"10/20 * * * *", monday, true},
+		{"0,30 9 * * *", monday, true},
+		{"30 9 * 2 *", monday, false},
+		{"30 9 1 * *", monday, true},
+		{"30 9 2 * *", monday, false},
+		// 日和星期都指定时满足任意一个即可
+		{"30 9 2 * 1", monday, true},
+		{"30 9 2 * 3", sunday, false},
+		{"30 9 7 * 1", sunday, true},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.match(tt.time); got != tt.want {
+			t.Errorf("%q.match(%v) = %v, want %v", tt.expr, tt.time, got, tt.want)
+		}
+	}
+}
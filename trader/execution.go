@@ -0,0 +1,450 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/miaolz123/conver"
+)
+
+// icebergInterval is how often an iceberg checks its visible order
+const icebergInterval = time.Second
+
+// maxFinishedExecutions is how many finished executions a trader keeps for GetExecution, older ones are dropped
+const maxFinishedExecutions = 100
+
+// the status of an execution
+const (
+	executionRunning  = "running"
+	executionDone     = "done"
+	executionCanceled = "canceled"
+	executionStopped  = "stopped" //机器人停止
+)
+
+// Execution is the status of a parent order executed by TWAP, VWAP or Iceberg
+type Execution struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`     //TWAP, VWAP 或 ICEBERG
+	Exchange   string    `json:"exchange"` //交易所名称
+	TradeType  string    `json:"tradeType"`
+	StockType  string    `json:"stockType"`
+	Amount     float64   `json:"amount"`
+	Price      float64   `json:"price"` //限价, 0 表示按对手价
+	DealAmount float64   `json:"dealAmount"`
+	Orders     int       `json:"orders"` //已下的子订单数量
+	Status     string    `json:"status"`
+	Message    string    `json:"message"`
+	StartedAt  time.Time `json:"startedAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// execution is a running parent order
+type execution struct {
+	Execution
+	mu       sync.Mutex
+	exchange api.Exchange
+	cancel   chan struct{}
+	child    string    //未结算的子订单ID
+	weights  []float64 //TWAP, VWAP 每个时间片的数量占比
+	interval time.Duration
+	visible  float64 //ICEBERG 每次显示的数量
+}
+
+// TWAP executes the amount in equal slices over the duration in milliseconds, it returns the id of the execution
+func (g *Global) TWAP(exchange api.Exchange, tradeType, stockType string, amount, duration interface{}, args ...interface{}) interface{} {
+	slices := 10
+	if len(args) > 0 && conver.IntMust(args[0]) > 0 {
+		slices = conver.IntMust(args[0])
+	}
+	x, err := g.newExecution("TWAP", exchange, tradeType, stockType, amount, limit(args)...)
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "TWAP() error, ", err)
+		return false
+	}
+	ms := conver.Int64Must(duration)
+	if ms <= 0 {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "TWAP() error, invalid duration")
+		return false
+	}
+	x.interval = time.Duration(ms) * time.Millisecond / time.Duration(slices)
+	for i := 0; i < slices; i++ {
+		x.weights = append(x.weights, 1)
+	}
+	return g.startExecution(x, x.runSlices)
+}
+
+// VWAP executes the amount over the duration in milliseconds in slices of the period, the slices are weighted by
+// the average volume of the same time of the day in the records of the period, it returns the id of the execution
+func (g *Global) VWAP(exchange api.Exchange, tradeType, stockType string, amount, duration interface{}, args ...interface{}) interface{} {
+	period := "M15"
+	if len(args) > 0 && fmt.Sprint(args[0]) != "" {
+		period = fmt.Sprint(args[0])
+	}
+	x, err := g.newExecution("VWAP", exchange, tradeType, stockType, amount, limit(args)...)
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "VWAP() error, ", err)
+		return false
+	}
+	seconds := api.PeriodSeconds(period)
+	ms := conver.Int64Must(duration)
+	if seconds <= 0 || ms <= 0 {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "VWAP() error, invalid period or duration")
+		return false
+	}
+	slices := int(math.Ceil(float64(ms) / float64(seconds*1000)))
+	x.interval = time.Duration(ms) * time.Millisecond / time.Duration(slices)
+	x.weights = volumeProfile(exchange, x.StockType, period, seconds, slices, time.Now().Unix())
+	return g.startExecution(x, x.runSlices)
+}
+
+// Iceberg executes the amount at the price showing no more than the visible amount at a time,
+// it returns the id of the execution
+func (g *Global) Iceberg(exchange api.Exchange, tradeType, stockType string, amount, price, visible interface{}) interface{} {
+	x, err := g.newExecution("ICEBERG", exchange, tradeType, stockType, amount, price)
+	if err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Iceberg() error, ", err)
+		return false
+	}
+	x.visible = conver.Float64Must(visible)
+	if x.Price <= 0 || x.visible <= 0 {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Iceberg() error, invalid price or visible amount")
+		return false
+	}
+	x.interval = icebergInterval
+	return g.startExecution(x, x.runIceberg)
+}
+
+// GetExecution returns the status of an execution
+func (g *Global) GetExecution(id interface{}) interface{} {
+	x := g.execution(conver.Int64Must(id))
+	if x == nil {
+		return false
+	}
+	return x.status()
+}
+
+// GetExecutions returns the status of all the executions
+func (g *Global) GetExecutions() (list []Execution) {
+	g.executionMu.Lock()
+	defer g.executionMu.Unlock()
+	for _, x := range g.executions {
+		list = append(list, x.status())
+	}
+	return
+}
+
+// CancelExecution stops an execution and cancels its open child order
+func (g *Global) CancelExecution(id interface{}) bool {
+	x := g.execution(conver.Int64Must(id))
+	if x == nil {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.Status != executionRunning {
+		return false
+	}
+	select {
+	case <-x.cancel:
+	default:
+		close(x.cancel)
+	}
+	return true
+}
+
+// GetExecutions returns the executions of the trader
+func GetExecutions(id int64) (list []Execution) {
+	if t := getExecutor(id); t != nil {
+		list = t.GetExecutions()
+	}
+	return
+}
+
+// CancelExecution cancels an execution of the trader
+func CancelExecution(id, executionID int64) (err error) {
+	t := getExecutor(id)
+	if t == nil {
+		return fmt.Errorf("Can not found the Trader")
+	}
+	if !t.CancelExecution(executionID) {
+		return fmt.Errorf("The execution is not running")
+	}
+	return
+}
+
+// limit returns the optional limit price after the first optional argument of TWAP and VWAP
+func limit(args []interface{}) []interface{} {
+	if len(args) > 1 {
+		return args[1:]
+	}
+	return nil
+}
+
+func (g *Global) newExecution(typ string, exchange api.Exchange, tradeType, stockType string, amount interface{}, price ...interface{}) (x *execution, err error) {
+	if exchange == nil {
+		return nil, fmt.Errorf("invalid exchange")
+	}
+	if g.backtest != nil {
+		return nil, fmt.Errorf("%v is not supported in a backtest", typ)
+	}
+	x = &execution{
+		Execution: Execution{
+			Type:      typ,
+			Exchange:  exchange.GetName(),
+			TradeType: strings.ToUpper(tradeType),
+			StockType: strings.ToUpper(stockType),
+			Amount:    conver.Float64Must(amount),
+			Status:    executionRunning,
+		},
+		exchange: exchange,
+		cancel:   make(chan struct{}),
+	}
+	if len(price) > 0 {
+		x.Price = conver.Float64Must(price[0])
+	}
+	if x.TradeType != constant.TradeTypeBuy && x.TradeType != constant.TradeTypeSell {
+		return nil, fmt.Errorf("unrecognized tradeType: %v", tradeType)
+	}
+	if x.Amount <= 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	return
+}
+
+func (g *Global) startExecution(x *execution, run func(g *Global)) int64 {
+	g.executionMu.Lock()
+	g.executionSeq++
+	x.ID = g.executionSeq
+	x.StartedAt, x.UpdatedAt = time.Now(), time.Now()
+	g.pruneExecutions()
+	g.executions = append(g.executions, x)
+	g.executionMu.Unlock()
+	g.Logger.Log(constant.INFO, x.StockType, x.Price, x.Amount, x.Type, " #", x.ID, " started, ", x.TradeType, " on ", x.Exchange)
+	go run(g)
+	return x.ID
+}
+
+// pruneExecutions drops the finished executions except the latest maxFinishedExecutions, executionMu must be held
+func (g *Global) pruneExecutions() {
+	finished := 0
+	for i := len(g.executions) - 1; i >= 0; i-- {
+		if g.executions[i].status().Status != executionRunning {
+			finished++
+			if finished > maxFinishedExecutions {
+				g.executions = append(g.executions[:i], g.executions[i+1:]...)
+			}
+		}
+	}
+}
+
+func (g *Global) execution(id int64) *execution {
+	g.executionMu.Lock()
+	defer g.executionMu.Unlock()
+	for _, x := range g.executions {
+		if x.ID == id {
+			return x
+		}
+	}
+	return nil
+}
+
+// volumeProfile returns the weights of the slices from now (unix seconds) by the average volume of the same time of the day
+func volumeProfile(exchange api.Exchange, stockType, period string, seconds int64, slices int, now int64) (weights []float64) {
+	volumes, counts := make(map[int64]float64), make(map[int64]float64)
+	size := 86400 / seconds * 7 //一周
+	if size > 1000 {
+		size = 1000
+	}
+	if records, ok := exchange.GetRecords(stockType, period, size).([]api.Record); ok {
+		for _, r := range records {
+			t := r.Time
+			if t > 1e11 { //毫秒时间戳
+				t /= 1000
+			}
+			slot := t % 86400 / seconds
+			volumes[slot] += r.Volume
+			counts[slot]++
+		}
+	}
+	total := 0.0
+	for i := 0; i < slices; i++ {
+		slot := (now + int64(i)*seconds) % 86400 / seconds
+		w := 0.0
+		if counts[slot] > 0 {
+			w = volumes[slot] / counts[slot]
+		}
+		weights = append(weights, w)
+		total += w
+	}
+	if total <= 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	return
+}
+
+// status returns a copy of the status
+func (x *execution) status() Execution {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.Execution
+}
+
+// wait waits for the duration, it returns false if the execution is canceled or the trader is stopped
+func (x *execution) wait(g *Global, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-x.cancel:
+		x.finish(g, executionCanceled, "")
+	case <-g.halt:
+		x.finish(g, executionStopped, "")
+	}
+	return false
+}
+
+// runSlices places one child order for every slice, the amount of a slice includes the part not filled before
+func (x *execution) runSlices(g *Global) {
+	total, done := 0.0, 0.0
+	for _, w := range x.weights {
+		total += w
+	}
+	for i, w := range x.weights {
+		if i > 0 && !x.wait(g, x.interval) {
+			return
+		}
+		done += w
+		if !x.settle() {
+			continue
+		}
+		target := x.Amount * done / total
+		if i == len(x.weights)-1 {
+			target = x.Amount
+		}
+		x.place(target-x.status().DealAmount, i == len(x.weights)-1)
+	}
+	if !x.wait(g, x.interval) {
+		return
+	}
+	x.finish(g, executionDone, "")
+}
+
+// runIceberg places the next visible order when the last one is filled
+func (x *execution) runIceberg(g *Global) {
+	for {
+		if x.child != "" {
+			if o, ok := x.exchange.GetOrder(x.StockType, x.child).(api.Order); !ok || o.DealAmount < o.Amount {
+				if !x.wait(g, x.interval) {
+					return
+				}
+				continue
+			}
+		}
+		if !x.settle() {
+			if !x.wait(g, x.interval) {
+				return
+			}
+			continue
+		}
+		remain := x.Amount - x.status().DealAmount
+		if remain <= 0 {
+			x.finish(g, executionDone, "")
+			return
+		}
+		x.place(math.Min(remain, x.visible), true)
+		if !x.wait(g, x.interval) {
+			return
+		}
+	}
+}
+
+// place places a child order at the best opposite price, limited by the price of the execution,
+// an amount below the min amount is left to the next slice unless it is the last
+func (x *execution) place(amount float64, last bool) {
+	if amount <= 0 {
+		return
+	}
+	minAmount := x.exchange.GetMinAmount(x.StockType)
+	if amount < minAmount {
+		if last {
+			x.setMessage(fmt.Sprintf("the remaining amount %v is below the min amount %v", amount, minAmount))
+		}
+		return
+	}
+	price := x.Price
+	if x.Type != "ICEBERG" {
+		ticker, ok := x.exchange.GetTicker(x.StockType).(api.Ticker)
+		if !ok {
+			x.setMessage("GetTicker() failed")
+			return
+		}
+		if x.TradeType == constant.TradeTypeBuy && (price <= 0 || ticker.Sell < price) {
+			price = ticker.Sell
+		}
+		if x.TradeType == constant.TradeTypeSell && (price <= 0 || ticker.Buy > price) {
+			price = ticker.Buy
+		}
+	}
+	id, ok := x.exchange.Trade(x.TradeType, x.StockType, price, amount, x.Type, " #", x.ID).(string)
+	if !ok {
+		x.setMessage("Trade() failed")
+		return
+	}
+	x.mu.Lock()
+	x.child = id
+	x.Orders++
+	x.UpdatedAt = time.Now()
+	x.mu.Unlock()
+}
+
+// settle cancels the open part of the child order and adds its deal amount, it returns false if the child order
+// can not be read and is left to the next time
+func (x *execution) settle() bool {
+	if x.child == "" {
+		return true
+	}
+	o, ok := x.exchange.GetOrder(x.StockType, x.child).(api.Order)
+	if !ok {
+		x.setMessage("GetOrder() failed")
+		return false
+	}
+	if o.DealAmount < o.Amount {
+		x.exchange.CancelOrder(o)
+		if final, ok := x.exchange.GetOrder(x.StockType, x.child).(api.Order); ok {
+			o = final
+		}
+	}
+	x.mu.Lock()
+	x.DealAmount += o.DealAmount
+	x.child = ""
+	x.UpdatedAt = time.Now()
+	x.mu.Unlock()
+	return true
+}
+
+func (x *execution) setMessage(msg string) {
+	x.mu.Lock()
+	x.Message = msg
+	x.UpdatedAt = time.Now()
+	x.mu.Unlock()
+}
+
+// finish settles the open child order and ends the execution
+func (x *execution) finish(g *Global, status, msg string) {
+	x.settle()
+	x.mu.Lock()
+	x.Status = status
+	if msg != "" {
+		x.Message = msg
+	}
+	x.UpdatedAt = time.Now()
+	s := x.Execution
+	x.mu.Unlock()
+	g.Logger.Log(constant.INFO, s.StockType, s.Price, s.DealAmount, s.Type, " #", s.ID, " ", s.Status, ", deal ", s.DealAmount, " of ", s.Amount)
+}
@@ -0,0 +1,263 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// sliceExchange fills every child order by the fill ratio and records the amounts
+type sliceExchange struct {
+	api.Exchange
+	records interface{}
+	min     float64
+	fill    float64
+	mu      sync.Mutex
+	orders  []api.Order
+}
+
+func (e *sliceExchange) GetName() string {
+	return "slice"
+}
+
+func (e *sliceExchange) GetMinAmount(stock string) float64 {
+	return e.min
+}
+
+func (e *sliceExchange) GetRecords(stockType, period string, sizes ...interface{}) interface{} {
+	return e.records
+}
+
+func (e *sliceExchange) GetTicker(stockType string, sizes ...interface{}) interface{} {
+	return api.Ticker{Buy: 99, Sell: 101}
+}
+
+func (e *sliceExchange) Trade(tradeType string, stockType string, price, amount interface{}, msgs ...interface{}) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	o := api.Order{ID: fmt.Sprint(len(e.orders) + 1), Price: price.(float64), Amount: amount.(float64), TradeType: tradeType, StockType: stockType}
+	o.DealAmount = o.Amount * e.fill
+	e.orders = append(e.orders, o)
+	return o.ID
+}
+
+func (e *sliceExchange) GetOrder(stockType, id string) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, o := range e.orders {
+		if o.ID == id {
+			return o
+		}
+	}
+	return false
+}
+
+func (e *sliceExchange) CancelOrder(order api.Order) bool {
+	return true
+}
+
+// amounts returns the rounded amounts of the child orders
+func (e *sliceExchange) amounts() (amounts []float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, o := range e.orders {
+		amounts = append(amounts, math.Round(o.Amount*1e8)/1e8)
+	}
+	return
+}
+
+func TestVolumeProfile(t *testing.T) {
+	const now = 1500000000 // 当天的第 9600 秒, 即 H1 的第 2 个时间片
+	day := int64(now - now%86400)
+	tests := []struct {
+		name    string
+		records interface{}
+		want    []float64
+	}{
+		{
+			name: "the average volume of the same hour",
+			records: []api.Record{
+				{Time: day - 86400 + 7200, Volume: 20},
+				{Time: day + 7200, Volume: 10},
+				{Time: day - 86400 + 10800, Volume: 5},
+				{Time: day - 86400 + 18000, Volume: 100},
+			},
+			want: []float64{15, 5, 0},
+		},
+		{
+			name: "millisecond times",
+			records: []api.Record{
+				{Time: (day + 7200) * 1000, Volume: 4},
+				{Time: (day + 14400) * 1000, Volume: 8},
+			},
+			want: []float64{4, 0, 8},
+		},
+		{
+			name:    "no volume in the slices",
+			records: []api.Record{{Time: day + 18000, Volume: 100}},
+			want:    []float64{1, 1, 1},
+		},
+		{
+			name:    "no records",
+			records: []api.Record{},
+			want:    []float64{1, 1, 1},
+		},
+		{
+			name:    "GetRecords failed",
+			records: false,
+			want:    []float64{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		got := volumeProfile(&sliceExchange{records: tt.records}, "BTC/USDT", "H1", 3600, 3, now)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: volumeProfile() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunSlices(t *testing.T) {
+	tests := []struct {
+		name        string
+		weights     []float64
+		amount      float64
+		min         float64
+		fill        float64
+		wantAmounts []float64
+		wantDeal    float64
+		wantMessage string
+	}{
+		{"equal slices", []float64{1, 1, 1, 1}, 10, 0, 1, []float64{2.5, 2.5, 2.5, 2.5}, 10, ""},
+		{"weighted slices", []float64{15, 5, 0, 20}, 8, 0, 1, []float64{3, 1, 4}, 8, ""},
+		{"the part not filled moves to the next slice", []float64{1, 1}, 10, 0, 0.5, []float64{5, 7.5}, 6.25, ""},
+		{"a slice below the min amount waits", []float64{1, 1, 1, 1}, 1, 0.3, 1, []float64{0.5, 0.5}, 1, ""},
+		{"the last part below the min amount", []float64{3, 1}, 1, 0.7, 0.5, []float64{0.75}, 0.375, "the remaining amount 0.625 is below the min amount 0.7"},
+	}
+	for _, tt := range tests {
+		e := &sliceExchange{min: tt.min, fill: tt.fill}
+		g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}, halt: make(chan struct{})}
+		x := &execution{
+			Execution: Execution{Type: "TWAP", TradeType: "BUY", StockType: "BTC/USDT", Amount: tt.amount, Status: executionRunning},
+			exchange:  e,
+			cancel:    make(chan struct{}),
+			weights:   tt.weights,
+			interval:  time.Millisecond,
+		}
+		x.runSlices(g)
+		s := x.status()
+		if got := e.amounts(); !reflect.DeepEqual(got, tt.wantAmounts) {
+			t.Errorf("%v: child amounts = %v, want %v", tt.name, got, tt.wantAmounts)
+		}
+		if math.Abs(s.DealAmount-tt.wantDeal) > 1e-9 || s.Status != executionDone || s.Message != tt.wantMessage || s.Orders != len(tt.wantAmounts) {
+			t.Errorf("%v: status = %+v, want deal %v done %q", tt.name, s, tt.wantDeal, tt.wantMessage)
+		}
+	}
+}
+
+func TestExecutionSlices(t *testing.T) {
+	tests := []struct {
+		name         string
+		start        func(g *Global, e api.Exchange) interface{}
+		wantWeights  []float64
+		wantInterval time.Duration
+	}{
+		{
+			name:         "TWAP default slices",
+			start:        func(g *Global, e api.Exchange) interface{} { return g.TWAP(e, "buy", "btc/usdt", 10, 60000) },
+			wantWeights:  []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+			wantInterval: 6 * time.Second,
+		},
+		{
+			name:         "TWAP slices",
+			start:        func(g *Global, e api.Exchange) interface{} { return g.TWAP(e, "sell", "btc/usdt", 10, 60000, 4, 100) },
+			wantWeights:  []float64{1, 1, 1, 1},
+			wantInterval: 15 * time.Second,
+		},
+		{
+			name:         "VWAP slices of the period",
+			start:        func(g *Global, e api.Exchange) interface{} { return g.VWAP(e, "buy", "btc/usdt", 10, 3600000) },
+			wantWeights:  []float64{1, 1, 1, 1},
+			wantInterval: 15 * time.Minute,
+		},
+		{
+			name:         "VWAP a part of a period",
+			start:        func(g *Global, e api.Exchange) interface{} { return g.VWAP(e, "buy", "btc/usdt", 10, 5400000, "H1") },
+			wantWeights:  []float64{1, 1},
+			wantInterval: 45 * time.Minute,
+		},
+		{
+			name:  "invalid duration",
+			start: func(g *Global, e api.Exchange) interface{} { return g.TWAP(e, "buy", "btc/usdt", 10, 0) },
+		},
+		{
+			name:  "invalid period",
+			start: func(g *Global, e api.Exchange) interface{} { return g.VWAP(e, "buy", "btc/usdt", 10, 60000, "X") },
+		},
+		{
+			name:  "invalid trade type",
+			start: func(g *Global, e api.Exchange) interface{} { return g.TWAP(e, "hold", "btc/usdt", 10, 60000) },
+		},
+	}
+	for _, tt := range tests {
+		e := &sliceExchange{records: false, fill: 1}
+		g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}, halt: make(chan struct{})}
+		id := tt.start(g, e)
+		if tt.wantWeights == nil {
+			if id != false {
+				t.Errorf("%v: = %v, want false", tt.name, id)
+			}
+			continue
+		}
+		x := g.execution(id.(int64))
+		if x == nil {
+			t.Errorf("%v: no execution of %v", tt.name, id)
+			continue
+		}
+		if !reflect.DeepEqual(x.weights, tt.wantWeights) || x.interval != tt.wantInterval {
+			t.Errorf("%v: slices = %v every %v, want %v every %v", tt.name, x.weights, x.interval, tt.wantWeights, tt.wantInterval)
+		}
+		if !g.CancelExecution(id) {
+			t.Errorf("%v: CancelExecution() = false", tt.name)
+		}
+		for i := 0; i < 100 && g.GetExecution(id).(Execution).Status == executionRunning; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if s := g.GetExecution(id).(Execution); s.Status != executionCanceled || s.Orders != 1 || s.DealAmount != s.Amount/float64(len(tt.wantWeights)) {
+			t.Errorf("%v: status after cancel = %+v", tt.name, s)
+		}
+	}
+}
+
+func TestPruneExecutions(t *testing.T) {
+	g := &Global{Logger: model.Logger{Sink: func(model.Log) {}}, halt: make(chan struct{})}
+	for i := 1; i <= maxFinishedExecutions+10; i++ {
+		status := executionDone
+		if i == 1 || i == 50 {
+			status = executionRunning
+		}
+		g.executions = append(g.executions, &execution{Execution: Execution{ID: int64(i), Status: status}})
+	}
+	g.executionSeq = int64(len(g.executions))
+	done := make(chan struct{})
+	id := g.startExecution(&execution{Execution: Execution{Status: executionRunning}}, func(*Global) { close(done) })
+	<-done
+	if n := len(g.GetExecutions()); n != maxFinishedExecutions+3 {
+		t.Errorf("%v executions are kept, want %v", n, maxFinishedExecutions+3)
+	}
+	for _, id := range []int64{1, 50, 10, id} {
+		if g.GetExecution(id) == false {
+			t.Errorf("the execution %v is dropped", id)
+		}
+	}
+	for _, id := range []int64{2, 9} {
+		if g.GetExecution(id) != false {
+			t.Errorf("the execution %v is kept", id)
+		}
+	}
+}
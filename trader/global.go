@@ -1,17 +1,18 @@
 package trader
 
 import (
-	//"encoding/json"
-	//"fmt"
+	"encoding/json"
+	"fmt"
 	"log"
-	//"reflect"
+	"reflect"
 	"sync"
 	"time"
 
-	"github.com/miaolz123/conver"
 	"github.com/geniustag/QuantBot/api"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/notify"
+	"github.com/miaolz123/conver"
 	"github.com/robertkrimen/otto"
 )
 
@@ -20,15 +21,47 @@ type Tasks map[string][]task
 // Global ...
 type Global struct {
 	model.Trader
-	Logger  model.Logger   //利用这个对象保存日志
-	ctx     *otto.Otto     //js虚拟机
-	es      []api.Exchange //交易所列表
-	tasks   Tasks          //任务列表
-	running bool
-	//statusLog string
+	Logger   model.Logger   //利用这个对象保存日志
+	Store    *storage       //持久化的键值存储
+	ctx      *otto.Otto     //js虚拟机
+	es       []api.Exchange //交易所列表
+	tasks    Tasks          //任务列表
+	running  bool
+	panel    Panel                    //LogStatus 输出的状态栏
+	panelMu  sync.Mutex               //任务并发时保护状态栏
+	loops    []*loop                  //Every, OnInterval 和 OnBar 添加的循环
+	loopSeq  int                      //Every 生成循环名称的序号
+	halt     chan struct{}            //停止时关闭
+	haltOnce sync.Once                //保证 halt 只关闭一次
+	commands chan string              //Trader.Command 发送的待处理命令
+	fees     map[api.Exchange]float64 //SetFee 设置的各交易所手续费率
+
+	executions   []*execution //TWAP, VWAP 和 Iceberg 启动的母订单
+	executionMu  sync.Mutex
+	executionSeq int64
+
+	conditions      []*model.ConditionalOrder //等待触发的条件单
+	conditionMu     sync.Mutex
+	conditionSaveMu sync.Mutex //保存条件单时持有, 保证最后保存的是最新的状态
+
+	backtest *backtest //回测时的模拟时钟, 实盘时为空
 }
 
-//js中的一个任务,目的是可以并发工作
+// Panel is the live status panel set by LogStatus
+type Panel struct {
+	Text      string       `json:"text"`
+	Tables    []PanelTable `json:"tables"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// PanelTable is a table of the status panel
+type PanelTable struct {
+	Title   string          `json:"title"`
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// js中的一个任务,目的是可以并发工作
 type task struct {
 	ctx  *otto.Otto    //js虚拟机
 	fn   otto.Value    //代表该任务的js函数
@@ -41,15 +74,41 @@ func (g *Global) Sleep(intervals ...interface{}) {
 	if len(intervals) > 0 {
 		interval = conver.Int64Must(intervals[0])
 	}
+	if g.backtest != nil {
+		if interval <= 0 {
+			interval = g.backtest.step
+		}
+		g.backtest.advance(g.backtest.now + interval)
+		return
+	}
 	if interval > 0 {
-		time.Sleep(time.Duration(interval * 1000000))
+		timeout := time.After(time.Duration(interval * 1000000))
+		for {
+			select {
+			case <-timeout:
+				return
+			case <-g.halt:
+				return
+			case cmd := <-g.commandQueue():
+				g.callCommand(cmd)
+			}
+		}
 	} else {
+		g.dispatchCommands()
 		for _, e := range g.es {
 			e.AutoSleep()
 		}
 	}
 }
 
+// now returns the simulated time in a backtest, otherwise the current time
+func (g *Global) now() time.Time {
+	if g.backtest != nil {
+		return time.Unix(0, g.backtest.now*int64(time.Millisecond))
+	}
+	return time.Now()
+}
+
 // Console ...
 func (g *Global) Console(msgs ...interface{}) {
 	log.Printf("%v %v\n", constant.INFO, msgs)
@@ -70,23 +129,83 @@ func (g *Global) LogProfit(msgs ...interface{}) {
 }
 
 // LogStatus ...
-//func (g *Global) LogStatus(msgs ...interface{}) {
-//	go func() {
-//		msg := ""
-//		for _, m := range msgs {
-//			v := reflect.ValueOf(m)
-//			switch v.Kind() {
-//			case reflect.Struct, reflect.Map, reflect.Slice:
-//				if bs, err := json.Marshal(m); err == nil {
-//					msg += string(bs)
-//					continue
-//				}
-//			}
-//			msg += fmt.Sprintf("%+v", m)
-//		}
-//		g.statusLog = msg
-//	}()
-//}
+func (g *Global) LogStatus(msgs ...interface{}) {
+	panel := Panel{UpdatedAt: g.now()}
+	for _, m := range msgs {
+		if table, ok := toPanelTable(m); ok {
+			panel.Tables = append(panel.Tables, table)
+			continue
+		}
+		v := reflect.ValueOf(m)
+		switch v.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice:
+			if bs, err := json.Marshal(m); err == nil {
+				panel.Text += string(bs)
+				continue
+			}
+		}
+		panel.Text += fmt.Sprintf("%+v", m)
+	}
+	g.panelMu.Lock()
+	g.panel = panel
+	g.panelMu.Unlock()
+	model.Publish(model.Event{TraderID: g.ID, Type: constant.EventPanel, Data: panel})
+}
+
+// toPanelTable converts a js object like {title: "", columns: [], rows: [[]]} to a table
+func toPanelTable(m interface{}) (table PanelTable, ok bool) {
+	obj, ok := m.(map[string]interface{})
+	if !ok {
+		return
+	}
+	columns, ok := toSlice(obj["columns"])
+	if !ok {
+		return
+	}
+	table.Title, _ = obj["title"].(string)
+	for _, c := range columns {
+		table.Columns = append(table.Columns, fmt.Sprint(c))
+	}
+	rows, _ := toSlice(obj["rows"])
+	for _, r := range rows {
+		if row, ok := toSlice(r); ok {
+			table.Rows = append(table.Rows, row)
+		} else {
+			table.Rows = append(table.Rows, []interface{}{r})
+		}
+	}
+	return
+}
+
+// toSlice converts the exported js array, which may be typed like []string, to []interface{}
+func toSlice(v interface{}) (s []interface{}, ok bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return
+	}
+	for i := 0; i < rv.Len(); i++ {
+		s = append(s, rv.Index(i).Interface())
+	}
+	return s, true
+}
+
+// Notify sends the message to the named notify channel
+func (g *Global) Notify(channel string, msgs ...interface{}) bool {
+	if g.backtest != nil {
+		g.Logger.Log(constant.INFO, "", 0.0, 0.0, "Notify() is skipped in a backtest, ", msgs)
+		return true
+	}
+	message := ""
+	for _, m := range msgs {
+		message += fmt.Sprintf("%+v", m)
+	}
+	title := fmt.Sprintf("[%v] %v", constant.Banner, g.Name)
+	if err := notify.Send(g.UserID, channel, title, message); err != nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Notify() error, ", err)
+		return false
+	}
+	return true
+}
 
 // AddTask ...
 func (g *Global) AddTask(group otto.Value, fn otto.Value, args ...interface{}) bool {
@@ -0,0 +1,141 @@
+package trader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/miaolz123/conver"
+	"github.com/robertkrimen/otto"
+)
+
+// onBarInterval is how often OnBar loops poll the records
+const onBarInterval = time.Second
+
+// loop is a callback scheduled by Every, OnInterval or OnBar
+type loop struct {
+	name     string
+	interval time.Duration
+	next     time.Time
+	fn       otto.Value
+
+	exchange api.Exchange //OnBar 使用的交易所
+	stock    string
+	period   string
+	lastBar  int64
+}
+
+// Every calls fn every interval milliseconds after main() returns, it returns the name of the loop
+func (g *Global) Every(interval interface{}, fn otto.Value) string {
+	g.loopSeq++
+	name := fmt.Sprintf("every#%v", g.loopSeq)
+	if !g.OnInterval(name, interval, fn) {
+		return ""
+	}
+	return name
+}
+
+// OnInterval calls fn every interval milliseconds after main() returns, a loop with the same name is replaced
+func (g *Global) OnInterval(name string, interval interface{}, fn otto.Value) bool {
+	ms := conver.Int64Must(interval)
+	if ms <= 0 {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "OnInterval(), Invalid interval")
+		return false
+	}
+	if !fn.IsFunction() {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "OnInterval(), Invalid function")
+		return false
+	}
+	g.addLoop(&loop{name: name, interval: time.Duration(ms) * time.Millisecond, fn: fn})
+	return true
+}
+
+// OnBar calls fn(records) when a new bar of the period appears, a loop of the same exchange, stock and period is replaced
+func (g *Global) OnBar(exchange api.Exchange, stock, period string, fn otto.Value) bool {
+	if exchange == nil {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "OnBar(), Invalid exchange")
+		return false
+	}
+	if !fn.IsFunction() {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, "OnBar(), Invalid function")
+		return false
+	}
+	name := fmt.Sprintf("bar#%v#%v#%v", exchange.GetName(), stock, period)
+	interval := onBarInterval
+	if g.backtest != nil {
+		interval = time.Duration(g.backtest.step) * time.Millisecond
+	}
+	g.addLoop(&loop{name: name, interval: interval, fn: fn, exchange: exchange, stock: stock, period: period})
+	return true
+}
+
+// Cancel removes the named loop
+func (g *Global) Cancel(name string) bool {
+	for i, l := range g.loops {
+		if l.name == name {
+			g.loops = append(g.loops[:i], g.loops[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Global) addLoop(l *loop) {
+	l.next = g.now()
+	g.Cancel(l.name)
+	g.loops = append(g.loops, l)
+}
+
+// runLoops drives the loops in the js goroutine until there is no loop or the trader is stopped
+func (g *Global) runLoops() {
+	for len(g.loops) > 0 {
+		next := g.loops[0]
+		for _, l := range g.loops {
+			if l.next.Before(next.next) {
+				next = l
+			}
+		}
+		if g.backtest != nil {
+			if g.backtest.advance(next.next.UnixNano() / int64(time.Millisecond)); g.halted() {
+				return
+			}
+		} else {
+			select {
+			case <-g.halt:
+				return
+			case cmd := <-g.commandQueue():
+				g.callCommand(cmd)
+				continue
+			case <-time.After(time.Until(next.next)):
+			}
+		}
+		next.next = g.now().Add(next.interval)
+		g.callLoop(next)
+	}
+}
+
+// callLoop calls the callback of a loop, errors are logged and do not stop the other loops
+func (g *Global) callLoop(l *loop) {
+	defer func() {
+		if err := recover(); err != nil {
+			if err == errHalt {
+				panic(err)
+			}
+			g.Logger.Log(constant.ERROR, "", 0.0, 0.0, l.name, " error, ", err)
+		}
+	}()
+	args := []interface{}{}
+	if l.exchange != nil {
+		records := l.exchange.GetRecords(l.stock, l.period)
+		rs, ok := records.([]api.Record)
+		if !ok || len(rs) == 0 || rs[len(rs)-1].Time == l.lastBar {
+			return
+		}
+		l.lastBar = rs[len(rs)-1].Time
+		args = append(args, records)
+	}
+	if _, err := l.fn.Call(l.fn, args...); err != nil && !g.halted() {
+		g.Logger.Log(constant.ERROR, "", 0.0, 0.0, l.name, " error, ", err)
+	}
+}
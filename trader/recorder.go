@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/config"
+	"github.com/miaolz123/conver"
+)
+
+// Record samples the order books declared in the [recorder] section of config.ini into the depth store,
+// like `okex = BTC/USDT:20:5, ETH/USDT`, the key is the name or the type of an exchange, the values are the stock
+// type, the depth (default 20) and the interval in seconds (default recorderInterval), every recorderKeyframe
+// samples are stored as a whole book
+func Record() {
+	interval := conver.Float64Must(config.String("recorderinterval"))
+	if interval <= 0 {
+		interval = 5
+	}
+	keyframe := conver.IntMust(config.String("recorderkeyframe"))
+	if keyframe <= 0 {
+		keyframe = 60
+	}
+	for key, value := range config.Section("recorder") {
+		opt := exchangeOption(key)
+		maker, ok := exchangeMaker[opt.Type]
+		if !ok {
+			log.Printf("Recorder error: unrecognized exchange %v\n", key)
+			continue
+		}
+		exchange := maker(opt)
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ":")
+			depth, seconds := 20, interval
+			if len(parts) > 1 {
+				depth = conver.IntMust(parts[1])
+			}
+			if len(parts) > 2 {
+				seconds = conver.Float64Must(parts[2])
+			}
+			if parts[0] == "" || len(parts) > 3 || depth <= 0 || seconds <= 0 {
+				log.Printf("Recorder error: invalid order book %v of %v\n", item, key)
+				continue
+			}
+			recorder := &api.DepthRecorder{Exchange: opt.Type, StockType: strings.ToUpper(parts[0]), Keyframe: keyframe}
+			go sample(exchange, recorder, depth, time.Duration(seconds*float64(time.Second)))
+		}
+	}
+}
+
+// sample records the order book of the exchange every interval
+func sample(exchange api.Exchange, recorder *api.DepthRecorder, depth int, interval time.Duration) {
+	for range time.Tick(interval) {
+		ticker, ok := exchange.GetTicker(recorder.StockType, depth).(api.Ticker)
+		if !ok {
+			continue
+		}
+		if err := recorder.Record(time.Now().UnixNano()/int64(time.Millisecond), ticker); err != nil {
+			log.Printf("Recorder error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"log"
+	"time"
+
+	"github.com/geniustag/QuantBot/config"
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// ValidateSchedule checks the start and stop cron expressions of a trader
+func ValidateSchedule(t model.Trader) (err error) {
+	for _, expr := range []string{t.StartCron, t.StopCron} {
+		if expr == "" {
+			continue
+		}
+		if _, err = parseCron(expr); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Schedule starts and stops the traders by their cron expressions every minute,
+// the time zone is the logsTimezone of the config
+func Schedule() {
+	loc, err := time.LoadLocation(config.String("logstimezone"))
+	if err != nil || loc == nil {
+		loc = time.Local
+	}
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		schedule(time.Now().In(loc))
+	}
+}
+
+// schedule runs the scheduled actions of the traders which match the time
+func schedule(now time.Time) {
+	traders := []model.Trader{}
+	if err := model.DB.Where("start_cron <> '' OR stop_cron <> ''").Find(&traders).Error; err != nil {
+		log.Println("Schedule error:", err)
+		return
+	}
+	for _, t := range traders {
+		logger := model.Logger{TraderID: t.ID, ExchangeType: "global"}
+		running := GetTraderStatus(t.ID) > 0
+		if c, err := parseCron(t.StopCron); err == nil && running && c.match(now) {
+			if err := stop(t.ID); err != nil {
+				logger.Log(constant.ERROR, "", 0.0, 0.0, "Scheduled stop error, ", err)
+			} else {
+				logger.Log(constant.INFO, "", 0.0, 0.0, "Scheduled stop by ", t.StopCron)
+			}
+			continue
+		}
+		if c, err := parseCron(t.StartCron); err == nil && !running && c.match(now) {
+			if err := run(t.ID); err != nil {
+				logger.Log(constant.ERROR, "", 0.0, 0.0, "Scheduled start error, ", err)
+			} else {
+				logger.Log(constant.INFO, "", 0.0, 0.0, "Scheduled start by ", t.StartCron)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package trader
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/config"
+	"github.com/geniustag/QuantBot/model"
+	"github.com/miaolz123/conver"
+)
+
+// Snapshot takes the account snapshots of all the exchanges periodically,
+// the interval (minutes) and the quote currency come from the config
+func Snapshot() {
+	interval := conver.Int64Must(config.String("snapshotinterval"))
+	if interval <= 0 {
+		return
+	}
+	quote := strings.ToUpper(config.String("snapshotquote"))
+	if quote == "" {
+		quote = "USDT"
+	}
+	for {
+		takeSnapshots(quote)
+		time.Sleep(time.Duration(interval) * time.Minute)
+	}
+}
+
+// takeSnapshots values the account of every exchange in the quote currency and saves it
+func takeSnapshots(quote string) {
+	exchanges := []model.Exchange{}
+	if err := model.DB.Find(&exchanges).Error; err != nil {
+		log.Println("Snapshot error:", err)
+		return
+	}
+	traderIDs := snapshotTraders()
+	now := time.Now().UnixNano()
+	for _, e := range exchanges {
+		maker, ok := exchangeMaker[e.Type]
+		if !ok {
+			continue
+		}
+		exchange := maker(api.Option{
+			TraderID:  traderIDs[e.ID],
+			Type:      e.Type,
+			Name:      e.Name,
+			AccessKey: e.AccessKey,
+			SecretKey: e.SecretKey,
+			Config:    e.Config,
+		})
+		account, ok := exchange.GetAccount().(map[string]float64)
+		if !ok {
+			log.Printf("Snapshot error: can not get the account of exchange %v\n", e.Name)
+			continue
+		}
+		amounts := make(map[string]float64)
+		for k, v := range account {
+			amounts[strings.ToUpper(strings.TrimPrefix(k, "Frozen"))] += v
+		}
+		for currency, amount := range amounts {
+			if amount == 0 {
+				continue
+			}
+			snapshot := model.Snapshot{
+				UserID:     e.UserID,
+				ExchangeID: e.ID,
+				Timestamp:  now,
+				Currency:   currency,
+				Quote:      quote,
+				Amount:     amount,
+				Price:      getPrice(exchange, currency, quote),
+			}
+			snapshot.Value = snapshot.Amount * snapshot.Price
+			if err := model.DB.Create(&snapshot).Error; err != nil {
+				log.Println("Snapshot error:", err)
+			}
+		}
+	}
+}
+
+// snapshotTraders returns the first trader of every exchange, the logs of a snapshot go to the trader of its exchange
+func snapshotTraders() map[int64]int64 {
+	traderIDs := make(map[int64]int64)
+	relations := []model.TraderExchange{}
+	if err := model.DB.Order("trader_id").Find(&relations).Error; err != nil {
+		log.Println("Snapshot error:", err)
+		return traderIDs
+	}
+	for _, r := range relations {
+		if _, ok := traderIDs[r.ExchangeID]; !ok {
+			traderIDs[r.ExchangeID] = r.TraderID
+		}
+	}
+	return traderIDs
+}
+
+// getPrice returns the price of the currency in the quote currency, 0 if the pair is not supported
+func getPrice(exchange api.Exchange, currency, quote string) float64 {
+	if currency == quote {
+		return 1.0
+	}
+	ticker, ok := exchange.GetTicker(currency + "/" + quote).(api.Ticker)
+	if !ok {
+		return 0.0
+	}
+	if ticker.Mid > 0 {
+		return ticker.Mid
+	}
+	return ticker.Buy
+}
@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geniustag/QuantBot/api"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// fakeExchange returns a simulated exchange with the balance and the close prices of the stocks
+func fakeExchange(balance map[string]float64, closes map[string]float64) api.Exchange {
+	opt := api.BacktestOption{
+		Type:    "fake",
+		Name:    "fake",
+		Period:  "M1",
+		Candles: make(map[string][]model.Candle),
+		Balance: balance,
+		Clock:   func() int64 { return 1e9 },
+		Logger:  model.Logger{Sink: func(model.Log) {}},
+	}
+	for stock, price := range closes {
+		opt.Candles[stock] = []model.Candle{{Timestamp: 0, Open: price, High: price, Low: price, Close: price}}
+	}
+	return api.NewBacktest(opt)
+}
+
+func TestGetPrice(t *testing.T) {
+	e := fakeExchange(nil, map[string]float64{"BTC/USDT": 6000})
+	tests := []struct {
+		currency, quote string
+		want            float64
+	}{
+		{"USDT", "USDT", 1},
+		{"BTC", "USDT", 6000},
+		{"ETH", "USDT", 0},
+	}
+	for _, tt := range tests {
+		if got := getPrice(e, tt.currency, tt.quote); got != tt.want {
+			t.Errorf("getPrice(%v, %v) = %v, want %v", tt.currency, tt.quote, got, tt.want)
+		}
+	}
+}
+
+func TestTakeSnapshots(t *testing.T) {
+	traderIDs := []int64{}
+	exchangeMaker["fake"] = func(opt api.Option) api.Exchange {
+		traderIDs = append(traderIDs, opt.TraderID)
+		return fakeExchange(map[string]float64{"BTC": 2, "USDT": 100, "ETH": 0, "LTC": 3}, map[string]float64{"BTC/USDT": 6000})
+	}
+	defer delete(exchangeMaker, "fake")
+	user := model.User{Username: "snapshot", Level: 99}
+	model.DB.Create(&user)
+	exchange := model.Exchange{UserID: user.ID, Type: "fake", Name: "fake"}
+	model.DB.Create(&exchange)
+	model.DB.Create(&model.Exchange{UserID: user.ID, Type: "unknown", Name: "unknown"})
+	model.DB.Create(&model.TraderExchange{TraderID: 8002, ExchangeID: exchange.ID})
+	model.DB.Create(&model.TraderExchange{TraderID: 8001, ExchangeID: exchange.ID})
+	takeSnapshots("USDT")
+	if len(traderIDs) != 1 || traderIDs[0] != 8001 {
+		t.Errorf("the snapshot exchanges are made of the traders %v, want [8001]", traderIDs)
+	}
+	portfolios, err := user.ListPortfolio(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(portfolios) != 1 {
+		t.Fatalf("%v portfolios, want 1", len(portfolios))
+	}
+	if portfolios[0].Value != 12100 {
+		t.Errorf("Value = %v, want 12100", portfolios[0].Value)
+	}
+	tests := []struct {
+		currency             string
+		amount, price, value float64
+	}{
+		{"BTC", 2, 6000, 12000},
+		{"USDT", 100, 1, 100},
+		{"LTC", 3, 0, 0},
+	}
+	if len(portfolios[0].Snapshots) != len(tests) {
+		t.Fatalf("%v snapshots, want %v", len(portfolios[0].Snapshots), len(tests))
+	}
+	for _, tt := range tests {
+		found := false
+		for _, s := range portfolios[0].Snapshots {
+			if s.Currency == tt.currency {
+				found = true
+				if s.Amount != tt.amount || s.Price != tt.price || s.Value != tt.value || s.Quote != "USDT" {
+					t.Errorf("%v snapshot = %+v, want %v %v %v", tt.currency, s, tt.amount, tt.price, tt.value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no snapshot of %v", tt.currency)
+		}
+	}
+}
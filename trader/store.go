@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/geniustag/QuantBot/constant"
+	"github.com/geniustag/QuantBot/model"
+)
+
+// storage is the persistent key-value storage of a trader, it survives restarts
+type storage struct {
+	traderID int64
+	logger   model.Logger
+	memory   map[string]string //不为空时保存在内存中, 回测使用
+}
+
+// Get returns the value of the key, or null if the key does not exist
+func (s *storage) Get(key string) interface{} {
+	if key == "" {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Get() error, key can not be empty")
+		return nil
+	}
+	data, ok := s.memory[key]
+	if s.memory == nil {
+		store, err := model.GetStore(s.traderID, key)
+		if err != nil {
+			return nil
+		}
+		data, ok = store.Value, true
+	}
+	if !ok {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Get() error, ", err)
+		return nil
+	}
+	return value
+}
+
+// Set saves the value of the key, the value must be able to encode to JSON
+func (s *storage) Set(key string, value interface{}) bool {
+	if key == "" {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Set() error, key can not be empty")
+		return false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Set() error, ", err)
+		return false
+	}
+	if s.memory != nil {
+		s.memory[key] = string(data)
+		return true
+	}
+	if err := model.SetStore(s.traderID, key, string(data)); err != nil {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Set() error, ", err)
+		return false
+	}
+	return true
+}
+
+// Delete removes the key
+func (s *storage) Delete(key string) bool {
+	if key == "" {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Delete() error, key can not be empty")
+		return false
+	}
+	if s.memory != nil {
+		delete(s.memory, key)
+		return true
+	}
+	if err := model.DeleteStore(s.traderID, key); err != nil {
+		s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Delete() error, ", err)
+		return false
+	}
+	return true
+}
+
+// Keys returns all the keys in order
+func (s *storage) Keys() []string {
+	keys := []string{}
+	if s.memory != nil {
+		for key := range s.memory {
+			keys = append(keys, key)
+		}
+	} else {
+		stores, err := model.ListStore(s.traderID)
+		if err != nil {
+			s.logger.Log(constant.ERROR, "", 0.0, 0.0, "Store.Keys() error, ", err)
+			return keys
+		}
+		for _, store := range stores {
+			keys = append(keys, store.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
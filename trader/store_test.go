@@ -0,0 +1,55 @@
+package trader
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geniustag/QuantBot/model"
+)
+
+func TestStorage(t *testing.T) {
+	stores := map[string]*storage{
+		"database": {traderID: 3000, logger: model.Logger{Sink: func(model.Log) {}}},
+		"memory":   {logger: model.Logger{Sink: func(model.Log) {}}, memory: make(map[string]string)},
+	}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			steps := []struct {
+				op    string
+				key   string
+				value interface{}
+				want  interface{}
+			}{
+				{"set", "b", map[string]interface{}{"x": 1}, true},
+				{"set", "a", "v", true},
+				{"set", "", "v", false},
+				{"get", "a", nil, "v"},
+				{"get", "b", nil, map[string]interface{}{"x": float64(1)}},
+				{"get", "missing", nil, nil},
+				{"get", "", nil, nil},
+				{"keys", "", nil, []string{"a", "b"}},
+				{"delete", "", nil, false},
+				{"keys", "", nil, []string{"a", "b"}},
+				{"delete", "a", nil, true},
+				{"get", "a", nil, nil},
+				{"keys", "", nil, []string{"b"}},
+			}
+			for i, step := range steps {
+				var got interface{}
+				switch step.op {
+				case "set":
+					got = s.Set(step.key, step.value)
+				case "get":
+					got = s.Get(step.key)
+				case "delete":
+					got = s.Delete(step.key)
+				case "keys":
+					got = s.Keys()
+				}
+				if !reflect.DeepEqual(got, step.want) {
+					t.Errorf("step %v, %v(%q) = %v, want %v", i, step.op, step.key, got, step.want)
+				}
+			}
+		})
+	}
+}
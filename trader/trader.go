@@ -2,24 +2,28 @@ package trader
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/geniustag/QuantBot/api"
 	"github.com/geniustag/QuantBot/constant"
 	"github.com/geniustag/QuantBot/model"
+	"github.com/geniustag/QuantBot/ta"
 	"github.com/robertkrimen/otto"
 )
 
 // Trader Variable
 var (
-	Executor      = make(map[int64]*Global) //保存正在运行的策略，防止重复运行
+	executors     = make(map[int64]*Global) //保存正在运行的策略，防止重复运行
+	executorsMu   sync.RWMutex              //保护 executors 和其中策略的 Status
 	errHalt       = fmt.Errorf("HALT")
 	exchangeMaker = map[string]func(api.Option) api.Exchange{ //保存所有交易所的构造函数
 		constant.Zb:         api.NewZb,
 		constant.Okex:       api.NewOKEX,
-		constant.OkexThree:  api.NewOKEXThree,
-		constant.Xnodes:     api.NewXnodes,
-		constant.Coffee:     api.NewCoffee,
+		constant.OkexThree:  api.NewOKEXV3,
+		constant.OkexV3:     api.NewOKEXV3,
+		constant.Xnodes:     api.NewOKEXV3,
+		constant.Coffee:     api.NewOKEXV3,
 		constant.Huobi:      api.NewHuobi,
 		constant.Binance:    api.NewBinance,
 		constant.GateIo:     api.NewGateIo,
@@ -29,14 +33,52 @@ var (
 	}
 )
 
+func init() {
+	for _, typ := range api.AdapterTypes() {
+		exchangeMaker[typ] = api.NewAdapter
+		constant.ExchangeTypes = append(constant.ExchangeTypes, typ)
+	}
+}
+
 // GetTraderStatus ...
 func GetTraderStatus(id int64) (status int64) {
-	if t, ok := Executor[id]; ok && t != nil {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	if t := executors[id]; t != nil {
 		status = t.Status
 	}
 	return
 }
 
+// getExecutor returns the trader of the id which is running or has run, or nil
+func getExecutor(id int64) *Global {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	return executors[id]
+}
+
+// getRunning returns the running trader of the id, or nil
+func getRunning(id int64) *Global {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	if t := executors[id]; t != nil && t.Status > 0 {
+		return t
+	}
+	return nil
+}
+
+// setExecutor saves the trader as the running one of its id, it fails if another trader of the id is running
+func setExecutor(t *Global) error {
+	executorsMu.Lock()
+	defer executorsMu.Unlock()
+	if old := executors[t.ID]; old != nil && old.Status > 0 {
+		return fmt.Errorf("The Trader is already running")
+	}
+	t.Status = 1
+	executors[t.ID] = t
+	return nil
+}
+
 // Switch ...
 func Switch(id int64) (err error) {
 	if GetTraderStatus(id) > 0 {
@@ -45,11 +87,13 @@ func Switch(id int64) (err error) {
 	return run(id)
 }
 
-//核心是初始化js运行环境，及其可以调用的api
-func initialize(id int64) (trader Global, err error) {
-	if t := Executor[id]; t != nil && t.Status > 0 {
+// 核心是初始化js运行环境，及其可以调用的api
+func initialize(id int64) (trader *Global, err error) {
+	if getRunning(id) != nil {
+		err = fmt.Errorf("The Trader is already running")
 		return
 	}
+	trader = &Global{}
 	err = model.DB.First(&trader.Trader, id).Error
 	if err != nil {
 		return
@@ -66,6 +110,12 @@ func initialize(id int64) (trader Global, err error) {
 	if err != nil {
 		return
 	}
+	version, err := trader.Algorithm.GetVersion(trader.Version)
+	if err != nil {
+		return
+	}
+	trader.Algorithm.Script = version.Script
+	trader.LastVersion = version.Version
 	es, err := self.GetTraderExchanges(trader.ID)
 	if err != nil {
 		return
@@ -74,12 +124,7 @@ func initialize(id int64) (trader Global, err error) {
 		TraderID:     trader.ID,
 		ExchangeType: "global",
 	}
-	trader.tasks = make(Tasks)
-	trader.ctx = otto.New()
-	trader.ctx.Interrupt = make(chan func(), 1)
-	for _, c := range constant.Consts {
-		trader.ctx.Set(c, c)
-	}
+	trader.Store = &storage{traderID: trader.ID, logger: trader.Logger}
 	for _, e := range es {
 		if maker, ok := exchangeMaker[e.Type]; ok {
 			opt := api.Option{
@@ -88,6 +133,7 @@ func initialize(id int64) (trader Global, err error) {
 				Name:      e.Name,
 				AccessKey: e.AccessKey,
 				SecretKey: e.SecretKey,
+				Config:    e.Config,
 			}
 			trader.es = append(trader.es, maker(opt))
 		}
@@ -96,70 +142,121 @@ func initialize(id int64) (trader Global, err error) {
 		err = fmt.Errorf("Please add at least one exchange")
 		return
 	}
-	trader.ctx.Set("Global", &trader)
-	trader.ctx.Set("G", &trader)
-	trader.ctx.Set("Exchange", trader.es[0])
-	trader.ctx.Set("E", trader.es[0])
-	trader.ctx.Set("Exchanges", trader.es)
-	trader.ctx.Set("Es", trader.es)
+	trader.bind()
 	return
 }
 
+// bind creates the js runtime of the trader and sets the objects which the script can use
+func (g *Global) bind() {
+	g.tasks = make(Tasks)
+	g.halt = make(chan struct{})
+	g.commands = make(chan string, commandSize)
+	g.ctx = otto.New()
+	g.ctx.Interrupt = make(chan func(), 1)
+	for _, c := range constant.Consts {
+		g.ctx.Set(c, c)
+	}
+	g.ctx.Set("Global", g)
+	g.ctx.Set("G", g)
+	g.ctx.Set("TA", ta.TA{})
+	g.ctx.Set("Exchange", g.es[0])
+	g.ctx.Set("E", g.es[0])
+	g.ctx.Set("Exchanges", g.es)
+	g.ctx.Set("Es", g.es)
+}
+
 // run ...
 func run(id int64) (err error) {
 	trader, err := initialize(id)
 	if err != nil {
 		return
 	}
+	if err = setExecutor(trader); err != nil {
+		return
+	}
+	trader.loadConditions()
+	go trader.watchConditions()
 	go func() {
 		defer func() {
 			if err := recover(); err != nil && err != errHalt {
 				trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
 			}
+			select {
+			case <-trader.ctx.Interrupt: //停止时 js 没有运行, 丢弃未处理的中断以便调用 exit
+			default:
+			}
 			if exit, err := trader.ctx.Get("exit"); err == nil && exit.IsFunction() {
 				if _, err := exit.Call(exit); err != nil {
 					trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
 				}
 			}
-			trader.Status = 0
+			trader.setStatus(0)
 		}()
 		trader.LastRunAt = time.Now()
-		trader.Status = 1
+		trader.setStatus(1)
+		model.DB.Model(&trader.Trader).UpdateColumns(map[string]interface{}{
+			"last_run_at":  trader.LastRunAt,
+			"last_version": trader.LastVersion,
+		})
 		if _, err := trader.ctx.Run(trader.Algorithm.Script); err != nil {
 			trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
 		}
 		if main, err := trader.ctx.Get("main"); err != nil || !main.IsFunction() {
-			trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Can not get the main function")
+			if len(trader.loops) == 0 {
+				trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, "Can not get the main function")
+			}
 		} else {
 			if _, err := main.Call(main); err != nil {
 				trader.Logger.Log(constant.ERROR, "", 0.0, 0.0, err)
 			}
 		}
+		trader.runLoops()
 	}()
-	Executor[trader.ID] = &trader
 	return
 }
 
-// getStatus ...
-//func getStatus(id int64) (status string) {
-//	if t := Executor[id]; t != nil {
-//		status = t.statusLog
-//	}
-//	return
-//}
+// setStatus changes the status of the trader and notifies the subscribers
+func (g *Global) setStatus(status int64) {
+	executorsMu.Lock()
+	g.Status = status
+	executorsMu.Unlock()
+	model.Publish(model.Event{TraderID: g.ID, Type: constant.EventStatus, Data: status})
+}
+
+// GetTraderPanel returns the latest status panel of the trader
+func GetTraderPanel(id int64) (panel Panel) {
+	if t := getExecutor(id); t != nil {
+		t.panelMu.Lock()
+		panel = t.panel
+		t.panelMu.Unlock()
+	}
+	return
+}
 
 // stop ...
 func stop(id int64) (err error) {
-	if t, ok := Executor[id]; !ok || t == nil {
+	t := getExecutor(id)
+	if t == nil {
 		return fmt.Errorf("Can not found the Trader")
 	}
-	Executor[id].ctx.Interrupt <- func() { panic(errHalt) }
+	t.interrupt()
 	return
 }
 
+// interrupt closes the halt channel and stops the running script, it is safe to call concurrently
+func (g *Global) interrupt() {
+	g.haltOnce.Do(func() {
+		close(g.halt)
+		select {
+		case g.ctx.Interrupt <- func() { panic(errHalt) }:
+		default:
+		}
+	})
+}
+
 // clean ...
 //func clean(userID int64) {
-//	for _, t := range Executor {
+//	for _, t := range executors {
 //		if t != nil && t.UserID == userID {
 //			stop(t.ID)
 //		}
@@ -0,0 +1,89 @@
+package trader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/geniustag/QuantBot/model"
+	"github.com/jinzhu/gorm"
+	"github.com/robertkrimen/otto"
+)
+
+// TestMain opens a temporary sqlite database for the tests
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "quantbot")
+	if err != nil {
+		panic(err)
+	}
+	if model.DB, err = gorm.Open("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	model.DB.AutoMigrate(&model.User{}, &model.Exchange{}, &model.Algorithm{}, &model.AlgorithmVersion{}, &model.TraderExchange{}, &model.Trader{}, &model.Log{}, &model.Snapshot{}, &model.Channel{}, &model.Store{}, &model.Candle{}, &model.Depth{}, &model.ConditionalOrder{}, &model.MarketTrade{})
+	code := m.Run()
+	model.DB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestSetExecutor(t *testing.T) {
+	const id = 9001
+	defer func() {
+		executorsMu.Lock()
+		delete(executors, id)
+		executorsMu.Unlock()
+	}()
+	started := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			g := &Global{}
+			g.ID = id
+			started <- setExecutor(g) == nil
+		}()
+	}
+	count := 0
+	for i := 0; i < 10; i++ {
+		if <-started {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("%v traders of the same id are started, want 1", count)
+	}
+	if GetTraderStatus(id) != 1 || getRunning(id) == nil {
+		t.Errorf("the trader is not running")
+	}
+	getExecutor(id).setStatus(0)
+	if getRunning(id) != nil || getExecutor(id) == nil {
+		t.Errorf("the stopped trader should be kept but not running")
+	}
+	g := &Global{}
+	g.ID = id
+	if err := setExecutor(g); err != nil {
+		t.Errorf("setExecutor() after stopped error = %v", err)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	g := &Global{halt: make(chan struct{}), ctx: otto.New()}
+	g.ctx.Interrupt = make(chan func(), 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.interrupt()
+		}()
+	}
+	wg.Wait()
+	select {
+	case <-g.halt:
+	default:
+		t.Errorf("the halt channel is not closed")
+	}
+	if len(g.ctx.Interrupt) != 1 {
+		t.Errorf("the script is interrupted %v times, want 1", len(g.ctx.Interrupt))
+	}
+}
@@ -168,6 +168,257 @@ export function TraderSwitch(req) {
   };
 }
 
+// Status
+
+function traderStatusRequest() {
+  return { type: actions.TRADER_STATUS_REQUEST };
+}
+
+function traderStatusSuccess(status, panel) {
+  return { type: actions.TRADER_STATUS_SUCCESS, status, panel };
+}
+
+function traderStatusFailure(message) {
+  return { type: actions.TRADER_STATUS_FAILURE, message };
+}
+
+export function TraderStatus(req) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderStatusRequest());
+    if (!cluster || !token) {
+      dispatch(traderStatusFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['Status'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.Status(req, (resp) => {
+      if (resp.success) {
+        dispatch(traderStatusSuccess(resp.data.status, resp.data.panel));
+      } else {
+        dispatch(traderStatusFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderStatusFailure('Server error'));
+      console.log('【Hprose】Trader.Status Error:', resp, err);
+    });
+  };
+}
+
+// Panel
+
+export function TraderPanel(panel) {
+  return { type: actions.TRADER_PANEL, panel };
+}
+
+// Command
+
+function traderCommandRequest() {
+  return { type: actions.TRADER_COMMAND_REQUEST };
+}
+
+function traderCommandSuccess() {
+  return { type: actions.TRADER_COMMAND_SUCCESS };
+}
+
+function traderCommandFailure(message) {
+  return { type: actions.TRADER_COMMAND_FAILURE, message };
+}
+
+export function TraderCommand(req, cmd) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderCommandRequest());
+    if (!cluster || !token) {
+      dispatch(traderCommandFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['Command'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.Command(req, cmd, (resp) => {
+      if (resp.success) {
+        dispatch(traderCommandSuccess());
+      } else {
+        dispatch(traderCommandFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderCommandFailure('Server error'));
+      console.log('【Hprose】Trader.Command Error:', resp, err);
+    });
+  };
+}
+
+// Store
+
+function traderStoreRequest() {
+  return { type: actions.TRADER_STORE_REQUEST };
+}
+
+function traderStoreSuccess(store) {
+  return { type: actions.TRADER_STORE_SUCCESS, store };
+}
+
+function traderStoreFailure(message) {
+  return { type: actions.TRADER_STORE_FAILURE, message };
+}
+
+export function TraderStore(req) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderStoreRequest());
+    if (!cluster || !token) {
+      dispatch(traderStoreFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['Store'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.Store(req, (resp) => {
+      if (resp.success) {
+        dispatch(traderStoreSuccess(resp.data));
+      } else {
+        dispatch(traderStoreFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderStoreFailure('Server error'));
+      console.log('【Hprose】Trader.Store Error:', resp, err);
+    });
+  };
+}
+
+export function TraderStoreSet(req, key, value) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderStoreRequest());
+    if (!cluster || !token) {
+      dispatch(traderStoreFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['StoreSet'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.StoreSet(req, key, value, (resp) => {
+      if (resp.success) {
+        dispatch(TraderStore(req));
+      } else {
+        dispatch(traderStoreFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderStoreFailure('Server error'));
+      console.log('【Hprose】Trader.StoreSet Error:', resp, err);
+    });
+  };
+}
+
+export function TraderStoreDelete(req, key) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderStoreRequest());
+    if (!cluster || !token) {
+      dispatch(traderStoreFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['StoreDelete'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.StoreDelete(req, key, (resp) => {
+      if (resp.success) {
+        dispatch(TraderStore(req));
+      } else {
+        dispatch(traderStoreFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderStoreFailure('Server error'));
+      console.log('【Hprose】Trader.StoreDelete Error:', resp, err);
+    });
+  };
+}
+
+// Conditional orders
+
+function traderConditionalRequest() {
+  return { type: actions.TRADER_CONDITIONAL_REQUEST };
+}
+
+function traderConditionalSuccess(conditionalOrders) {
+  return { type: actions.TRADER_CONDITIONAL_SUCCESS, conditionalOrders };
+}
+
+function traderConditionalFailure(message) {
+  return { type: actions.TRADER_CONDITIONAL_FAILURE, message };
+}
+
+export function TraderConditionalOrders(req) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderConditionalRequest());
+    if (!cluster || !token) {
+      dispatch(traderConditionalFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['ConditionalOrders'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.ConditionalOrders(req, (resp) => {
+      if (resp.success) {
+        dispatch(traderConditionalSuccess(resp.data || []));
+      } else {
+        dispatch(traderConditionalFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderConditionalFailure('Server error'));
+      console.log('【Hprose】Trader.ConditionalOrders Error:', resp, err);
+    });
+  };
+}
+
+export function TraderConditionalOrderCancel(req, id) {
+  return (dispatch, getState) => {
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    dispatch(traderConditionalRequest());
+    if (!cluster || !token) {
+      dispatch(traderConditionalFailure('No authorization'));
+      return;
+    }
+
+    const client = Client.create(`${cluster}/api`, { Trader: ['CancelConditionalOrder'] });
+
+    client.setHeader('Authorization', `Bearer ${token}`);
+    client.Trader.CancelConditionalOrder(req, id, (resp) => {
+      if (resp.success) {
+        dispatch(TraderConditionalOrders(req));
+      } else {
+        dispatch(traderConditionalFailure(resp.message));
+      }
+    }, (resp, err) => {
+      dispatch(traderConditionalFailure('Server error'));
+      console.log('【Hprose】Trader.CancelConditionalOrder Error:', resp, err);
+    });
+  };
+}
+
 // Cache
 
 export function TraderCache(cache) {
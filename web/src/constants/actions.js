@@ -72,6 +72,24 @@ export const TRADER_DELETE_FAILURE = 'TRADER_DELETE_FAILURE';
 export const TRADER_SWITCH_REQUEST = 'TRADER_SWITCH_REQUEST';
 export const TRADER_SWITCH_SUCCESS = 'TRADER_SWITCH_SUCCESS';
 export const TRADER_SWITCH_FAILURE = 'TRADER_SWITCH_FAILURE';
+// Trader.Status
+export const TRADER_STATUS_REQUEST = 'TRADER_STATUS_REQUEST';
+export const TRADER_STATUS_SUCCESS = 'TRADER_STATUS_SUCCESS';
+export const TRADER_STATUS_FAILURE = 'TRADER_STATUS_FAILURE';
+// Trader.Panel
+export const TRADER_PANEL = 'TRADER_PANEL';
+
+export const TRADER_COMMAND_REQUEST = 'TRADER_COMMAND_REQUEST';
+export const TRADER_COMMAND_SUCCESS = 'TRADER_COMMAND_SUCCESS';
+export const TRADER_COMMAND_FAILURE = 'TRADER_COMMAND_FAILURE';
+
+export const TRADER_STORE_REQUEST = 'TRADER_STORE_REQUEST';
+export const TRADER_STORE_SUCCESS = 'TRADER_STORE_SUCCESS';
+export const TRADER_STORE_FAILURE = 'TRADER_STORE_FAILURE';
+
+export const TRADER_CONDITIONAL_REQUEST = 'TRADER_CONDITIONAL_REQUEST';
+export const TRADER_CONDITIONAL_SUCCESS = 'TRADER_CONDITIONAL_SUCCESS';
+export const TRADER_CONDITIONAL_FAILURE = 'TRADER_CONDITIONAL_FAILURE';
 // Trader.Cache
 export const TRADER_CACHE = 'TRADER_CACHE';
 
@@ -1,9 +1,10 @@
 import { ResetError } from '../actions';
 import { LogList } from '../actions/log';
+import { TraderStatus, TraderPanel, TraderCommand, TraderStore, TraderStoreSet, TraderStoreDelete, TraderConditionalOrders, TraderConditionalOrderCancel } from '../actions/trader';
 import React from 'react';
 import { connect } from 'react-redux';
 import { browserHistory } from 'react-router';
-import { Button, Table, Tag, notification } from 'antd';
+import { Button, Input, Modal, Popconfirm, Table, Tag, notification } from 'antd';
 
 class Log extends React.Component {
   constructor(props) {
@@ -17,10 +18,21 @@ class Log extends React.Component {
         total: 0,
       },
       filters: {},
+      command: '',
+      storeShow: false,
+      storeKey: '',
+      storeValue: '',
+      conditionalShow: false,
     };
 
     this.reload = this.reload.bind(this);
     this.handleTableChange = this.handleTableChange.bind(this);
+    this.handleCommand = this.handleCommand.bind(this);
+    this.handleStoreShow = this.handleStoreShow.bind(this);
+    this.handleStoreCancel = this.handleStoreCancel.bind(this);
+    this.handleStoreSave = this.handleStoreSave.bind(this);
+    this.handleConditionalShow = this.handleConditionalShow.bind(this);
+    this.handleConditionalCancel = this.handleConditionalCancel.bind(this);
   }
 
   componentWillReceiveProps(nextProps) {
@@ -32,14 +44,14 @@ class Log extends React.Component {
       browserHistory.push('/algorithm');
     }
 
-    if (!messageErrorKey && log.message) {
+    if (!messageErrorKey && (log.message || trader.message)) {
       this.setState({
         messageErrorKey: 'logError',
       });
       notification['error']({
         key: 'logError',
         message: 'Error',
-        description: String(log.message),
+        description: String(log.message || trader.message),
         onClose: () => {
           if (this.state.messageErrorKey) {
             this.setState({ messageErrorKey: '' });
@@ -53,14 +65,44 @@ class Log extends React.Component {
   }
 
   componentWillMount() {
+    const { trader, dispatch } = this.props;
+
     this.filters = {};
     this.reload();
+    this.subscribe();
+    dispatch(TraderStatus(trader.cache));
   }
 
   componentWillUnmount() {
+    if (this.source) {
+      this.source.close();
+    }
+    clearTimeout(this.reloadTimer);
     notification.destroy();
   }
 
+  subscribe() {
+    const { trader, dispatch } = this.props;
+    const cluster = localStorage.getItem('cluster');
+    const token = localStorage.getItem('token');
+
+    if (!window.EventSource || !cluster || !token || !trader.cache.id) {
+      return;
+    }
+    this.source = new EventSource(`${cluster}/api/stream?trader=${trader.cache.id}&token=${encodeURIComponent(token)}`);
+    this.source.addEventListener('log', () => {
+      if (this.state.pagination.current === 1 && !this.reloadTimer) {
+        this.reloadTimer = setTimeout(() => {
+          this.reloadTimer = null;
+          this.reload();
+        }, 1000);
+      }
+    });
+    this.source.addEventListener('panel', (e) => {
+      dispatch(TraderPanel(JSON.parse(e.data).data));
+    });
+  }
+
   reload() {
     const { pagination } = this.state;
     const { trader, dispatch } = this.props;
@@ -81,8 +123,229 @@ class Log extends React.Component {
     browserHistory.push('/algorithm');
   }
 
+  handleCommand() {
+    const { command } = this.state;
+    const { trader, dispatch } = this.props;
+
+    if (!command) {
+      return;
+    }
+    dispatch(TraderCommand(trader.cache, command));
+    this.setState({ command: '' });
+  }
+
+  handleStoreShow() {
+    const { trader, dispatch } = this.props;
+
+    dispatch(TraderStore(trader.cache));
+    this.setState({ storeShow: true, storeKey: '', storeValue: '' });
+  }
+
+  handleStoreCancel() {
+    this.setState({ storeShow: false });
+  }
+
+  handleStoreEdit(record) {
+    this.setState({ storeKey: record.key, storeValue: record.value });
+  }
+
+  handleStoreSave() {
+    const { storeKey, storeValue } = this.state;
+    const { trader, dispatch } = this.props;
+
+    try {
+      JSON.parse(storeValue);
+    } catch (e) {
+      notification['error']({
+        message: 'Error',
+        description: 'Value must be valid JSON',
+      });
+      return;
+    }
+    dispatch(TraderStoreSet(trader.cache, storeKey, storeValue));
+    this.setState({ storeKey: '', storeValue: '' });
+  }
+
+  handleStoreDelete(record) {
+    const { trader, dispatch } = this.props;
+
+    dispatch(TraderStoreDelete(trader.cache, record.key));
+  }
+
+  renderStore() {
+    const { storeShow, storeKey, storeValue } = this.state;
+    const { trader } = this.props;
+    const columns = [{
+      width: 160,
+      title: 'Key',
+      dataIndex: 'key',
+    }, {
+      title: 'Value',
+      dataIndex: 'value',
+    }, {
+      width: 120,
+      title: 'Action',
+      key: 'action',
+      render: (v, r) => (
+        <span>
+          <a onClick={this.handleStoreEdit.bind(this, r)}>Edit</a>
+          <span className="ant-divider" />
+          <Popconfirm title="Sure to delete?" onConfirm={this.handleStoreDelete.bind(this, r)}>
+            <a>Delete</a>
+          </Popconfirm>
+        </span>
+      ),
+    }];
+
+    return (
+      <Modal closable
+        maskClosable={false}
+        width="50%"
+        title={`Store - ${trader.cache.name}`}
+        visible={storeShow}
+        okText="Save"
+        onOk={this.handleStoreSave}
+        onCancel={this.handleStoreCancel}
+      >
+        <Table rowKey="id"
+          size="small"
+          columns={columns}
+          dataSource={trader.store}
+          pagination={false}
+          loading={trader.loading}
+        />
+        <Input style={{ marginTop: 16 }}
+          placeholder="Key"
+          value={storeKey}
+          onChange={(e) => this.setState({ storeKey: e.target.value })}
+        />
+        <Input style={{ marginTop: 8 }}
+          type="textarea"
+          rows={4}
+          placeholder="JSON Value"
+          value={storeValue}
+          onChange={(e) => this.setState({ storeValue: e.target.value })}
+        />
+      </Modal>
+    );
+  }
+
+  handleConditionalShow() {
+    const { trader, dispatch } = this.props;
+
+    dispatch(TraderConditionalOrders(trader.cache));
+    this.setState({ conditionalShow: true });
+  }
+
+  handleConditionalCancel() {
+    this.setState({ conditionalShow: false });
+  }
+
+  handleConditionalDelete(record) {
+    const { trader, dispatch } = this.props;
+
+    dispatch(TraderConditionalOrderCancel(trader.cache, record.id));
+  }
+
+  renderConditional() {
+    const { conditionalShow } = this.state;
+    const { trader } = this.props;
+    const columns = [{
+      width: 60,
+      title: 'ID',
+      dataIndex: 'id',
+    }, {
+      width: 100,
+      title: 'Exchange',
+      dataIndex: 'exchange',
+    }, {
+      width: 110,
+      title: 'Type',
+      dataIndex: 'type',
+      render: (v, r) => <Tag color={r.tradeType === 'BUY' ? '#4682B4' : '#F50F50'}>{`${r.tradeType} ${v}`}</Tag>,
+    }, {
+      width: 100,
+      title: 'Stock',
+      dataIndex: 'stockType',
+    }, {
+      width: 80,
+      title: 'Amount',
+      dataIndex: 'amount',
+    }, {
+      width: 100,
+      title: 'Stop Price',
+      dataIndex: 'stopPrice',
+      render: (v, r) => (r.type === 'TRAILING' ? `${v ? v.toFixed(4) : '-'} (${r.trailing * 100}%)` : v),
+    }, {
+      width: 60,
+      title: 'OCO',
+      dataIndex: 'oco',
+      render: (v) => v || '',
+    }, {
+      title: 'Status',
+      dataIndex: 'status',
+      render: (v, r) => `${v}${r.message ? `, ${r.message}` : ''}`,
+    }, {
+      width: 80,
+      title: 'Action',
+      key: 'action',
+      render: (v, r) => (r.status === 'waiting' ? (
+        <Popconfirm title="Sure to cancel?" onConfirm={this.handleConditionalDelete.bind(this, r)}>
+          <a>Cancel</a>
+        </Popconfirm>
+      ) : null),
+    }];
+
+    return (
+      <Modal closable
+        width="70%"
+        title={`Conditional Orders - ${trader.cache.name}`}
+        visible={conditionalShow}
+        footer={null}
+        onCancel={this.handleConditionalCancel}
+      >
+        <Table rowKey="id"
+          size="small"
+          columns={columns}
+          dataSource={trader.conditionalOrders}
+          pagination={false}
+          loading={trader.loading}
+        />
+      </Modal>
+    );
+  }
+
+  renderPanel() {
+    const { panel } = this.props.trader;
+
+    if (!panel || (!panel.text && !(panel.tables && panel.tables.length))) {
+      return null;
+    }
+    return (
+      <div className="status-panel">
+        {panel.text ? <pre>{panel.text}</pre> : null}
+        {(panel.tables || []).map((table, i) => (
+          <Table key={i}
+            size="small"
+            title={table.title ? () => table.title : null}
+            columns={(table.columns || []).map((c, j) => ({ title: c, dataIndex: String(j), key: j }))}
+            dataSource={(table.rows || []).map((row, j) => {
+              const data = { key: j };
+
+              row.forEach((v, k) => {
+                data[k] = typeof v === 'object' ? JSON.stringify(v) : String(v);
+              });
+              return data;
+            })}
+            pagination={false}
+          />
+        ))}
+      </div>
+    );
+  }
+
   render() {
-    const { pagination } = this.state;
+    const { pagination, command } = this.state;
     const { log } = this.props;
     const colors = {
       'INFO': '#A9A9A9',
@@ -122,8 +385,20 @@ class Log extends React.Component {
       <div>
         <div className="table-operations">
           <Button type="primary" onClick={this.reload}>Reload</Button>
+          <Button onClick={this.handleStoreShow}>Store</Button>
+          <Button onClick={this.handleConditionalShow}>Conditional Orders</Button>
           <Button type="ghost" onClick={this.handleCancel}>Back</Button>
+          <Input style={{ width: 240 }}
+            placeholder="Command"
+            value={command}
+            onChange={(e) => this.setState({ command: e.target.value })}
+            onPressEnter={this.handleCommand}
+          />
+          <Button onClick={this.handleCommand}>Send</Button>
         </div>
+        {this.renderPanel()}
+        {this.renderStore()}
+        {this.renderConditional()}
         <Table rowKey="id"
           columns={columns}
           dataSource={log.list}
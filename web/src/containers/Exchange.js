@@ -119,6 +119,7 @@ class Exchange extends React.Component {
         type: '',
         accessKey: '',
         secretKey: '',
+        config: '',
       };
     }
     this.setState({ info, infoModalShow: true });
@@ -138,6 +139,7 @@ class Exchange extends React.Component {
         type: values.type,
         accessKey: values.accessKey,
         secretKey: values.secretKey,
+        config: values.config,
       };
 
       dispatch(ExchangePut(req, pagination.pageSize, pagination.current, this.order));
@@ -254,6 +256,16 @@ class Exchange extends React.Component {
                 <Input />
               )}
             </FormItem>
+            <FormItem
+              {...formItemLayout}
+              label="Config"
+            >
+              {getFieldDecorator('config', {
+                initialValue: info.config,
+              })(
+                <Input type="textarea" rows={4} placeholder='JSON, e.g. {"host": "https://www.okex.com/api/spot/v3", "passphrase": ""}' />
+              )}
+            </FormItem>
             <Form.Item wrapperCol={{ span: 12, offset: 7 }} style={{ marginTop: 24 }}>
               <Button type="primary" onClick={this.handleInfoSubmit} loading={exchange.loading}>Submit</Button>
             </Form.Item>
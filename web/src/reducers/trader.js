@@ -5,6 +5,10 @@ const TRADER_INIT = {
   loading: false,
   map: {},
   cache: {},
+  status: 0,
+  panel: {},
+  store: [],
+  conditionalOrders: [],
   message: '',
 };
 
@@ -72,6 +76,66 @@ function trader(state = TRADER_INIT, action) {
         loading: false,
         message: action.message,
       });
+    case actions.TRADER_STATUS_REQUEST:
+      return assign({}, state, {
+        loading: true,
+      });
+    case actions.TRADER_STATUS_SUCCESS:
+      return assign({}, state, {
+        loading: false,
+        status: action.status,
+        panel: action.panel,
+      });
+    case actions.TRADER_STATUS_FAILURE:
+      return assign({}, state, {
+        loading: false,
+        message: action.message,
+      });
+    case actions.TRADER_PANEL:
+      return assign({}, state, {
+        panel: action.panel,
+      });
+    case actions.TRADER_COMMAND_REQUEST:
+      return assign({}, state, {
+        loading: true,
+      });
+    case actions.TRADER_COMMAND_SUCCESS:
+      return assign({}, state, {
+        loading: false,
+      });
+    case actions.TRADER_COMMAND_FAILURE:
+      return assign({}, state, {
+        loading: false,
+        message: action.message,
+      });
+    case actions.TRADER_STORE_REQUEST:
+      return assign({}, state, {
+        loading: true,
+      });
+    case actions.TRADER_STORE_SUCCESS:
+      return assign({}, state, {
+        loading: false,
+        store: action.store,
+      });
+    case actions.TRADER_STORE_FAILURE:
+      return assign({}, state, {
+        loading: false,
+        message: action.message,
+      });
+    case actions.TRADER_CONDITIONAL_REQUEST:
+      return assign({}, state, {
+        loading: true,
+      });
+    case actions.TRADER_CONDITIONAL_SUCCESS:
+      return assign({}, state, {
+        loading: false,
+        conditionalOrders: action.conditionalOrders,
+      });
+    case actions.TRADER_CONDITIONAL_FAILURE:
+      return assign({}, state, {
+        loading: false,
+        message: action.message,
+      });
     case actions.TRADER_CACHE:
       return assign({}, state, {
         cache: action.cache,